@@ -0,0 +1,78 @@
+//go:build fuse
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/fuse"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// MountCmd represents the mount command
+var MountCmd = &cobra.Command{
+	Use:   "mount [mountpoint]",
+	Short: "Mount the virtual file tree to a local directory with FUSE",
+	Long: `Mount the virtual file tree to a local directory with FUSE.
+The mount is read-write, all operations are performed on behalf of the given user
+(the admin user by default). It requires libfuse (Linux), macFUSE (macOS) or WinFsp (Windows),
+and a binary built with the "fuse" build tag.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("mountpoint is required")
+		}
+		mountSrc, _ := cmd.Flags().GetString("path")
+		username, _ := cmd.Flags().GetString("user")
+		opts, _ := cmd.Flags().GetStringArray("option")
+		bootstrap.Init()
+		defer bootstrap.Release()
+		user, err := op.GetAdmin()
+		if username != "" {
+			user, err = op.GetUserByName(username)
+		}
+		if err != nil {
+			return fmt.Errorf("failed get user: %+v", err)
+		}
+		if user.Disabled {
+			return fmt.Errorf("user [%s] is disabled", user.Username)
+		}
+		bootstrap.LoadStorages()
+		bootstrap.InitTaskManager()
+		fuseOpts := make([]string, 0, len(opts)*2)
+		for _, o := range opts {
+			fuseOpts = append(fuseOpts, "-o", o)
+		}
+		m := fuse.Mount(mountSrc, args[0], user, fuseOpts)
+		utils.Log.Infof("mount [%s] to %s", mountSrc, args[0])
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		done := make(chan bool, 1)
+		go func() {
+			done <- m.Wait()
+		}()
+		select {
+		case <-quit:
+			m.Unmount()
+			<-done
+		case ok := <-done:
+			if !ok {
+				return fmt.Errorf("failed mount to %s", args[0])
+			}
+		}
+		utils.Log.Infof("unmounted %s", args[0])
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+	MountCmd.Flags().String("path", "/", "the path of the virtual file tree to mount")
+	MountCmd.Flags().String("user", "", "the user to perform operations as, defaults to the admin user")
+	MountCmd.Flags().StringArrayP("option", "o", nil, "extra FUSE mount options, e.g. -o allow_other")
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	queueSize     = 1024
+	batchSize     = 100
+	flushInterval = time.Second
+)
+
+var (
+	queue     = make(chan *model.AuditLog, queueSize)
+	flushReq  = make(chan chan struct{})
+	startOnce sync.Once
+)
+
+// Record appends an entry to the audit trail.
+// The user, client ip and protocol are taken from ctx, the entry is written asynchronously.
+func Record(ctx context.Context, action, src, dst string, size int64, err error) {
+	result := model.AuditSuccess
+	if err != nil {
+		result = model.AuditFailed
+	}
+	RecordResult(ctx, action, src, dst, size, result, err)
+}
+
+// RecordResult is like Record but with an explicit result, e.g. model.AuditQueued
+func RecordResult(ctx context.Context, action, src, dst string, size int64, result string, err error) {
+	if !setting.GetBool(conf.AuditLogEnabled) {
+		return
+	}
+	entry := &model.AuditLog{
+		Time:     time.Now(),
+		Protocol: model.ProtocolWeb,
+		Action:   action,
+		Src:      src,
+		Dst:      dst,
+		Size:     size,
+		Result:   result,
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		entry.UserId = user.ID
+		entry.Username = user.Username
+	}
+	if ip, ok := ctx.Value(conf.ClientIPKey).(string); ok {
+		entry.IP = ip
+	}
+	if protocol, ok := ctx.Value(conf.ProtocolKey).(string); ok && protocol != "" {
+		entry.Protocol = protocol
+	}
+	if err != nil {
+		entry.Message = err.Error()
+	}
+	startOnce.Do(start)
+	select {
+	case queue <- entry:
+	default:
+		// the queue is full, don't lose the entry
+		if e := db.CreateAuditLogs([]*model.AuditLog{entry}); e != nil {
+			log.Errorf("failed write audit log: %+v", e)
+		}
+	}
+}
+
+func start() {
+	go worker()
+}
+
+func worker() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]*model.AuditLog, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := db.CreateAuditLogs(batch); err != nil {
+			log.Errorf("failed write %d audit logs: %+v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case entry := <-queue:
+			batch = append(batch, entry)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case done := <-flushReq:
+			for len(queue) > 0 {
+				batch = append(batch, <-queue)
+				if len(batch) >= batchSize {
+					flush()
+				}
+			}
+			flush()
+			close(done)
+		}
+	}
+}
+
+// Flush writes the queued entries, it's called on shutdown so that the last entries aren't lost
+func Flush() {
+	startOnce.Do(start)
+	done := make(chan struct{})
+	select {
+	case flushReq <- done:
+		<-done
+	case <-time.After(10 * time.Second):
+		log.Warnf("timeout flushing %d audit logs", len(queue))
+	}
+}
+
+// Cleanup removes the logs older than the retention days, 0 means keep forever
+func Cleanup() {
+	days := setting.GetInt(conf.AuditLogRetentionDays, 0)
+	if days <= 0 {
+		return
+	}
+	n, err := db.DeleteAuditLogsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Errorf("failed cleanup audit logs: %+v", err)
+		return
+	}
+	if n > 0 {
+		log.Infof("removed %d audit logs older than %d days", n, days)
+	}
+}
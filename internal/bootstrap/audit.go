@@ -0,0 +1,13 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+func InitAudit() {
+	audit.Cleanup()
+	cron.NewCron(time.Hour * 24).Do(audit.Cleanup)
+}
@@ -0,0 +1,25 @@
+package v4_1_10
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// HashSharingPwd replaces the plaintext passwords of the sharings with their hashes
+func HashSharingPwd() {
+	sharings, _, err := db.GetSharings(1, -1)
+	if err != nil {
+		utils.Log.Errorf("[hash sharing pwd] failed get sharings: %v", err)
+		return
+	}
+	for i := range sharings {
+		s := sharings[i]
+		if s.Pwd == "" || s.PwdHash != "" {
+			continue
+		}
+		s.SetPwd(s.Pwd)
+		if err := db.UpdateSharing(&s); err != nil {
+			utils.Log.Errorf("[hash sharing pwd] failed update sharing %s: %v", s.ID, err)
+		}
+	}
+}
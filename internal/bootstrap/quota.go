@@ -0,0 +1,18 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitQuota() {
+	cron.NewCron(time.Hour * 24).Do(func() {
+		if err := op.ReconcileQuotas(context.Background()); err != nil {
+			log.Errorf("failed reconcile quotas: %+v", err)
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/rss"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+func InitRss() {
+	// the due subscriptions are checked every minute, each of them has its own interval
+	cron.NewCron(time.Minute).Do(func() {
+		rss.CheckDue(context.Background())
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/data"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
@@ -40,6 +41,7 @@ func Init() {
 }
 
 func Release() {
+	audit.Flush()
 	db.Close()
 }
 
@@ -92,6 +94,13 @@ func Start() {
 	InitOfflineDownloadTools()
 	LoadStorages()
 	InitTaskManager()
+	InitAudit()
+	InitQuota()
+	InitTrash()
+	InitVersions()
+	InitStorageHealth()
+	InitSyncJobs()
+	InitRss()
 	if !flags.Debug && !flags.Dev {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -0,0 +1,18 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/health"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+func InitStorageHealth() {
+	// the due storages are checked every minute, so that the interval can be changed at runtime
+	cron.NewCron(time.Minute).Do(func() {
+		health.CheckDue(context.Background())
+	})
+	health.Cleanup()
+	cron.NewCron(time.Hour * 24).Do(health.Cleanup)
+}
@@ -0,0 +1,15 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+func InitTrash() {
+	cron.NewCron(time.Hour * 24).Do(func() {
+		fs.PurgeExpiredTrash(context.Background())
+	})
+}
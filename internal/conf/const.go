@@ -59,6 +59,10 @@ const (
 	HandleHookAfterWriting  = "handle_hook_after_writing"
 	HandleHookRateLimit     = "handle_hook_rate_limit"
 	IgnoreSystemFiles       = "ignore_system_files"
+	AuditLogEnabled         = "audit_log_enabled"
+	AuditLogRetentionDays   = "audit_log_retention_days"
+	StorageHealthInterval   = "storage_health_interval"
+	StorageHealthRetention  = "storage_health_retention_days"
 
 	// index
 	SearchIndex     = "search_index"
@@ -66,6 +70,9 @@ const (
 	IgnorePaths     = "ignore_paths"
 	MaxIndexDepth   = "max_index_depth"
 
+	// simple http
+	SimpleHttpConnections = "simple_http_connections"
+
 	// aria2
 	Aria2Uri    = "aria2_uri"
 	Aria2Secret = "aria2_secret"
@@ -74,6 +81,11 @@ const (
 	TransmissionUri      = "transmission_uri"
 	TransmissionSeedtime = "transmission_seedtime"
 
+	// bittorrent
+	TorrentListenPort = "torrent_listen_port"
+	TorrentSeedRatio  = "torrent_seed_ratio"
+	TorrentSeedtime   = "torrent_seedtime"
+
 	// 115
 	Pan115TempDir = "115_temp_dir"
 
@@ -157,6 +169,7 @@ const (
 	TaskMoveThreadsNum                    = "move_task_threads_num"
 	TaskDecompressDownloadThreadsNum      = "decompress_download_task_threads_num"
 	TaskDecompressUploadThreadsNum        = "decompress_upload_task_threads_num"
+	TaskCompressThreadsNum                = "compress_task_threads_num"
 	StreamMaxClientDownloadSpeed          = "max_client_download_speed"
 	StreamMaxClientUploadSpeed            = "max_client_upload_speed"
 	StreamMaxServerDownloadSpeed          = "max_server_download_speed"
@@ -191,4 +204,9 @@ const (
 	PathKey
 	SharingIDKey
 	SkipHookKey
+	ApiTokenKey
+	ProtocolKey
+	S3CredentialKey
+	SharingKey
+	SkipObjEventKey
 )
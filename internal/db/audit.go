@@ -0,0 +1,66 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateAuditLogs(logs []*model.AuditLog) error {
+	return errors.WithStack(db.CreateInBatches(logs, 100).Error)
+}
+
+func filterAuditLogs(tx *gorm.DB, f *model.AuditLogFilter) *gorm.DB {
+	if f.Username != "" {
+		tx = tx.Where("username = ?", f.Username)
+	}
+	if f.IP != "" {
+		tx = tx.Where("ip = ?", f.IP)
+	}
+	if f.Protocol != "" {
+		tx = tx.Where("protocol = ?", f.Protocol)
+	}
+	if f.Action != "" {
+		tx = tx.Where("action = ?", f.Action)
+	}
+	if f.Result != "" {
+		tx = tx.Where("result = ?", f.Result)
+	}
+	if f.Path != "" {
+		like := "%" + f.Path + "%"
+		tx = tx.Where("(src LIKE ? OR dst LIKE ?)", like, like)
+	}
+	if !f.Start.IsZero() {
+		tx = tx.Where(columnName("time")+" >= ?", f.Start)
+	}
+	if !f.End.IsZero() {
+		tx = tx.Where(columnName("time")+" <= ?", f.End)
+	}
+	return tx
+}
+
+func GetAuditLogs(f *model.AuditLogFilter, pageIndex, pageSize int) (logs []model.AuditLog, count int64, err error) {
+	logDB := filterAuditLogs(db.Model(&model.AuditLog{}), f)
+	if err := logDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err := logDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+// WalkAuditLogs calls fn on every log matching the filter in batches, in the order of time
+func WalkAuditLogs(f *model.AuditLogFilter, fn func(logs []model.AuditLog) error) error {
+	var logs []model.AuditLog
+	return errors.WithStack(filterAuditLogs(db.Model(&model.AuditLog{}), f).FindInBatches(&logs, 500, func(tx *gorm.DB, batch int) error {
+		return fn(logs)
+	}).Error)
+}
+
+func DeleteAuditLogsBefore(t time.Time) (int64, error) {
+	res := db.Where(columnName("time")+" < ?", t).Delete(&model.AuditLog{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
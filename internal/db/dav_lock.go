@@ -0,0 +1,71 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// liveDavLocks selects the locks not expired at now
+func liveDavLocks(now time.Time) *gorm.DB {
+	return db.Where(fmt.Sprintf("(%s = 0 OR %s > ?)", columnName("expiry"), columnName("expiry")), now.UnixMilli())
+}
+
+func CreateDavLock(l *model.DavLock) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+func UpdateDavLock(l *model.DavLock) error {
+	return errors.WithStack(db.Save(l).Error)
+}
+
+func GetDavLockByToken(token string, now time.Time) (*model.DavLock, error) {
+	var l model.DavLock
+	if err := liveDavLocks(now).Where(fmt.Sprintf("%s = ?", columnName("token")), token).
+		First(&l).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dav lock")
+	}
+	return &l, nil
+}
+
+// GetDavLocksByRoots returns the alive locks of the roots
+func GetDavLocksByRoots(roots []string, now time.Time) ([]model.DavLock, error) {
+	var locks []model.DavLock
+	if err := liveDavLocks(now).Where(fmt.Sprintf("%s IN ?", columnName("root")), roots).
+		Find(&locks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dav locks")
+	}
+	return locks, nil
+}
+
+// GetDavLocksUnder returns the alive locks of the paths under path, not including itself
+func GetDavLocksUnder(path string, now time.Time) ([]model.DavLock, error) {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	var locks []model.DavLock
+	if err := liveDavLocks(now).Where(fmt.Sprintf("%s LIKE ?", columnName("root")), prefix+"%").
+		Find(&locks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dav locks")
+	}
+	// LIKE also matches '%' and '_' in path
+	res := locks[:0]
+	for _, l := range locks {
+		if strings.HasPrefix(l.Root, prefix) {
+			res = append(res, l)
+		}
+	}
+	return res, nil
+}
+
+func DeleteDavLockByToken(token string) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("token")), token).
+		Delete(&model.DavLock{}).Error)
+}
+
+func DeleteExpiredDavLocks(now time.Time) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s > 0 AND %s <= ?", columnName("expiry"), columnName("expiry")), now.UnixMilli()).
+		Delete(&model.DavLock{}).Error)
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetDavPropsByPath(path string) ([]model.DavProp, error) {
+	var props []model.DavProp
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("path")), path).
+		Order(columnName("id")).Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dav props")
+	}
+	return props, nil
+}
+
+// SetDavProps replaces all the dead properties of path with props
+func SetDavProps(path string, props []model.DavProp) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where(fmt.Sprintf("%s = ?", columnName("path")), path).Delete(&model.DavProp{}).Error
+		if err != nil || len(props) == 0 {
+			return err
+		}
+		for i := range props {
+			props[i].ID = 0
+			props[i].Path = path
+		}
+		return tx.Create(&props).Error
+	}))
+}
+
+// getDavPropsInTree returns the dead properties of path and all the paths under it,
+// LIKE also matches '%' and '_' in path, so the results are checked again
+func getDavPropsInTree(tx *gorm.DB, path string) ([]model.DavProp, error) {
+	var props []model.DavProp
+	query := tx.Where(fmt.Sprintf("%s = ?", columnName("path")), path)
+	if path == "/" {
+		query = tx.Where("1 = 1")
+	} else {
+		query = query.Or(fmt.Sprintf("%s LIKE ?", columnName("path")), path+"/%")
+	}
+	if err := query.Find(&props).Error; err != nil {
+		return nil, err
+	}
+	res := props[:0]
+	for _, p := range props {
+		if p.Path == path || strings.HasPrefix(p.Path, strings.TrimSuffix(path, "/")+"/") {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+// MoveDavProps moves the dead properties of src and the paths under it to dst,
+// the existing properties of the destination paths are dropped
+func MoveDavProps(src, dst string) error {
+	if src == dst {
+		return nil
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		props, err := getDavPropsInTree(tx, src)
+		if err != nil || len(props) == 0 {
+			return err
+		}
+		if err = deleteDavPropsInTree(tx, dst); err != nil {
+			return err
+		}
+		for _, p := range props {
+			err = tx.Model(&model.DavProp{}).Where("id = ?", p.ID).
+				Update("path", dst+strings.TrimPrefix(p.Path, src)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// CopyDavProps copies the dead properties of src and the paths under it to dst,
+// the existing properties of the destination paths are replaced
+func CopyDavProps(src, dst string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		props, err := getDavPropsInTree(tx, src)
+		if err != nil || len(props) == 0 {
+			return err
+		}
+		if err = deleteDavPropsInTree(tx, dst); err != nil {
+			return err
+		}
+		for i := range props {
+			props[i].ID = 0
+			props[i].Path = dst + strings.TrimPrefix(props[i].Path, src)
+		}
+		return tx.CreateInBatches(&props, 1000).Error
+	}))
+}
+
+func deleteDavPropsInTree(tx *gorm.DB, path string) error {
+	props, err := getDavPropsInTree(tx, path)
+	if err != nil || len(props) == 0 {
+		return err
+	}
+	ids := make([]uint, 0, len(props))
+	for _, p := range props {
+		ids = append(ids, p.ID)
+	}
+	return tx.Delete(&model.DavProp{}, ids).Error
+}
+
+// DeleteDavProps deletes the dead properties of path and the paths under it
+func DeleteDavProps(path string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		return deleteDavPropsInTree(tx, path)
+	}))
+}
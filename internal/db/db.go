@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.ApiToken), new(model.AuditLog), new(model.Group), new(model.PathRule), new(model.Quota), new(model.TrashItem), new(model.SyncJob), new(model.SyncRun), new(model.DedupFile), new(model.S3Credential), new(model.DavProp), new(model.DavLock), new(model.FileVersion), new(model.SharingAccess), new(model.NotifyChannel), new(model.NotifySubscription), new(model.StorageHealth), new(model.RssSubscription), new(model.RssItem))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
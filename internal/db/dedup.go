@@ -0,0 +1,70 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func ClearDedupFiles() error {
+	return errors.WithStack(db.Where("1 = 1").Delete(&model.DedupFile{}).Error)
+}
+
+func CreateDedupFiles(files []model.DedupFile) error {
+	return errors.WithStack(db.CreateInBatches(files, 500).Error)
+}
+
+func DeleteDedupFileByPath(path string) error {
+	return errors.WithStack(db.Where(columnName("path")+" = ?", path).Delete(&model.DedupFile{}).Error)
+}
+
+func GetDedupFilesByHashKey(hashKey string) ([]model.DedupFile, error) {
+	var files []model.DedupFile
+	if err := db.Where(columnName("hash_key")+" = ?", hashKey).Find(&files).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find dedup files")
+	}
+	return files, nil
+}
+
+// UpdateDedupHashKey moves the files of the key to the group of newKey
+func UpdateDedupHashKey(key, newKey string) error {
+	return errors.WithStack(db.Model(&model.DedupFile{}).Where(columnName("hash_key")+" = ?", key).
+		Update("hash_key", newKey).Error)
+}
+
+// GetDedupGroups returns the groups of duplicate files, the groups wasting more space come first
+func GetDedupGroups(pageIndex, pageSize int) (groups []model.DedupGroup, count int64, err error) {
+	hashKey := columnName("hash_key")
+	groupDB := db.Model(&model.DedupFile{}).
+		Select(fmt.Sprintf("%s, MAX(%s) AS size, COUNT(*) AS count", hashKey, columnName("size"))).
+		Group(hashKey).
+		Having("COUNT(*) > 1")
+	if err = db.Table("(?) AS g", groupDB).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get dedup groups count")
+	}
+	if err = groupDB.Order(fmt.Sprintf("MAX(%s) * (COUNT(*) - 1) DESC", columnName("size"))).
+		Offset((pageIndex - 1) * pageSize).Limit(pageSize).Scan(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find dedup groups")
+	}
+	if len(groups) == 0 {
+		return groups, count, nil
+	}
+	keys := make([]string, len(groups))
+	for i := range groups {
+		keys[i] = groups[i].HashKey
+	}
+	var files []model.DedupFile
+	if err = db.Where(hashKey+" IN ?", keys).Order(columnName("path")).Find(&files).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find dedup files")
+	}
+	index := make(map[string]int, len(groups))
+	for i := range groups {
+		index[groups[i].HashKey] = i
+	}
+	for _, f := range files {
+		g := &groups[index[f.HashKey]]
+		g.Files = append(g.Files, f)
+	}
+	return groups, count, nil
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetGroupById(id uint) (*model.Group, error) {
+	var g model.Group
+	if err := db.First(&g, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get old group")
+	}
+	return &g, nil
+}
+
+func CreateGroup(g *model.Group) error {
+	return errors.WithStack(db.Create(g).Error)
+}
+
+func UpdateGroup(g *model.Group) error {
+	return errors.WithStack(db.Save(g).Error)
+}
+
+func GetGroups(pageIndex, pageSize int) (groups []model.Group, count int64, err error) {
+	groupDB := db.Model(&model.Group{})
+	if err = groupDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get groups count")
+	}
+	if err = groupDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, count, nil
+}
+
+func GetAllGroups() (groups []model.Group, err error) {
+	if err = db.Find(&groups).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, nil
+}
+
+func DeleteGroupById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Group{}, id).Error)
+}
+
+func GetPathRuleById(id uint) (*model.PathRule, error) {
+	var r model.PathRule
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get old path rule")
+	}
+	return &r, nil
+}
+
+func CreatePathRule(r *model.PathRule) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdatePathRule(r *model.PathRule) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+func GetPathRules(pageIndex, pageSize int) (rules []model.PathRule, count int64, err error) {
+	ruleDB := db.Model(&model.PathRule{})
+	if err = ruleDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get path rules count")
+	}
+	if err = ruleDB.Order(columnName("path")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&rules).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find path rules")
+	}
+	return rules, count, nil
+}
+
+func GetAllPathRules() (rules []model.PathRule, err error) {
+	if err = db.Find(&rules).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find path rules")
+	}
+	return rules, nil
+}
+
+func DeletePathRuleById(id uint) error {
+	return errors.WithStack(db.Delete(&model.PathRule{}, id).Error)
+}
+
+func DeletePathRulesByGroupId(groupId uint) error {
+	return errors.WithStack(db.Where("group_id = ?", groupId).Delete(&model.PathRule{}).Error)
+}
+
+func DeletePathRulesByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.PathRule{}).Error)
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetNotifyChannels() ([]model.NotifyChannel, error) {
+	var channels []model.NotifyChannel
+	if err := db.Order(columnName("id")).Find(&channels).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find notify channels")
+	}
+	return channels, nil
+}
+
+func GetNotifyChannelById(id uint) (*model.NotifyChannel, error) {
+	var ch model.NotifyChannel
+	if err := db.First(&ch, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get notify channel")
+	}
+	return &ch, nil
+}
+
+func CreateNotifyChannel(ch *model.NotifyChannel) error {
+	return errors.WithStack(db.Create(ch).Error)
+}
+
+func UpdateNotifyChannel(ch *model.NotifyChannel) error {
+	return errors.WithStack(db.Save(ch).Error)
+}
+
+func DeleteNotifyChannelById(id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("channel_id = ?", id).Delete(&model.NotifySubscription{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.NotifyChannel{}, id).Error
+	}))
+}
+
+func GetNotifySubscriptionsByUserId(userId uint) ([]model.NotifySubscription, error) {
+	var subs []model.NotifySubscription
+	if err := db.Where("user_id = ?", userId).Order(columnName("id")).Find(&subs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find user's notify subscriptions")
+	}
+	return subs, nil
+}
+
+// GetEnabledNotifySubscriptions returns the enabled subscriptions of the users, all users if userIds is empty
+func GetEnabledNotifySubscriptions(userIds ...uint) ([]model.NotifySubscription, error) {
+	var subs []model.NotifySubscription
+	query := db.Where("disabled = ?", false)
+	if len(userIds) > 0 {
+		query = query.Where("user_id IN ?", userIds)
+	}
+	if err := query.Find(&subs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find notify subscriptions")
+	}
+	return subs, nil
+}
+
+func GetNotifySubscriptionById(id uint) (*model.NotifySubscription, error) {
+	var sub model.NotifySubscription
+	if err := db.First(&sub, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get notify subscription")
+	}
+	return &sub, nil
+}
+
+func CreateNotifySubscription(sub *model.NotifySubscription) error {
+	return errors.WithStack(db.Create(sub).Error)
+}
+
+func UpdateNotifySubscription(sub *model.NotifySubscription) error {
+	return errors.WithStack(db.Save(sub).Error)
+}
+
+func DeleteNotifySubscriptionById(id uint) error {
+	return errors.WithStack(db.Delete(&model.NotifySubscription{}, id).Error)
+}
+
+func DeleteNotifySubscriptionsByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.NotifySubscription{}).Error)
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetQuotaById(id uint) (*model.Quota, error) {
+	var q model.Quota
+	if err := db.First(&q, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get old quota")
+	}
+	return &q, nil
+}
+
+func CreateQuota(q *model.Quota) error {
+	return errors.WithStack(db.Create(q).Error)
+}
+
+// UpdateQuota updates the limits of the quota, the usage is kept
+func UpdateQuota(q *model.Quota) error {
+	return errors.WithStack(db.Model(q).Select("path", "user_id", "max_size", "max_files", "remark").Updates(q).Error)
+}
+
+func GetQuotas(pageIndex, pageSize int) (quotas []model.Quota, count int64, err error) {
+	quotaDB := db.Model(&model.Quota{})
+	if err = quotaDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get quotas count")
+	}
+	if err = quotaDB.Order(columnName("path")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&quotas).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find quotas")
+	}
+	return quotas, count, nil
+}
+
+func GetAllQuotas() (quotas []model.Quota, err error) {
+	if err = db.Find(&quotas).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find quotas")
+	}
+	return quotas, nil
+}
+
+func DeleteQuotaById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Quota{}, id).Error)
+}
+
+func DeleteQuotasByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.Quota{}).Error)
+}
+
+func AddQuotaUsage(id uint, size, files int64) error {
+	return errors.WithStack(db.Model(&model.Quota{}).Where("id = ?", id).Updates(map[string]any{
+		"used_size":  gorm.Expr("used_size + ?", size),
+		"used_files": gorm.Expr("used_files + ?", files),
+	}).Error)
+}
+
+func SetQuotaUsage(id uint, size, files int64, reconciled time.Time) error {
+	return errors.WithStack(db.Model(&model.Quota{}).Where("id = ?", id).Updates(map[string]any{
+		"used_size":  size,
+		"used_files": files,
+		"reconciled": reconciled,
+	}).Error)
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetRssSubscriptionById(id uint) (*model.RssSubscription, error) {
+	var s model.RssSubscription
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get old rss subscription")
+	}
+	return &s, nil
+}
+
+func CreateRssSubscription(s *model.RssSubscription) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateRssSubscription(s *model.RssSubscription) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+func SetRssSubscriptionCheck(id uint, t time.Time, errMsg string) error {
+	return errors.WithStack(db.Model(&model.RssSubscription{}).Where("id = ?", id).Updates(map[string]any{
+		"last_check": t,
+		"last_error": errMsg,
+	}).Error)
+}
+
+func GetRssSubscriptions(pageIndex, pageSize int) (subs []model.RssSubscription, count int64, err error) {
+	subDB := db.Model(&model.RssSubscription{})
+	if err = subDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get rss subscriptions count")
+	}
+	if err = subDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&subs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find rss subscriptions")
+	}
+	return subs, count, nil
+}
+
+func GetAllRssSubscriptions() (subs []model.RssSubscription, err error) {
+	if err = db.Find(&subs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find rss subscriptions")
+	}
+	return subs, nil
+}
+
+func DeleteRssSubscriptionById(id uint) error {
+	if err := db.Where("subscription_id = ?", id).Delete(&model.RssItem{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.RssSubscription{}, id).Error)
+}
+
+// GetRssItemGuids returns the guids of the items of the subscription added already
+func GetRssItemGuids(subId uint) (map[string]struct{}, error) {
+	var guids []string
+	if err := db.Model(&model.RssItem{}).Where("subscription_id = ?", subId).Pluck("guid", &guids).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get rss item guids")
+	}
+	res := make(map[string]struct{}, len(guids))
+	for _, guid := range guids {
+		res[guid] = struct{}{}
+	}
+	return res, nil
+}
+
+func CreateRssItem(item *model.RssItem) error {
+	return errors.WithStack(db.Create(item).Error)
+}
+
+func CreateRssItems(items []model.RssItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.CreateInBatches(items, 100).Error)
+}
+
+func GetRssItems(subId uint, pageIndex, pageSize int) (items []model.RssItem, count int64, err error) {
+	itemDB := db.Model(&model.RssItem{}).Where("subscription_id = ?", subId)
+	if err = itemDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get rss items count")
+	}
+	if err = itemDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find rss items")
+	}
+	return items, count, nil
+}
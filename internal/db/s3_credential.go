@@ -0,0 +1,58 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetS3CredentialsByUserId(userId uint, pageIndex, pageSize int) (creds []model.S3Credential, count int64, err error) {
+	credDB := db.Model(&model.S3Credential{})
+	query := model.S3Credential{UserId: userId}
+	if err := credDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's s3 credentials count")
+	}
+	if err := credDB.Where(query).Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&creds).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's s3 credentials")
+	}
+	return creds, count, nil
+}
+
+func GetEnabledS3Credentials() ([]model.S3Credential, error) {
+	var creds []model.S3Credential
+	if err := db.Where(map[string]any{"disabled": false}).Find(&creds).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 credentials")
+	}
+	return creds, nil
+}
+
+func GetS3CredentialById(id uint) (*model.S3Credential, error) {
+	var c model.S3Credential
+	if err := db.First(&c, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 credential")
+	}
+	return &c, nil
+}
+
+func GetS3CredentialByAccessKey(accessKeyId string) (*model.S3Credential, error) {
+	c := model.S3Credential{AccessKeyId: accessKeyId}
+	if err := db.Where(c).First(&c).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 credential")
+	}
+	return &c, nil
+}
+
+func CreateS3Credential(c *model.S3Credential) error {
+	return errors.WithStack(db.Create(c).Error)
+}
+
+func UpdateS3Credential(c *model.S3Credential) error {
+	return errors.WithStack(db.Save(c).Error)
+}
+
+func DeleteS3CredentialById(id uint) error {
+	return errors.WithStack(db.Delete(&model.S3Credential{}, id).Error)
+}
+
+func DeleteS3CredentialsByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.S3Credential{}).Error)
+}
@@ -35,10 +35,37 @@ func DeleteSearchNodesByParent(path string) error {
 	if err != nil {
 		return err
 	}
-	dir, name := stdpath.Split(path)
 	return db.Where(fmt.Sprintf("%s = ? AND %s = ?",
 		columnName("parent"), columnName("name")),
-		dir, name).Delete(&model.SearchNode{}).Error
+		stdpath.Dir(path), stdpath.Base(path)).Delete(&model.SearchNode{}).Error
+}
+
+// RenameSearchNodes moves the node at src and its children to dst
+func RenameSearchNodes(src, dst string) error {
+	src, dst = utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst)
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.SearchNode{}).Where(fmt.Sprintf("%s = ? AND %s = ?",
+			columnName("parent"), columnName("name")),
+			stdpath.Dir(src), stdpath.Base(src)).
+			Updates(map[string]any{"parent": stdpath.Dir(dst), "name": stdpath.Base(dst)}).Error
+		if err != nil {
+			return err
+		}
+		var parents []string
+		err = tx.Model(&model.SearchNode{}).Where(whereInParent(src)).
+			Distinct(columnName("parent")).Pluck(columnName("parent"), &parents).Error
+		if err != nil {
+			return err
+		}
+		for _, parent := range parents {
+			err = tx.Model(&model.SearchNode{}).Where(fmt.Sprintf("%s = ?", columnName("parent")), parent).
+				Update("parent", dst+strings.TrimPrefix(parent, src)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
 }
 
 func ClearSearchNodes() error {
@@ -77,15 +104,47 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 		isDir := req.Scope == 1
 		searchDB.Where(db.Where("is_dir = ?", isDir))
 	}
+	searchDB = whereMatchFilters(searchDB, req)
 
 	var count int64
 	if err := searchDB.Count(&count).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed get search items count")
 	}
+	orderBy, direction := "name", "asc"
+	if req.OrderBy != "" {
+		orderBy = req.OrderBy
+	}
+	if req.OrderDirection == "desc" {
+		direction = "desc"
+	}
 	var files []model.SearchNode
-	if err := searchDB.Order("name asc").Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+	if err := searchDB.Order(fmt.Sprintf("%s %s", columnName(orderBy), direction)).
+		Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
 		Find(&files).Error; err != nil {
 		return nil, 0, err
 	}
 	return files, count, nil
 }
+
+// whereMatchFilters applies the metadata filters of req
+func whereMatchFilters(searchDB *gorm.DB, req model.SearchReq) *gorm.DB {
+	if req.MinSize > 0 {
+		searchDB = searchDB.Where(fmt.Sprintf("%s >= ?", columnName("size")), req.MinSize)
+	}
+	if req.MaxSize > 0 {
+		searchDB = searchDB.Where(fmt.Sprintf("%s <= ?", columnName("size")), req.MaxSize)
+	}
+	if req.ModifiedAfter != nil {
+		searchDB = searchDB.Where(fmt.Sprintf("%s >= ?", columnName("modified")), *req.ModifiedAfter)
+	}
+	if req.ModifiedBefore != nil {
+		searchDB = searchDB.Where(fmt.Sprintf("%s <= ?", columnName("modified")), *req.ModifiedBefore)
+	}
+	if len(req.Types) > 0 {
+		searchDB = searchDB.Where(fmt.Sprintf("%s IN ?", columnName("obj_type")), req.Types)
+	}
+	if req.Storage != "" {
+		searchDB = searchDB.Where(fmt.Sprintf("%s = ?", columnName("storage")), utils.FixAndCleanPath(req.Storage))
+	}
+	return searchDB
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateSharingAccess(a *model.SharingAccess) error {
+	return errors.WithStack(db.Create(a).Error)
+}
+
+func GetSharingAccesses(sharingId string, pageIndex, pageSize int) (accesses []model.SharingAccess, count int64, err error) {
+	accessDB := db.Model(&model.SharingAccess{}).Where(fmt.Sprintf("%s = ?", columnName("sharing_id")), sharingId)
+	if err = accessDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sharing accesses count")
+	}
+	if err = accessDB.Order(columnName("id") + " DESC").
+		Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&accesses).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sharing accesses")
+	}
+	return accesses, count, nil
+}
+
+// GetSharingStats aggregates the access logs of the sharings
+func GetSharingStats(sharingIds []string) ([]model.SharingStats, error) {
+	var stats []model.SharingStats
+	if len(sharingIds) == 0 {
+		return stats, nil
+	}
+	typ := columnName("type")
+	down := fmt.Sprintf("%s IN ('%s', '%s')", typ, model.SharingAccessDown, model.SharingAccessExtract)
+	if err := db.Model(&model.SharingAccess{}).
+		Select(fmt.Sprintf("%s, SUM(CASE WHEN %s = '%s' THEN 1 ELSE 0 END) AS views, "+
+			"SUM(CASE WHEN %s AND NOT %s THEN 1 ELSE 0 END) AS downloads, SUM(CASE WHEN %s THEN %s ELSE 0 END) AS bytes, "+
+			"COUNT(DISTINCT %s) AS visitors, MAX(%s) AS last_id",
+			columnName("sharing_id"), typ, model.SharingAccessView, down, columnName("partial"), down, columnName("bytes"),
+			columnName("ip"), columnName("id"))).
+		Where(fmt.Sprintf("%s IN ?", columnName("sharing_id")), sharingIds).
+		Group(columnName("sharing_id")).Scan(&stats).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sharing stats")
+	}
+	if len(stats) == 0 {
+		return stats, nil
+	}
+	lastIds := make([]uint, len(stats))
+	for i := range stats {
+		lastIds[i] = stats[i].LastId
+	}
+	var last []model.SharingAccess
+	if err := db.Where(fmt.Sprintf("%s IN ?", columnName("id")), lastIds).Find(&last).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find last sharing accesses")
+	}
+	times := make(map[uint]*model.SharingAccess, len(last))
+	for i := range last {
+		times[last[i].ID] = &last[i]
+	}
+	for i := range stats {
+		if a, ok := times[stats[i].LastId]; ok {
+			stats[i].LastAccessed = &a.Time
+		}
+	}
+	return stats, nil
+}
+
+// CountFileDownloads returns the times and the bytes the file in the sharing has been downloaded,
+// the downloads of the files in it are counted for an archive, the partial requests add bytes only
+func CountFileDownloads(sharingId, path string) (count, bytes int64, err error) {
+	var res struct {
+		Count int64
+		Bytes int64
+	}
+	if err = db.Model(&model.SharingAccess{}).
+		Select(fmt.Sprintf("COALESCE(SUM(CASE WHEN %s THEN 0 ELSE 1 END), 0) AS count, COALESCE(SUM(%s), 0) AS bytes",
+			columnName("partial"), columnName("bytes"))).
+		Where(fmt.Sprintf("%s = ? AND %s = ? AND %s IN ?", columnName("sharing_id"), columnName("path"), columnName("type")),
+			sharingId, path, []string{model.SharingAccessDown, model.SharingAccessExtract}).
+		Scan(&res).Error; err != nil {
+		return 0, 0, errors.Wrapf(err, "failed count file downloads")
+	}
+	return res.Count, res.Bytes, nil
+}
+
+// HasFileDownload reports whether the client of ip has downloaded the file in the sharing from the start
+func HasFileDownload(sharingId, path, ip string) (bool, error) {
+	var count int64
+	if err := db.Model(&model.SharingAccess{}).
+		Where(fmt.Sprintf("%s = ? AND %s = ? AND %s = ? AND %s IN ? AND %s = ?", columnName("sharing_id"), columnName("path"),
+			columnName("ip"), columnName("type"), columnName("partial")),
+			sharingId, path, ip, []string{model.SharingAccessDown, model.SharingAccessExtract}, false).
+		Count(&count).Error; err != nil {
+		return false, errors.Wrapf(err, "failed check file download")
+	}
+	return count > 0, nil
+}
+
+func DeleteSharingAccesses(sharingId string) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("sharing_id")), sharingId).
+		Delete(&model.SharingAccess{}).Error)
+}
+
+func DeleteSharingAccessesByCreatorId(creatorId uint) error {
+	sharings := db.Model(&model.SharingDB{}).Select(columnName("id")).Where("creator_id = ?", creatorId)
+	return errors.WithStack(db.Where(fmt.Sprintf("%s IN (?)", columnName("sharing_id")), sharings).
+		Delete(&model.SharingAccess{}).Error)
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateStorageHealth(h *model.StorageHealth) error {
+	return errors.WithStack(db.Create(h).Error)
+}
+
+func GetStorageHealths(storageId uint, pageIndex, pageSize int) (healths []model.StorageHealth, count int64, err error) {
+	healthDB := db.Model(&model.StorageHealth{}).Where("storage_id = ?", storageId)
+	if err := healthDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get storage healths count")
+	}
+	if err := healthDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&healths).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find storage healths")
+	}
+	return healths, count, nil
+}
+
+func DeleteStorageHealthsBefore(t time.Time) (int64, error) {
+	res := db.Where(columnName("time")+" < ?", t).Delete(&model.StorageHealth{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
+
+func DeleteStorageHealthsByStorageId(storageId uint) error {
+	return errors.WithStack(db.Where("storage_id = ?", storageId).Delete(&model.StorageHealth{}).Error)
+}
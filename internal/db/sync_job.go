@@ -0,0 +1,80 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	var j model.SyncJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get old sync job")
+	}
+	return &j, nil
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+func SetSyncJobLastRun(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.SyncJob{}).Where("id = ?", id).Update("last_run", t).Error)
+}
+
+func GetSyncJobs(pageIndex, pageSize int) (jobs []model.SyncJob, count int64, err error) {
+	jobDB := db.Model(&model.SyncJob{})
+	if err = jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync jobs count")
+	}
+	if err = jobDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetAllSyncJobs() (jobs []model.SyncJob, err error) {
+	if err = db.Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, nil
+}
+
+func DeleteSyncJobById(id uint) error {
+	if err := db.Where("job_id = ?", id).Delete(&model.SyncRun{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.SyncJob{}, id).Error)
+}
+
+func CreateSyncRun(r *model.SyncRun) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateSyncRun(r *model.SyncRun) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+func GetSyncRuns(jobId uint, pageIndex, pageSize int) (runs []model.SyncRun, count int64, err error) {
+	runDB := db.Model(&model.SyncRun{}).Where("job_id = ?", jobId)
+	if err = runDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync runs count")
+	}
+	if err = runDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync runs")
+	}
+	return runs, count, nil
+}
+
+// FailRunningSyncRuns marks the runs interrupted by a restart as failed
+func FailRunningSyncRuns(msg string) error {
+	return errors.WithStack(db.Model(&model.SyncRun{}).Where("status = ?", model.SyncRunning).Updates(map[string]any{
+		"status": model.SyncFailed,
+		"errors": msg,
+	}).Error)
+}
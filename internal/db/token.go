@@ -0,0 +1,58 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetApiTokensByUserId(userId uint, pageIndex, pageSize int) (tokens []model.ApiToken, count int64, err error) {
+	tokenDB := db.Model(&model.ApiToken{})
+	query := model.ApiToken{UserId: userId}
+	if err := tokenDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's tokens count")
+	}
+	if err := tokenDB.Where(query).Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&tokens).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's tokens")
+	}
+	return tokens, count, nil
+}
+
+func GetApiTokenById(id uint) (*model.ApiToken, error) {
+	var t model.ApiToken
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get token")
+	}
+	return &t, nil
+}
+
+func GetApiTokenByHash(hash string) (*model.ApiToken, error) {
+	t := model.ApiToken{TokenHash: hash}
+	if err := db.Where(t).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find token")
+	}
+	return &t, nil
+}
+
+func GetApiTokenByUserName(userId uint, name string) (*model.ApiToken, error) {
+	t := model.ApiToken{UserId: userId, Name: name}
+	if err := db.Where(t).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find token with name of user")
+	}
+	return &t, nil
+}
+
+func CreateApiToken(t *model.ApiToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func UpdateApiToken(t *model.ApiToken) error {
+	return errors.WithStack(db.Save(t).Error)
+}
+
+func DeleteApiTokenById(id uint) error {
+	return errors.WithStack(db.Delete(&model.ApiToken{}, id).Error)
+}
+
+func DeleteApiTokensByUserId(userId uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userId).Delete(&model.ApiToken{}).Error)
+}
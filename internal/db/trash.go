@@ -0,0 +1,53 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTrashItem(item *model.TrashItem) error {
+	return errors.WithStack(db.Create(item).Error)
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	var item model.TrashItem
+	if err := db.First(&item, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &item, nil
+}
+
+func GetTrashItems(filter model.TrashFilter, pageIndex, pageSize int) (items []model.TrashItem, count int64, err error) {
+	trashDB := db.Model(&model.TrashItem{})
+	if filter.StorageId != 0 {
+		trashDB = trashDB.Where("storage_id = ?", filter.StorageId)
+	}
+	if filter.Path != "" {
+		trashDB = trashDB.Where(columnName("path")+" LIKE ?", filter.Path+"%")
+	}
+	if err = trashDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get trash items count")
+	}
+	if err = trashDB.Order(columnName("deleted") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, count, nil
+}
+
+// GetTrashItemsBefore returns the items of the storage deleted before t
+func GetTrashItemsBefore(storageId uint, t time.Time) (items []model.TrashItem, err error) {
+	if err = db.Where("storage_id = ? AND "+columnName("deleted")+" < ?", storageId, t).Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, nil
+}
+
+func DeleteTrashItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.TrashItem{}, id).Error)
+}
+
+func DeleteTrashItemsByStorageId(storageId uint) error {
+	return errors.WithStack(db.Where("storage_id = ?", storageId).Delete(&model.TrashItem{}).Error)
+}
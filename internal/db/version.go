@@ -0,0 +1,92 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateFileVersion(v *model.FileVersion) error {
+	return errors.WithStack(db.Create(v).Error)
+}
+
+func GetFileVersionById(id uint) (*model.FileVersion, error) {
+	var v model.FileVersion
+	if err := db.First(&v, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get file version")
+	}
+	return &v, nil
+}
+
+// GetFileVersionsByPath returns the versions of the file, the newest first
+func GetFileVersionsByPath(path string) (versions []model.FileVersion, err error) {
+	if err = db.Where(fmt.Sprintf("%s = ?", columnName("path")), path).
+		Order(columnName("created") + " DESC").Order(columnName("id") + " DESC").Find(&versions).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find file versions")
+	}
+	return versions, nil
+}
+
+// GetFileVersionsUnder returns the versions of path and the files under it
+func GetFileVersionsUnder(path string) ([]model.FileVersion, error) {
+	var versions []model.FileVersion
+	tx := db.Model(&model.FileVersion{})
+	if path != "/" {
+		tx = tx.Where(fmt.Sprintf("%s = ? OR %s LIKE ?", columnName("path"), columnName("path")), path, path+"/%")
+	}
+	if err := tx.Find(&versions).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find file versions")
+	}
+	// LIKE also matches '%' and '_' in path
+	return utils.SliceFilter(versions, func(v model.FileVersion) bool {
+		return path == "/" || v.Path == path || strings.HasPrefix(v.Path, path+"/")
+	}), nil
+}
+
+// GetFileVersionsBefore returns the versions of the files of the storage created before t
+func GetFileVersionsBefore(storageId uint, t time.Time) (versions []model.FileVersion, err error) {
+	if err = db.Where("storage_id = ? AND "+columnName("created")+" < ?", storageId, t).Find(&versions).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find file versions")
+	}
+	return versions, nil
+}
+
+// MoveFileVersions moves the versions of src and the files under it to dst
+func MoveFileVersions(src, dst string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var versions []model.FileVersion
+		err := tx.Where(fmt.Sprintf("%s = ? OR %s LIKE ?", columnName("path"), columnName("path")), src, src+"/%").
+			Find(&versions).Error
+		if err != nil {
+			return err
+		}
+		for _, v := range versions {
+			// LIKE also matches '%' and '_' in src
+			if v.Path != src && !strings.HasPrefix(v.Path, src+"/") {
+				continue
+			}
+			err = tx.Model(&model.FileVersion{}).Where("id = ?", v.ID).
+				Update("path", dst+strings.TrimPrefix(v.Path, src)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func DeleteFileVersionById(id uint) error {
+	return errors.WithStack(db.Delete(&model.FileVersion{}, id).Error)
+}
+
+// DeleteFileVersionsByStorageId deletes the versions of the files of the storage
+// and the versions held by the storage
+func DeleteFileVersionsByStorageId(storageId uint) error {
+	return errors.WithStack(db.Where("storage_id = ? OR version_storage_id = ?", storageId, storageId).
+		Delete(&model.FileVersion{}).Error)
+}
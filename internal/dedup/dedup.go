@@ -0,0 +1,293 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	batchSize     = 500
+	sizeKeyPrefix = "size:"
+)
+
+// the hashes comparable across most drivers are preferred
+var preferredHashes = []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256}
+
+type Status struct {
+	Running  bool       `json:"running"`
+	Paths    []string   `json:"paths"`
+	Scanned  int64      `json:"scanned"`
+	Started  *time.Time `json:"started"`
+	Finished *time.Time `json:"finished"`
+	Error    string     `json:"error"`
+}
+
+var (
+	mu     sync.Mutex
+	status Status
+	cancel context.CancelFunc
+)
+
+func GetStatus() Status {
+	mu.Lock()
+	defer mu.Unlock()
+	return status
+}
+
+// HashKeys returns the keys of all the hashes of obj, the preferred ones come first.
+// The file without any hash has only the key of its size and name, which may group different files.
+func HashKeys(obj model.Obj) []string {
+	hi := obj.GetHash()
+	var keys, others []string
+	for _, ht := range preferredHashes {
+		if h := hi.GetHash(ht); h != "" {
+			keys = append(keys, ht.Name+":"+h)
+		}
+	}
+	for ht, h := range hi.All() {
+		if h != "" && !slices.Contains(preferredHashes, ht) {
+			others = append(others, ht.Name+":"+h)
+		}
+	}
+	sort.Strings(others)
+	keys = append(keys, others...)
+	if len(keys) == 0 {
+		return []string{SizeKey(obj.GetSize(), obj.GetName())}
+	}
+	return keys
+}
+
+func SizeKey(size int64, name string) string {
+	return fmt.Sprintf("%s%d:%s", sizeKeyPrefix, size, name)
+}
+
+// IsSizeKey reports whether the group of the key is got by the size and name of the files only
+func IsSizeKey(key string) bool {
+	return strings.HasPrefix(key, sizeKeyPrefix)
+}
+
+// keyUnion merges the keys of the hashes of the same files,
+// so the files are grouped if they share any hash, e.g. a file reporting md5 and sha1
+// of one storage and the same file reporting only sha1 of another storage
+type keyUnion map[string]string
+
+func (u keyUnion) find(key string) string {
+	parent, ok := u[key]
+	if !ok {
+		u[key] = key
+		return key
+	}
+	if parent == key {
+		return key
+	}
+	root := u.find(parent)
+	u[key] = root
+	return root
+}
+
+// union merges the groups of the keys, the smallest key is the key of the merged group
+func (u keyUnion) union(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	root := u.find(keys[0])
+	for _, key := range keys[1:] {
+		r := u.find(key)
+		if r == root {
+			continue
+		}
+		if r < root {
+			root, r = r, root
+		}
+		u[r] = root
+	}
+}
+
+// Scan starts walking the paths in the background, the result of the last scan is replaced
+func Scan(paths []string) error {
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return errors.WithMessage(err, "failed get admin")
+	}
+	for i := range paths {
+		paths[i] = utils.FixAndCleanPath(paths[i])
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if status.Running {
+		return errors.New("a scan is running")
+	}
+	now := time.Now()
+	status = Status{Running: true, Paths: paths, Started: &now}
+	var ctx context.Context
+	ctx, cancel = context.WithCancel(context.WithValue(context.Background(), conf.UserKey, admin))
+	go func() {
+		err := scan(ctx, paths)
+		if err != nil {
+			log.Errorf("failed dedup scan: %+v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		finished := time.Now()
+		status.Running = false
+		status.Finished = &finished
+		if err != nil {
+			status.Error = err.Error()
+		}
+		cancel()
+	}()
+	return nil
+}
+
+// Stop cancels the running scan
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	if status.Running {
+		cancel()
+	}
+}
+
+func scan(ctx context.Context, paths []string) error {
+	if err := db.ClearDedupFiles(); err != nil {
+		return err
+	}
+	batch := make([]model.DedupFile, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := db.CreateDedupFiles(batch)
+		batch = batch[:0]
+		return err
+	}
+	union := keyUnion{}
+	// the keys the files are saved with
+	saved := map[string]struct{}{}
+	walkFn := func(reqPath string, obj model.Obj) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if obj.IsDir() {
+			return nil
+		}
+		keys := HashKeys(obj)
+		union.union(keys)
+		saved[keys[0]] = struct{}{}
+		// the key is replaced by the one of the merged group after the scan
+		batch = append(batch, model.DedupFile{
+			HashKey:  keys[0],
+			Path:     reqPath,
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime(),
+		})
+		mu.Lock()
+		status.Scanned++
+		mu.Unlock()
+		if len(batch) >= batchSize {
+			return flush()
+		}
+		return nil
+	}
+	// a file must not be counted twice if the paths overlap
+	sort.Strings(paths)
+	var roots []string
+	for _, path := range paths {
+		if len(roots) > 0 && utils.IsSubPath(roots[len(roots)-1], path) {
+			continue
+		}
+		roots = append(roots, path)
+	}
+	for _, path := range roots {
+		obj, err := fs.Get(ctx, path, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			return errors.WithMessagef(err, "failed get %s", path)
+		}
+		err = fs.WalkFS(ctx, -1, path, obj, walkFn)
+		if err != nil && err != filepath.SkipDir {
+			return errors.WithMessagef(err, "failed walk %s", path)
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	for key := range saved {
+		if root := union.find(key); root != key {
+			if err := db.UpdateDedupHashKey(key, root); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CheckGroup checks that the paths, and keep if it's not empty, are the files of the group
+// of hashKey found by the last scan, and that a file of the group is left.
+// The groups of the size and name only are not sure to be duplicates, the caller must confirm the action on them.
+func CheckGroup(hashKey string, paths []string, keep string, confirmed bool) error {
+	if IsSizeKey(hashKey) && !confirmed {
+		return errors.New("the files are grouped by their size and name only, they may be different, confirm to continue")
+	}
+	files, err := db.GetDedupFilesByHashKey(hashKey)
+	if err != nil {
+		return err
+	}
+	left := make(map[string]struct{}, len(files))
+	for _, f := range files {
+		left[f.Path] = struct{}{}
+	}
+	if len(left) == 0 {
+		return errors.Errorf("dedup group %s not found", hashKey)
+	}
+	if keep != "" {
+		if _, ok := left[keep]; !ok {
+			return errors.Errorf("%s is not in the dedup group", keep)
+		}
+	}
+	for _, path := range paths {
+		if path == keep {
+			continue
+		}
+		if _, ok := left[path]; !ok {
+			return errors.Errorf("%s is not in the dedup group", path)
+		}
+		delete(left, path)
+	}
+	if len(left) == 0 {
+		return errors.New("all the files of the dedup group would be removed")
+	}
+	return nil
+}
+
+// Remove removes a duplicate file
+func Remove(ctx context.Context, path string) error {
+	if err := fs.Remove(ctx, path); err != nil {
+		return err
+	}
+	return db.DeleteDedupFileByPath(path)
+}
+
+// ReplaceWithLink adds a link named after the duplicate file to linkDir, then removes the file.
+// The storage of linkDir must support adding a url, e.g. a writable UrlTree.
+func ReplaceWithLink(ctx context.Context, path, linkDir, url string) error {
+	if err := fs.PutURL(ctx, linkDir, stdpath.Base(path), url); err != nil {
+		return errors.WithMessage(err, "failed add link")
+	}
+	return Remove(ctx, path)
+}
@@ -0,0 +1,34 @@
+package dedup
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func TestGroupKeys(t *testing.T) {
+	objs := []*model.Object{
+		// the same file in a storage reporting md5 and sha1 and in one reporting sha1 only
+		{Name: "a", Size: 1, HashInfo: utils.NewHashInfoByMap(map[*utils.HashType]string{utils.MD5: "m1", utils.SHA1: "s1"})},
+		{Name: "b", Size: 1, HashInfo: utils.NewHashInfo(utils.SHA1, "s1")},
+		{Name: "c", Size: 1, HashInfo: utils.NewHashInfo(utils.MD5, "m2")},
+		{Name: "d", Size: 1},
+	}
+	union := keyUnion{}
+	var first []string
+	for _, obj := range objs {
+		keys := HashKeys(obj)
+		union.union(keys)
+		first = append(first, keys[0])
+	}
+	want := []string{"md5:m1", "md5:m1", "md5:m2", "size:1:d"}
+	for i, key := range first {
+		if got := union.find(key); got != want[i] {
+			t.Errorf("group of %s = %s, want %s", objs[i].Name, got, want[i])
+		}
+	}
+	if !IsSizeKey(first[3]) || IsSizeKey(first[0]) {
+		t.Errorf("IsSizeKey is wrong")
+	}
+}
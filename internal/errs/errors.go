@@ -18,6 +18,7 @@ var (
 	StorageNotInit     = errors.New("storage not init")
 	StreamIncomplete   = errors.New("upload/download stream incomplete, possible network issue")
 	StreamPeekFail     = errors.New("StreamPeekFail")
+	QuotaExceeded      = errors.New("storage quota exceeded")
 
 	UnknownArchiveFormat      = errors.New("unknown archive format")
 	WrongArchivePassword      = errors.New("wrong archive password")
@@ -26,6 +27,10 @@ var (
 	WrongShareCode  = errors.New("wrong share code")
 	InvalidSharing  = errors.New("invalid sharing")
 	SharingNotFound = errors.New("sharing not found")
+	// the visitor is not in the allowed ip ranges or users of the sharing
+	SharingNotAllowed = errors.New("not allowed to access the sharing")
+	UploadOnly        = errors.New("the sharing only accepts uploads")
+	DownloadLimit     = errors.New("the download limit of the file is reached")
 )
 
 // NewErr wrap constant error with an extra message
@@ -12,6 +12,7 @@ var (
 	NotFolder           = errors.New("not a folder")
 	NotFile             = errors.New("not a file")
 	IgnoredSystemFile   = errors.New("system file upload ignored")
+	ObjectLocked        = errors.New("object is locked")
 )
 
 func IsObjectNotFound(err error) bool {
@@ -7,4 +7,7 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+	InvalidApiToken    = errors.New("api token is invalid")
+	ApiTokenExpired    = errors.New("api token is expired")
+	InvalidS3Key       = errors.New("s3 access key is invalid")
 )
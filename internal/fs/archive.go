@@ -16,6 +16,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
@@ -63,6 +64,12 @@ func (t *ArchiveDownloadTask) Run() error {
 	return nil
 }
 
+func (t *ArchiveDownloadTask) OnFailed() {
+	recordTaskDone(t, model.AuditDecompress, stdpath.Join(utils.GetFullPath(t.SrcStorageMp, t.SrcActualPath), t.InnerPath),
+		utils.GetFullPath(t.DstStorageMp, t.DstActualPath), 0)
+	notify.TaskDone(t, "decompress", false)
+}
+
 func (t *ArchiveDownloadTask) RunWithoutPushUploadTask() (*ArchiveContentUploadTask, error) {
 	srcObj, tool, ss, err := op.GetArchiveToolAndStream(t.Ctx(), t.SrcStorage, t.SrcActualPath, model.LinkArgs{})
 	if err != nil {
@@ -163,11 +170,20 @@ func (t *ArchiveContentUploadTask) Run() error {
 }
 
 func (t *ArchiveContentUploadTask) OnSucceeded() {
+	t.recordDone()
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
+	notify.TaskDone(t, "decompress_upload", true)
 }
 
 func (t *ArchiveContentUploadTask) OnFailed() {
+	t.recordDone()
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
+	notify.TaskDone(t, "decompress_upload", false)
+}
+
+// recordDone records the outcome of uploading an entry of the decompressed archive
+func (t *ArchiveContentUploadTask) recordDone() {
+	recordTaskDone(t, model.AuditDecompress, t.ObjName, utils.GetFullPath(t.DstStorageMp, t.DstActualPath), t.GetTotalBytes())
 }
 
 func (t *ArchiveContentUploadTask) SetRetry(retry int, maxRetry int) {
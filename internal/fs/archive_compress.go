@@ -0,0 +1,382 @@
+package fs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/KirCute/zip"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+)
+
+// ArchiveCompressTask compresses the objects, which may be in different storages, into an archive.
+// The src objects are read from their links while they are compressed,
+// only the archive is cached to get its size before it's uploaded.
+type ArchiveCompressTask struct {
+	task.TaskExtension
+	Status   string   `json:"-"`
+	SrcPaths []string `json:"src_paths"`
+	// the actual path of the archive
+	DstActualPath string `json:"dst_path"`
+	DstStorageMp  string `json:"dst_storage_mp"`
+	dstStorage    driver.Driver
+	model.ArchiveCompressArgs
+	// the bytes of the src files compressed
+	compressed int64
+}
+
+func (t *ArchiveCompressTask) GetName() string {
+	return fmt.Sprintf("compress %s to [%s](%s)", strings.Join(t.SrcPaths, ", "), t.DstStorageMp, t.DstActualPath)
+}
+
+func (t *ArchiveCompressTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *ArchiveCompressTask) Run() error {
+	if t.dstStorage == nil {
+		dstStorage, _, err := op.GetStorageAndActualPath(t.DstStorageMp)
+		if err != nil {
+			return err
+		}
+		t.dstStorage = dstStorage
+	}
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	ctx := t.Ctx()
+	if !t.Overwrite {
+		if res, _ := op.Get(ctx, t.dstStorage, t.DstActualPath); res != nil {
+			return errs.ObjectAlreadyExists
+		}
+	}
+	t.Status = "walking src objects"
+	entries, total, err := walkCompressEntries(ctx, t.SrcPaths, t.MetaPass)
+	if err != nil {
+		return err
+	}
+	// the archive is cached before it's uploaded, so the quota of the creator is checked
+	// against the size of the files before it's compressed rather than after
+	if err = op.CheckQuota(ctx, op.Key(t.dstStorage, stdpath.Dir(t.DstActualPath)), total, 1); err != nil {
+		return err
+	}
+	t.SetTotalBytes(total)
+	t.compressed = 0
+	t.Status = "compressing"
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(t.compress(pw, entries, total))
+	}()
+	name := stdpath.Base(t.DstActualPath)
+	s := &stream.FileStream{
+		Ctx: ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     -1,
+			Modified: time.Now(),
+		},
+		Mimetype: utils.GetMimeType(name),
+		Reader:   pr,
+	}
+	s.Closers.Add(pr)
+	if _, err = s.CacheFullAndWriter(nil, nil); err != nil {
+		_ = s.Close()
+		return errors.WithMessage(err, "failed compress")
+	}
+	t.Status = "uploading"
+	return op.Put(context.WithValue(ctx, conf.SkipHookKey, struct{}{}), t.dstStorage, stdpath.Dir(t.DstActualPath), s,
+		model.UpdateProgressWithRange(t.SetProgress, 50, 100))
+}
+
+func (t *ArchiveCompressTask) OnSucceeded() {
+	t.recordDone()
+	notify.TaskDone(t, "compress", true)
+}
+
+func (t *ArchiveCompressTask) OnFailed() {
+	t.recordDone()
+	notify.TaskDone(t, "compress", false)
+}
+
+func (t *ArchiveCompressTask) recordDone() {
+	recordTaskDone(t, model.AuditCompress, strings.Join(t.SrcPaths, ", "), utils.GetFullPath(t.DstStorageMp, t.DstActualPath), t.GetTotalBytes())
+}
+
+// compress writes the archive of the entries, the progress of it is the first half of the task
+func (t *ArchiveCompressTask) compress(w io.Writer, entries []compressEntry, total int64) error {
+	var enc archiveEncoder
+	if t.Format == model.ArchiveTarGz {
+		enc = newTarGzEncoder(w)
+	} else {
+		enc = &zipEncoder{w: zip.NewWriter(w), password: t.Password}
+	}
+	for _, e := range entries {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		if e.obj.IsDir() {
+			if _, err := enc.create(e.name+"/", e.obj, 0); err != nil {
+				return errors.WithMessagef(err, "failed add [%s]", e.path)
+			}
+			continue
+		}
+		if err := t.compressFile(enc, e, total); err != nil {
+			return errors.WithMessagef(err, "failed add [%s]", e.path)
+		}
+	}
+	return enc.Close()
+}
+
+func (t *ArchiveCompressTask) compressFile(enc archiveEncoder, e compressEntry, total int64) error {
+	l, obj, err := link(t.Ctx(), e.path, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: obj,
+		Ctx: t.Ctx(),
+	}, l)
+	if err != nil {
+		_ = l.Close()
+		return err
+	}
+	defer ss.Close()
+	size := ss.GetSize()
+	w, err := enc.create(e.name, obj, size)
+	if err != nil {
+		return err
+	}
+	compressed := t.compressed
+	err = utils.CopyWithCtx(t.Ctx(), w, ss, size, func(p float64) {
+		if total > 0 {
+			t.SetProgress((float64(compressed) + p*float64(size)/100) / float64(total) * 50)
+		}
+	})
+	t.compressed += size
+	return err
+}
+
+type compressEntry struct {
+	// the path in the archive
+	name string
+	path string
+	obj  model.Obj
+}
+
+// walkCompressEntries lists the src objects recursively, the dirs are before the objects in them.
+// The objects hidden from the user in ctx or protected by a meta password other than metaPass are skipped.
+// It returns the entries and the total size of the files.
+func walkCompressEntries(ctx context.Context, srcPaths []string, metaPass string) ([]compressEntry, int64, error) {
+	var (
+		entries []compressEntry
+		total   int64
+	)
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if user == nil {
+		return nil, 0, errors.WithStack(errs.PermissionDenied)
+	}
+	canAccess := func(path string) (bool, error) {
+		meta, err := op.GetNearestMeta(path)
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			return false, err
+		}
+		return common.CanAccess(user, meta, path, metaPass), nil
+	}
+	var walk func(path, name string) error
+	walk = func(path, name string) error {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		objs, err := list(ctx, path, &ListArgs{NoLog: true})
+		if err != nil {
+			return errors.WithMessagef(err, "failed list [%s]", path)
+		}
+		for _, obj := range objs {
+			e := compressEntry{
+				name: name + "/" + obj.GetName(),
+				path: stdpath.Join(path, obj.GetName()),
+				obj:  obj,
+			}
+			if ok, err := canAccess(e.path); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+			entries = append(entries, e)
+			if obj.IsDir() {
+				if err = walk(e.path, e.name); err != nil {
+					return err
+				}
+			} else {
+				total += obj.GetSize()
+			}
+		}
+		return nil
+	}
+	for _, path := range srcPaths {
+		if ok, err := canAccess(path); err != nil {
+			return nil, 0, err
+		} else if !ok {
+			return nil, 0, errors.WithMessagef(errs.PermissionDenied, "can't access [%s]", path)
+		}
+		obj, err := get(ctx, path, &GetArgs{NoLog: true})
+		if err != nil {
+			return nil, 0, errors.WithMessagef(err, "failed get [%s]", path)
+		}
+		e := compressEntry{name: stdpath.Base(path), path: path, obj: obj}
+		entries = append(entries, e)
+		if !obj.IsDir() {
+			total += obj.GetSize()
+			continue
+		}
+		if err = walk(e.path, e.name); err != nil {
+			return nil, 0, err
+		}
+	}
+	return entries, total, nil
+}
+
+// archiveEncoder writes the entries of an archive one by one
+type archiveEncoder interface {
+	// create adds the entry of the file or the dir, the name of a dir ends with /
+	create(name string, obj model.Obj, size int64) (io.Writer, error)
+	Close() error
+}
+
+type zipEncoder struct {
+	w        *zip.Writer
+	password string
+}
+
+func (z *zipEncoder) create(name string, obj model.Obj, size int64) (io.Writer, error) {
+	fh := &zip.FileHeader{
+		Name:   name,
+		Method: zip.Deflate,
+	}
+	fh.SetModTime(modTime(obj))
+	if obj.IsDir() {
+		fh.Method = zip.Store
+		fh.SetMode(os.ModeDir | 0o755)
+		return z.w.CreateHeader(fh)
+	}
+	fh.SetMode(0o644)
+	if z.password != "" {
+		fh.SetPassword(z.password)
+		fh.SetEncryptionMethod(zip.AES256Encryption)
+	}
+	return z.w.CreateHeader(fh)
+}
+
+func (z *zipEncoder) Close() error {
+	return z.w.Close()
+}
+
+type tarGzEncoder struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzEncoder(w io.Writer) *tarGzEncoder {
+	gw := gzip.NewWriter(w)
+	return &tarGzEncoder{gw: gw, tw: tar.NewWriter(gw)}
+}
+
+func (t *tarGzEncoder) create(name string, obj model.Obj, size int64) (io.Writer, error) {
+	hdr := &tar.Header{
+		Name:     name,
+		Typeflag: tar.TypeReg,
+		Mode:     0o644,
+		Size:     size,
+		ModTime:  modTime(obj),
+	}
+	if obj.IsDir() {
+		hdr.Typeflag = tar.TypeDir
+		hdr.Mode = 0o755
+		hdr.Size = 0
+	}
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	return t.tw, nil
+}
+
+func (t *tarGzEncoder) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	return t.gw.Close()
+}
+
+func modTime(obj model.Obj) time.Time {
+	// the virtual dirs of the storages have no modified time
+	if m := obj.ModTime(); !m.IsZero() {
+		return m
+	}
+	return time.Now()
+}
+
+var ArchiveCompressTaskManager *tache.Manager[*ArchiveCompressTask]
+
+func archiveCompress(ctx context.Context, srcPaths []string, dstPath string, args model.ArchiveCompressArgs) (task.TaskExtensionInfo, error) {
+	switch args.Format {
+	case model.ArchiveZip:
+	case model.ArchiveTarGz:
+		if args.Password != "" {
+			return nil, errors.New("only the zip can be encrypted")
+		}
+	default:
+		return nil, errors.Errorf("unsupported archive format: %s", args.Format)
+	}
+	if len(srcPaths) == 0 {
+		return nil, errors.New("no object to compress")
+	}
+	names := make(map[string]struct{}, len(srcPaths))
+	for _, path := range srcPaths {
+		if path == "/" {
+			return nil, errors.New("can't compress the root")
+		}
+		// the src objects are the top entries of the archive
+		name := stdpath.Base(path)
+		if _, ok := names[name]; ok {
+			return nil, errors.Errorf("duplicate name of the objects to compress: %s", name)
+		}
+		names[name] = struct{}{}
+	}
+	dstStorage, dstActualPath, err := op.GetStorageAndActualPath(dstPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	if !args.Overwrite {
+		if res, _ := op.Get(ctx, dstStorage, dstActualPath); res != nil {
+			return nil, errs.ObjectAlreadyExists
+		}
+	}
+	tsk := &ArchiveCompressTask{
+		SrcPaths:            srcPaths,
+		DstActualPath:       dstActualPath,
+		DstStorageMp:        dstStorage.GetStorage().MountPath,
+		dstStorage:          dstStorage,
+		ArchiveCompressArgs: args,
+	}
+	tsk.Creator, _ = ctx.Value(conf.UserKey).(*model.User)
+	tsk.ApiUrl = common.GetApiUrl(ctx)
+	ArchiveCompressTaskManager.Add(tsk)
+	return tsk, nil
+}
@@ -79,13 +79,22 @@ func (t *FileTransferTask) Run() error {
 }
 
 func (t *FileTransferTask) OnSucceeded() {
+	t.recordDone()
+	task_group.TransferCoordinator.AppendResult(t.groupID, t, t.TaskType.String(), true)
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
 }
 
 func (t *FileTransferTask) OnFailed() {
+	t.recordDone()
+	task_group.TransferCoordinator.AppendResult(t.groupID, t, t.TaskType.String(), false)
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
 }
 
+func (t *FileTransferTask) recordDone() {
+	recordTaskDone(t, t.TaskType.String(), utils.GetFullPath(t.SrcStorageMp, t.SrcActualPath),
+		utils.GetFullPath(t.DstStorageMp, t.DstActualPath), t.GetTotalBytes())
+}
+
 func (t *FileTransferTask) SetRetry(retry int, maxRetry int) {
 	t.TaskData.SetRetry(retry, maxRetry)
 	if retry == 0 &&
@@ -3,9 +3,12 @@ package fs
 import (
 	"context"
 	"io"
+	stdpath "path"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -65,14 +68,20 @@ func MakeDir(ctx context.Context, path string) error {
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
 	}
+	audit.Record(ctx, model.AuditMkdir, path, "", 0, err)
 	return err
 }
 
 func Move(ctx context.Context, srcPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error) {
-	req, err := transfer(ctx, move, srcPath, dstDirPath, skipHook...)
+	var req task.TaskExtensionInfo
+	err := op.CheckDavLocks(ctx, srcPath, stdpath.Join(dstDirPath, stdpath.Base(srcPath)))
+	if err == nil {
+		req, err = transfer(ctx, move, srcPath, dstDirPath, skipHook...)
+	}
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
 	}
+	recordTask(ctx, model.AuditMove, srcPath, dstDirPath, 0, req, err)
 	return req, err
 }
 
@@ -81,6 +90,7 @@ func Copy(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	recordTask(ctx, model.AuditCopy, srcObjPath, dstDirPath, 0, res, err)
 	return res, err
 }
 
@@ -89,38 +99,51 @@ func Merge(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool)
 	if err != nil {
 		log.Errorf("failed merge %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	recordTask(ctx, model.AuditMerge, srcObjPath, dstDirPath, 0, res, err)
 	return res, err
 }
 
 func Rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) error {
-	err := rename(ctx, srcPath, dstName, skipHook...)
+	err := op.CheckDavLocks(ctx, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName))
+	if err == nil {
+		err = rename(ctx, srcPath, dstName, skipHook...)
+	}
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
 	}
+	audit.Record(ctx, model.AuditRename, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName), 0, err)
 	return err
 }
 
 func Remove(ctx context.Context, path string) error {
-	err := remove(ctx, path)
+	err := op.CheckDavLocks(ctx, path)
+	if err == nil {
+		err = remove(ctx, path)
+	}
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
 	}
+	audit.Record(ctx, model.AuditRemove, path, "", 0, err)
 	return err
 }
 
 func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, skipHook ...bool) error {
+	dstPath, size := stdpath.Join(dstDirPath, file.GetName()), file.GetSize()
 	err := putDirectly(ctx, dstDirPath, file, skipHook...)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	audit.Record(ctx, model.AuditUpload, "", dstPath, size, err)
 	return err
 }
 
 func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
+	dstPath, size := stdpath.Join(dstDirPath, file.GetName()), file.GetSize()
 	t, err := putAsTask(ctx, dstDirPath, file)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	recordTask(ctx, model.AuditUpload, "", dstPath, size, t, err)
 	return t, err
 }
 
@@ -145,6 +168,16 @@ func ArchiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	if err != nil {
 		log.Errorf("failed decompress [%s]%s: %+v", srcObjPath, args.InnerPath, err)
 	}
+	recordTask(ctx, model.AuditDecompress, stdpath.Join(srcObjPath, args.InnerPath), dstDirPath, 0, t, err)
+	return t, err
+}
+
+func ArchiveCompress(ctx context.Context, srcPaths []string, dstPath string, args model.ArchiveCompressArgs) (task.TaskExtensionInfo, error) {
+	t, err := archiveCompress(ctx, srcPaths, dstPath, args)
+	if err != nil {
+		log.Errorf("failed compress %v to %s: %+v", srcPaths, dstPath, err)
+	}
+	recordTask(ctx, model.AuditCompress, strings.Join(srcPaths, ", "), dstPath, 0, t, err)
 	return t, err
 }
 
@@ -184,6 +217,12 @@ func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 }
 
 func PutURL(ctx context.Context, path, dstName, urlStr string) error {
+	err := putURL(ctx, path, dstName, urlStr)
+	audit.Record(ctx, model.AuditPutURL, urlStr, stdpath.Join(path, dstName), 0, err)
+	return err
+}
+
+func putURL(ctx context.Context, path, dstName, urlStr string) error {
 	storage, dstDirActualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -206,3 +245,18 @@ func GetDirectUploadInfo(ctx context.Context, tool, path, dstName string, fileSi
 	}
 	return info, err
 }
+
+// recordTask records an operation that may have been submitted as a task
+func recordTask(ctx context.Context, action, src, dst string, size int64, t task.TaskExtensionInfo, err error) {
+	if err == nil && t != nil {
+		audit.RecordResult(ctx, action, src, dst, size, model.AuditQueued, nil)
+		return
+	}
+	audit.Record(ctx, action, src, dst, size, err)
+}
+
+// recordTaskDone records the outcome of a task recorded as queued by recordTask,
+// it's called by the tasks on success or failure
+func recordTaskDone(t task.TaskExtensionInfo, action, src, dst string, size int64) {
+	audit.Record(t.Ctx(), action, src, dst, size, t.GetErr())
+}
@@ -2,6 +2,7 @@ package fs
 
 import (
 	"context"
+	stdpath "path"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -36,6 +37,8 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 				return nil, errors.WithMessage(err, "failed get objs")
 			}
 		}
+		_objs = hideTrash(storage, actualPath, _objs)
+		_objs = hideVersions(storage, actualPath, _objs)
 	}
 
 	om := model.NewObjMerge()
@@ -43,12 +46,18 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 		om.InitHideReg(meta.Hide)
 	}
 	objs := om.Merge(_objs, virtualFiles...)
+	if user != nil && !user.IsAdmin() {
+		// drop the objects that path rules deny the user to read
+		objs = utils.SliceFilter(objs, func(obj model.Obj) bool {
+			return model.CanRead(op.GetUserPermission(user, stdpath.Join(path, obj.GetName())))
+		})
+	}
 	return objs, nil
 }
 
 func whetherHide(user *model.User, meta *model.Meta, path string) bool {
 	// if is admin, don't hide
-	if user == nil || user.CanSeeHides() {
+	if user == nil || model.CanSeeHides(op.GetUserPermission(user, path)) {
 		return false
 	}
 	// if meta is nil, don't hide
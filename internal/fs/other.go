@@ -36,6 +36,9 @@ func remove(ctx context.Context, path string) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	if trashable(storage, actualPath) {
+		return moveToTrash(ctx, storage, path, actualPath)
+	}
 	return op.Remove(ctx, storage, actualPath)
 }
 
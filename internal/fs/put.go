@@ -39,15 +39,21 @@ func (t *UploadTask) Run() error {
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
-	return op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.storage, t.dstDirActualPath, t.file, t.SetProgress)
+	return put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.storage, t.dstDirActualPath, t.file, t.SetProgress)
 }
 
 func (t *UploadTask) OnSucceeded() {
-	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath), true)
+	groupID := stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath)
+	recordTaskDone(t, model.AuditUpload, "", stdpath.Join(groupID, t.file.GetName()), t.file.GetSize())
+	task_group.TransferCoordinator.AppendResult(groupID, t, "upload", true)
+	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), groupID, true)
 }
 
 func (t *UploadTask) OnFailed() {
-	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath), false)
+	groupID := stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath)
+	recordTaskDone(t, model.AuditUpload, "", stdpath.Join(groupID, t.file.GetName()), t.file.GetSize())
+	task_group.TransferCoordinator.AppendResult(groupID, t, "upload", false)
+	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), groupID, false)
 }
 
 func (t *UploadTask) SetRetry(retry int, maxRetry int) {
@@ -69,6 +75,12 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 	if storage.Config().NoUpload {
 		return nil, errors.WithStack(errs.UploadNotSupported)
 	}
+	if err = op.CheckPutQuota(ctx, storage, dstDirActualPath, file); err != nil {
+		return nil, err
+	}
+	if err = op.CheckDavLocks(ctx, stdpath.Join(dstDirPath, file.GetName())); err != nil {
+		return nil, err
+	}
 	if file.NeedStore() {
 		_, err := file.CacheFullAndWriter(nil, nil)
 		if err != nil {
@@ -104,10 +116,14 @@ func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 		_ = file.Close()
 		return errors.WithStack(errs.UploadNotSupported)
 	}
+	if err = op.CheckDavLocks(ctx, stdpath.Join(dstDirPath, file.GetName())); err != nil {
+		_ = file.Close()
+		return err
+	}
 	if utils.IsBool(skipHook...) {
 		ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
 	}
-	return op.Put(ctx, storage, dstDirActualPath, file, nil)
+	return put(ctx, storage, dstDirActualPath, file, nil)
 }
 
 func getDirectUploadInfo(ctx context.Context, tool, dstDirPath, dstName string, fileSize int64) (any, error) {
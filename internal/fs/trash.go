@@ -0,0 +1,185 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the removed objects of a storage with trash enabled are moved to
+// <trash path>/<time>-<random>/<name>, and recorded as model.TrashItem
+
+// trashable reports whether removing actualPath of the storage should move it to the trash
+func trashable(storage driver.Driver, actualPath string) bool {
+	s := storage.GetStorage()
+	if !s.TrashEnabled {
+		return false
+	}
+	// the objects in the trash are removed permanently
+	if utils.IsSubPath(s.GetTrashPath(), actualPath) {
+		return false
+	}
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		return true
+	default:
+		return false
+	}
+}
+
+func moveToTrash(ctx context.Context, storage driver.Driver, path, actualPath string) error {
+	s := storage.GetStorage()
+	trashPath := s.GetTrashPath()
+	if utils.IsSubPath(actualPath, trashPath) {
+		return errors.Errorf("%s contains the trash directory", path)
+	}
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		// same as op.Remove, it's ok if the object is not found
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return errors.WithMessage(err, "failed to get object")
+	}
+	if model.ObjHasMask(obj, model.NoRemove) {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	dir := stdpath.Join(trashPath, time.Now().Format("20060102150405")+"-"+random.String(6))
+	if err = op.MakeDir(ctx, storage, dir); err != nil {
+		return errors.WithMessage(err, "failed make trash dir")
+	}
+	if err = op.Move(ctx, storage, actualPath, dir); err != nil {
+		return errors.WithMessage(err, "failed move to trash")
+	}
+	item := &model.TrashItem{
+		StorageId:  s.ID,
+		Path:       path,
+		ActualPath: actualPath,
+		Dir:        dir,
+		IsDir:      obj.IsDir(),
+		Size:       obj.GetSize(),
+		Deleted:    time.Now(),
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		item.UserId = user.ID
+		item.Username = user.Username
+	}
+	return db.CreateTrashItem(item)
+}
+
+// hideTrash drops the trash directory from the objects listed in actualPath of the storage
+func hideTrash(storage driver.Driver, actualPath string, objs []model.Obj) []model.Obj {
+	s := storage.GetStorage()
+	if !s.TrashEnabled {
+		return objs
+	}
+	trashPath := s.GetTrashPath()
+	return utils.SliceFilter(objs, func(obj model.Obj) bool {
+		return !utils.PathEqual(stdpath.Join(actualPath, obj.GetName()), trashPath)
+	})
+}
+
+func getStorageById(id uint) (driver.Driver, error) {
+	for _, storage := range op.GetAllStorages() {
+		if storage.GetStorage().ID == id {
+			return storage, nil
+		}
+	}
+	return nil, errors.WithStack(errs.StorageNotFound)
+}
+
+// RestoreTrash moves the object of the trash item back to its original path
+func RestoreTrash(ctx context.Context, id uint) error {
+	item, err := db.GetTrashItemById(id)
+	if err != nil {
+		return err
+	}
+	err = restoreTrash(ctx, item)
+	if err != nil {
+		log.Errorf("failed restore %s: %+v", item.Path, err)
+	}
+	audit.Record(ctx, model.AuditTrashRestore, stdpath.Join(item.Dir, stdpath.Base(item.ActualPath)), item.Path, item.Size, err)
+	return err
+}
+
+func restoreTrash(ctx context.Context, item *model.TrashItem) error {
+	storage, err := getStorageById(item.StorageId)
+	if err != nil {
+		return err
+	}
+	if _, err = op.Get(ctx, storage, item.ActualPath); err == nil {
+		return errors.Errorf("%s already exists", item.Path)
+	}
+	dstDir := stdpath.Dir(item.ActualPath)
+	if err = op.MakeDir(ctx, storage, dstDir); err != nil {
+		return errors.WithMessagef(err, "failed make dir [%s]", dstDir)
+	}
+	if err = op.Move(ctx, storage, stdpath.Join(item.Dir, stdpath.Base(item.ActualPath)), dstDir); err != nil {
+		return errors.WithMessage(err, "failed move out of trash")
+	}
+	if err = op.Remove(ctx, storage, item.Dir); err != nil {
+		log.Warnf("failed remove trash dir %s: %+v", item.Dir, err)
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrash removes the object of the trash item permanently
+func PurgeTrash(ctx context.Context, id uint) error {
+	item, err := db.GetTrashItemById(id)
+	if err != nil {
+		return err
+	}
+	err = purgeTrash(ctx, item)
+	if err != nil {
+		log.Errorf("failed purge %s: %+v", item.Path, err)
+	}
+	audit.Record(ctx, model.AuditTrashPurge, item.Path, "", item.Size, err)
+	return err
+}
+
+func purgeTrash(ctx context.Context, item *model.TrashItem) error {
+	storage, err := getStorageById(item.StorageId)
+	if err == nil {
+		err = op.Remove(ctx, storage, item.Dir)
+	}
+	// the record of an unmounted storage is useless
+	if err != nil && !errors.Is(err, errs.StorageNotFound) {
+		return err
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeExpiredTrash purges the items that have been in the trash longer than the retention days of their storage
+func PurgeExpiredTrash(ctx context.Context) {
+	for _, storage := range op.GetAllStorages() {
+		s := storage.GetStorage()
+		if !s.TrashEnabled || s.TrashRetentionDays <= 0 {
+			continue
+		}
+		items, err := db.GetTrashItemsBefore(s.ID, time.Now().AddDate(0, 0, -s.TrashRetentionDays))
+		if err != nil {
+			log.Errorf("failed get expired trash items of %s: %+v", s.MountPath, err)
+			continue
+		}
+		for i := range items {
+			if err = purgeTrash(ctx, &items[i]); err != nil {
+				log.Errorf("failed purge %s: %+v", items[i].Path, err)
+			}
+		}
+		if len(items) > 0 {
+			log.Infof("purged %d expired trash items of %s", len(items), s.MountPath)
+		}
+	}
+}
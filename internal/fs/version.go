@@ -0,0 +1,289 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the files of a storage with versioning enabled are copied to
+// <versions path>/<time>-<random>/<name> of the versions storage before they are overwritten,
+// and recorded as model.FileVersion
+
+// getVersionsStorage returns the storage keeping the versions of the files of storage
+func getVersionsStorage(storage driver.Driver) (driver.Driver, error) {
+	s := storage.GetStorage()
+	if s.VersionsStorage == "" {
+		return storage, nil
+	}
+	return op.GetStorageByMountPath(utils.FixAndCleanPath(s.VersionsStorage))
+}
+
+// versionsPaths returns the versions directories kept in storage
+func versionsPaths(storage driver.Driver) []string {
+	var paths []string
+	mountPath := storage.GetStorage().MountPath
+	for _, d := range op.GetAllStorages() {
+		s := d.GetStorage()
+		if !s.VersioningEnabled {
+			continue
+		}
+		if (s.VersionsStorage == "" && s.MountPath == mountPath) ||
+			(s.VersionsStorage != "" && utils.PathEqual(s.VersionsStorage, mountPath)) {
+			paths = append(paths, s.GetVersionsPath())
+		}
+	}
+	return paths
+}
+
+// IsVersionsPath reports whether actualPath of the storage is in a versions directory
+func IsVersionsPath(storage driver.Driver, actualPath string) bool {
+	for _, p := range versionsPaths(storage) {
+		if utils.IsSubPath(p, actualPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// hideVersions drops the versions directories from the objects listed in actualPath of the storage
+func hideVersions(storage driver.Driver, actualPath string, objs []model.Obj) []model.Obj {
+	paths := versionsPaths(storage)
+	if len(paths) == 0 {
+		return objs
+	}
+	return utils.SliceFilter(objs, func(obj model.Obj) bool {
+		p := stdpath.Join(actualPath, obj.GetName())
+		for _, versionsPath := range paths {
+			if utils.PathEqual(p, versionsPath) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// put puts the file to dstDirActualPath of the storage,
+// the file to be overwritten is kept as a version first
+func put(ctx context.Context, storage driver.Driver, dstDirActualPath string, file model.FileStreamer, up driver.UpdateProgress) error {
+	dstPath := stdpath.Join(dstDirActualPath, file.GetName())
+	if err := keepVersion(ctx, storage, dstPath); err != nil {
+		_ = file.Close()
+		return errors.WithMessage(err, "failed keep the version of the existing file")
+	}
+	err := op.Put(ctx, storage, dstDirActualPath, file, up)
+	pruneVersions(ctx, storage, dstPath)
+	return err
+}
+
+func keepVersion(ctx context.Context, storage driver.Driver, actualPath string) error {
+	s := storage.GetStorage()
+	if !s.VersioningEnabled || IsVersionsPath(storage, actualPath) {
+		return nil
+	}
+	old, err := op.GetUnwrap(ctx, storage, actualPath)
+	// an empty file is removed by op.Put, nothing to keep
+	if err != nil || old.IsDir() || old.GetSize() == 0 {
+		return nil
+	}
+	versionsStorage, err := getVersionsStorage(storage)
+	if err != nil {
+		return errors.WithMessage(err, "failed get versions storage")
+	}
+	// the version is charged to the quotas of the file too, those of the versions dir are applied when it's written
+	path := utils.GetFullPath(s.MountPath, actualPath)
+	versionsPath := utils.GetFullPath(versionsStorage.GetStorage().MountPath, s.GetVersionsPath())
+	if err = op.CheckQuota(ctx, path, old.GetSize(), 1, versionsPath); err != nil {
+		return err
+	}
+	dir := stdpath.Join(s.GetVersionsPath(), time.Now().Format("20060102150405")+"-"+random.String(6))
+	if err = op.MakeDir(ctx, versionsStorage, dir); err != nil {
+		return errors.WithMessage(err, "failed make versions dir")
+	}
+	err = errs.NotImplement
+	if versionsStorage == storage {
+		err = op.Copy(ctx, storage, actualPath, dir)
+	}
+	if errors.Is(err, errs.NotImplement) || errors.Is(err, errs.NotSupport) {
+		err = transferFile(ctx, storage, actualPath, versionsStorage, dir, old.GetName())
+	}
+	if err != nil {
+		return err
+	}
+	op.AddQuotaUsage(path, old.GetSize(), 1, versionsPath)
+	v := &model.FileVersion{
+		StorageId:        s.ID,
+		Path:             path,
+		VersionStorageId: versionsStorage.GetStorage().ID,
+		Dir:              dir,
+		Name:             old.GetName(),
+		Size:             old.GetSize(),
+		Modified:         old.ModTime(),
+		Created:          time.Now(),
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		v.UserId = user.ID
+		v.Username = user.Username
+	}
+	return db.CreateFileVersion(v)
+}
+
+// transferFile streams the file at srcPath of srcStorage to dstDirPath of dstStorage as name
+func transferFile(ctx context.Context, srcStorage driver.Driver, srcPath string, dstStorage driver.Driver, dstDirPath, name string) error {
+	link, srcObj, err := op.Link(ctx, srcStorage, srcPath, model.LinkArgs{})
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] link", srcPath)
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     srcObj.GetSize(),
+			Modified: srcObj.ModTime(),
+			Ctime:    srcObj.CreateTime(),
+		},
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return errors.WithMessagef(err, "failed get [%s] stream", srcPath)
+	}
+	return op.Put(ctx, dstStorage, dstDirPath, ss, nil)
+}
+
+// pruneVersions removes the versions of the file beyond the max count of the storage
+func pruneVersions(ctx context.Context, storage driver.Driver, actualPath string) {
+	s := storage.GetStorage()
+	if !s.VersioningEnabled || s.VersionsMaxCount <= 0 {
+		return
+	}
+	path := utils.GetFullPath(s.MountPath, actualPath)
+	versions, err := db.GetFileVersionsByPath(path)
+	if err != nil {
+		log.Errorf("failed get versions of %s: %+v", path, err)
+		return
+	}
+	for i := s.VersionsMaxCount; i < len(versions); i++ {
+		if err = deleteVersion(ctx, &versions[i]); err != nil {
+			log.Errorf("failed delete version %d of %s: %+v", versions[i].ID, path, err)
+		}
+	}
+}
+
+func GetVersions(path string) ([]model.FileVersion, error) {
+	return db.GetFileVersionsByPath(utils.FixAndCleanPath(path))
+}
+
+// RestoreVersion puts the content of the version back to the file,
+// the current content is kept as a version in turn
+func RestoreVersion(ctx context.Context, id uint) error {
+	v, err := db.GetFileVersionById(id)
+	if err != nil {
+		return err
+	}
+	err = restoreVersion(ctx, v)
+	if err != nil {
+		log.Errorf("failed restore version %d of %s: %+v", v.ID, v.Path, err)
+	}
+	audit.Record(ctx, model.AuditVersionRestore, stdpath.Join(v.Dir, v.Name), v.Path, v.Size, err)
+	return err
+}
+
+func restoreVersion(ctx context.Context, v *model.FileVersion) error {
+	versionsStorage, err := getStorageById(v.VersionStorageId)
+	if err != nil {
+		return err
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(v.Path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if err = op.CheckDavLocks(ctx, v.Path); err != nil {
+		return err
+	}
+	if err = keepVersion(ctx, storage, actualPath); err != nil {
+		return errors.WithMessage(err, "failed keep the version of the existing file")
+	}
+	// the restored version may be pruned, so prune after it's been put back
+	err = transferFile(ctx, versionsStorage, stdpath.Join(v.Dir, v.Name), storage, stdpath.Dir(actualPath), stdpath.Base(actualPath))
+	pruneVersions(ctx, storage, actualPath)
+	return err
+}
+
+// DeleteVersion removes the version permanently
+func DeleteVersion(ctx context.Context, id uint) error {
+	v, err := db.GetFileVersionById(id)
+	if err != nil {
+		return err
+	}
+	err = deleteVersion(ctx, v)
+	if err != nil {
+		log.Errorf("failed delete version %d of %s: %+v", v.ID, v.Path, err)
+	}
+	audit.Record(ctx, model.AuditVersionDelete, v.Path, "", v.Size, err)
+	return err
+}
+
+func deleteVersion(ctx context.Context, v *model.FileVersion) error {
+	storage, err := getStorageById(v.VersionStorageId)
+	if err == nil {
+		err = op.Remove(ctx, storage, v.Dir)
+	}
+	if err == nil {
+		op.AddQuotaUsage(v.Path, -v.Size, -1, utils.GetFullPath(storage.GetStorage().MountPath, stdpath.Dir(v.Dir)))
+	}
+	// the record of an unmounted storage is useless
+	if err != nil && !errors.Is(err, errs.StorageNotFound) {
+		return err
+	}
+	return db.DeleteFileVersionById(v.ID)
+}
+
+// PruneExpiredVersions removes the versions older than the retention days of their storage
+func PruneExpiredVersions(ctx context.Context) {
+	for _, storage := range op.GetAllStorages() {
+		s := storage.GetStorage()
+		if !s.VersioningEnabled || s.VersionsRetention <= 0 {
+			continue
+		}
+		versions, err := db.GetFileVersionsBefore(s.ID, time.Now().AddDate(0, 0, -s.VersionsRetention))
+		if err != nil {
+			log.Errorf("failed get expired versions of %s: %+v", s.MountPath, err)
+			continue
+		}
+		for i := range versions {
+			if err = deleteVersion(ctx, &versions[i]); err != nil {
+				log.Errorf("failed delete version %d of %s: %+v", versions[i].ID, versions[i].Path, err)
+			}
+		}
+		if len(versions) > 0 {
+			log.Infof("pruned %d expired versions of %s", len(versions), s.MountPath)
+		}
+	}
+}
+
+// handleObjEvent keeps the versions following their files when they are moved or renamed
+func handleObjEvent(ctx context.Context, event op.ObjEvent) {
+	if event.Type != op.ObjEventMove {
+		return
+	}
+	if err := db.MoveFileVersions(event.Path, event.DstPath); err != nil {
+		log.Errorf("failed move versions of %s: %+v", event.Path, err)
+	}
+}
+
+func init() {
+	op.RegisterObjEventHook(handleObjEvent)
+}
@@ -0,0 +1,200 @@
+package fuse
+
+import (
+	"io"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// fileHandle is an opened file.
+// Reads are served by ranged requests on the link of the object until the file is written,
+// after that the whole content is staged in a temp file of conf.TempDir and
+// committed with fs.PutDirectly on flush.
+type fileHandle struct {
+	fs       *Fs
+	reqPath  string
+	obj      model.Obj
+	writable bool
+
+	mu     sync.Mutex
+	ss     *stream.SeekableStream
+	reader model.File
+	tmp    *os.File
+	dirty  bool
+}
+
+// stat returns the object with the size of the staged content if any
+func (h *fileHandle) stat() model.Obj {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tmp == nil {
+		return h.obj
+	}
+	info, err := h.tmp.Stat()
+	if err != nil {
+		return h.obj
+	}
+	return &model.Object{
+		Name:     h.obj.GetName(),
+		Size:     info.Size(),
+		Modified: info.ModTime(),
+		Ctime:    h.obj.CreateTime(),
+	}
+}
+
+func (h *fileHandle) openReader() error {
+	if h.reader != nil {
+		return nil
+	}
+	link, obj, err := fs.Link(h.fs.ctx(), h.reqPath, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: obj,
+		Ctx: h.fs.ctx(),
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return err
+	}
+	reader, err := stream.NewReadAtSeeker(ss, 0)
+	if err != nil {
+		_ = ss.Close()
+		return err
+	}
+	h.ss = ss
+	h.reader = reader
+	return nil
+}
+
+func (h *fileHandle) readAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var (
+		n   int
+		err error
+	)
+	if h.tmp != nil {
+		n, err = h.tmp.ReadAt(p, off)
+	} else {
+		if off >= h.obj.GetSize() {
+			return 0, nil
+		}
+		if err = h.openReader(); err != nil {
+			return 0, err
+		}
+		n, err = h.reader.ReadAt(p, off)
+	}
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return n, err
+}
+
+// stage creates the temp file, copying the current content if withContent is set
+func (h *fileHandle) stage(withContent bool) error {
+	if h.tmp != nil {
+		return nil
+	}
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return err
+	}
+	if withContent && h.obj.GetSize() > 0 {
+		err = h.openReader()
+		if err == nil {
+			_, err = utils.CopyWithBuffer(tmp, io.NewSectionReader(h.reader, 0, h.obj.GetSize()))
+		}
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+			return errors.WithMessage(err, "failed stage content")
+		}
+	}
+	h.tmp = tmp
+	return nil
+}
+
+func (h *fileHandle) writeAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.stage(true); err != nil {
+		return 0, err
+	}
+	h.dirty = true
+	return h.tmp.WriteAt(p, off)
+}
+
+func (h *fileHandle) truncate(size int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.stage(size > 0); err != nil {
+		return err
+	}
+	h.dirty = true
+	return h.tmp.Truncate(size)
+}
+
+// flush uploads the staged content if it has been changed since the last flush
+func (h *fileHandle) flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.dirty || h.tmp == nil {
+		return nil
+	}
+	size, err := h.tmp.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err = h.tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	dir, name := stdpath.Split(h.reqPath)
+	obj := &model.Object{
+		Name:     name,
+		Size:     size,
+		Modified: time.Now(),
+		Ctime:    h.obj.CreateTime(),
+	}
+	s := &stream.FileStream{
+		Ctx:      h.fs.ctx(),
+		Obj:      obj,
+		Reader:   h.tmp,
+		Mimetype: utils.GetMimeType(name),
+	}
+	if err = fs.PutDirectly(h.fs.ctx(), dir, s); err != nil {
+		return err
+	}
+	h.dirty = false
+	h.obj = obj
+	return nil
+}
+
+func (h *fileHandle) release() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var err error
+	if h.ss != nil {
+		err = h.ss.Close()
+		h.ss = nil
+		h.reader = nil
+	}
+	if h.tmp != nil {
+		_ = h.tmp.Close()
+		if e := os.Remove(h.tmp.Name()); err == nil {
+			err = e
+		}
+		h.tmp = nil
+	}
+	return err
+}
@@ -1,170 +1,598 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
-
+import (
+	"context"
+	"math"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// Fs exposes the virtual tree below RootFolder through cgofuse.
+// Every operation is performed on behalf of User and goes through the fs package,
+// so metas, permissions and hooks behave the same as with the other protocols.
 type Fs struct {
 	RootFolder string
+	User       *model.User
 	fuse.FileSystemBase
-}
-
-func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
-}
 
-func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+	mu      sync.Mutex
+	nextFh  uint64
+	handles map[uint64]*fileHandle
+	// files opened for writing, keyed by virtual path, so that Getattr
+	// reports the staged size before the content is committed
+	pending map[string]*fileHandle
+}
+
+func NewFs(rootFolder string, user *model.User) *Fs {
+	return &Fs{
+		RootFolder: utils.FixAndCleanPath(rootFolder),
+		User:       user,
+		handles:    make(map[uint64]*fileHandle),
+		pending:    make(map[string]*fileHandle),
+	}
+}
+
+func (f *Fs) ctx() context.Context {
+	ctx := context.WithValue(context.Background(), conf.UserKey, f.User)
+	ctx = context.WithValue(ctx, conf.MetaPassKey, "")
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.ProtocolFuse)
+	return ctx
+}
+
+// reqPath converts a path inside the mount point to a path of the virtual tree
+func (f *Fs) reqPath(path string) (string, error) {
+	return f.User.JoinPath(stdpath.Join(f.RootFolder, path))
+}
+
+func (f *Fs) canAccess(reqPath string) bool {
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	return common.CanAccess(f.User, meta, reqPath, "")
+}
+
+func (f *Fs) canWrite(reqPath string) bool {
+	if common.HasPermission(f.User, reqPath, model.CanWrite) {
+		return true
+	}
+	meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
+	if err != nil {
+		return false
+	}
+	return common.CanWrite(meta, stdpath.Dir(reqPath))
+}
+
+func (f *Fs) Init() {
+	log.Infof("fuse: mounted [%s] for user [%s]", f.RootFolder, f.User.Username)
+}
+
+func (f *Fs) Destroy() {
+	f.mu.Lock()
+	handles := f.handles
+	f.handles = make(map[uint64]*fileHandle)
+	f.pending = make(map[string]*fileHandle)
+	f.mu.Unlock()
+	for _, h := range handles {
+		if err := h.release(); err != nil {
+			log.Errorf("fuse: failed release %s: %+v", h.reqPath, err)
+		}
+	}
+}
+
+func (f *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
+	// the virtual tree has no meaningful capacity, report a large free space
+	// so that clients don't refuse to write
+	const blockSize = 4096
+	stat.Bsize = blockSize
+	stat.Frsize = blockSize
+	stat.Blocks = math.MaxUint32
+	stat.Bfree = math.MaxUint32
+	stat.Bavail = math.MaxUint32
+	stat.Files = math.MaxUint32
+	stat.Ffree = math.MaxUint32
+	stat.Favail = math.MaxUint32
+	stat.Namemax = 255
+	return 0
+}
+
+func (f *Fs) Mkdir(path string, mode uint32) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if !f.canWrite(reqPath) {
+		return -fuse.EACCES
+	}
+	return errno(fs.MakeDir(f.ctx(), reqPath))
+}
+
+func (f *Fs) Unlink(path string) int {
+	return f.remove(path)
+}
+
+func (f *Fs) Rmdir(path string) int {
+	return f.remove(path)
+}
+
+func (f *Fs) remove(path string) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if !common.HasPermission(f.User, reqPath, model.CanRemove) {
+		return -fuse.EACCES
+	}
+	return errno(fs.Remove(f.ctx(), reqPath))
+}
+
+func (f *Fs) Rename(oldpath string, newpath string) int {
+	srcPath, err := f.reqPath(oldpath)
+	if err != nil {
+		return errno(err)
+	}
+	dstPath, err := f.reqPath(newpath)
+	if err != nil {
+		return errno(err)
+	}
+	if utils.PathEqual(srcPath, dstPath) {
+		return 0
+	}
+	ctx := f.ctx()
+	srcDir, srcBase := stdpath.Split(srcPath)
+	dstDir, dstBase := stdpath.Split(dstPath)
+	srcPerm := op.GetUserPermission(f.User, srcPath)
+	rename := func() error {
+		return fsRename(ctx, srcPath, dstBase)
+	}
+	if srcDir == dstDir {
+		if !model.CanRename(srcPerm) {
+			return -fuse.EACCES
+		}
+	} else {
+		if !model.CanMove(srcPerm) || !common.HasPermission(f.User, dstDir, model.CanMove) ||
+			(srcBase != dstBase && !model.CanRename(srcPerm)) {
+			return -fuse.EACCES
+		}
+		rename = func() error {
+			return renameAcross(ctx, srcPath, dstDir, dstBase)
+		}
+	}
+	dstObj, err := f.getObj(dstPath)
+	if err != nil {
+		if !errs.IsObjectNotFound(err) {
+			return errno(err)
+		}
+		return errno(rename())
+	}
+	// the existing dst is replaced like rename(2) does
+	srcObj, err := f.getObj(srcPath)
+	if err != nil {
+		return errno(err)
+	}
+	switch {
+	case srcObj.IsDir() && !dstObj.IsDir():
+		return -fuse.ENOTDIR
+	case !srcObj.IsDir() && dstObj.IsDir():
+		return -fuse.EISDIR
+	}
+	if !common.HasPermission(f.User, dstPath, model.CanRemove) {
+		return -fuse.EACCES
+	}
+	if dstObj.IsDir() {
+		objs, err := fs.List(ctx, dstPath, &fs.ListArgs{NoLog: true})
+		if err != nil {
+			return errno(err)
+		}
+		if len(objs) > 0 {
+			return -fuse.ENOTEMPTY
+		}
+		if err = fs.Remove(ctx, dstPath); err != nil {
+			return errno(err)
+		}
+		return errno(rename())
+	}
+	return errno(replaceFile(ctx, dstPath, rename))
+}
+
+// the operations used by renameAcross and replaceFile, replaced in the tests
+var (
+	fsRename = fs.Rename
+	fsMove   = fs.Move
+	fsRemove = fs.Remove
+)
+
+// replaceFile runs rename, which puts a file to dstPath where a file exists already, e.g. an editor saving
+// through a temp file. The existing file is renamed aside first and removed after rename succeeds,
+// it's renamed back if rename fails, so that it's never lost.
+func replaceFile(ctx context.Context, dstPath string, rename func() error) error {
+	dstDir, dstBase := stdpath.Split(dstPath)
+	aside := dstBase + ".openlist_replaced"
+	if err := fsRename(ctx, dstPath, aside, true); err != nil {
+		return err
+	}
+	if err := rename(); err != nil {
+		if e := fsRename(ctx, stdpath.Join(dstDir, aside), dstBase, true); e != nil {
+			log.Errorf("fuse: failed rename [%s] back after the rename failed: %+v", stdpath.Join(dstDir, aside), e)
+		}
+		return err
+	}
+	if err := fsRemove(ctx, stdpath.Join(dstDir, aside)); err != nil {
+		log.Errorf("fuse: failed remove the replaced [%s]: %+v", stdpath.Join(dstDir, aside), err)
+	}
+	return nil
+}
+
+// renameAcross moves the object to another dir with a new name. The object is renamed in the src dir
+// before it's moved, and it's renamed back if the move fails, so that it's never left with the new name there.
+func renameAcross(ctx context.Context, srcPath, dstDir, dstBase string) error {
+	srcDir, srcBase := stdpath.Split(srcPath)
+	if srcBase != dstBase {
+		if err := fsRename(ctx, srcPath, dstBase, true); err != nil {
+			return err
+		}
+	}
+	// the move is done before returning, the error of it can't be got from a task
+	_, err := fsMove(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), stdpath.Join(srcDir, dstBase), dstDir)
+	if err != nil && srcBase != dstBase {
+		if e := fsRename(ctx, stdpath.Join(srcDir, dstBase), srcBase, true); e != nil {
+			log.Errorf("fuse: failed rename [%s] back after the move failed: %+v", stdpath.Join(srcDir, dstBase), e)
+		}
+	}
+	return err
+}
+
+// Chmod, Chown and Utimens are accepted but ignored, the drivers have no way to store them
+
+func (f *Fs) Chmod(path string, mode uint32) int {
+	return 0
+}
+
+func (f *Fs) Chown(path string, uid uint32, gid uint32) int {
+	return 0
+}
+
+func (f *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
+	return 0
+}
+
+func (f *Fs) Access(path string, mask uint32) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if !f.canAccess(reqPath) {
+		return -fuse.EACCES
+	}
+	if mask&fuse.W_OK != 0 && !f.canWrite(reqPath) {
+		return -fuse.EACCES
+	}
+	return 0
+}
+
+func (f *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if !f.canWrite(reqPath) {
+		return -fuse.EACCES, ^uint64(0)
+	}
+	h := &fileHandle{
+		fs:      f,
+		reqPath: reqPath,
+		obj: &model.Object{
+			Name:     stdpath.Base(reqPath),
+			Modified: time.Now(),
+		},
+		writable: true,
+	}
+	if err = h.stage(false); err != nil {
+		return errno(err), ^uint64(0)
+	}
+	h.dirty = true
+	return 0, f.addHandle(h)
+}
+
+func (f *Fs) Open(path string, flags int) (int, uint64) {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if !f.canAccess(reqPath) {
+		return -fuse.EACCES, ^uint64(0)
+	}
+	writable := flags&fuse.O_ACCMODE != fuse.O_RDONLY
+	if writable && !f.canWrite(reqPath) {
+		return -fuse.EACCES, ^uint64(0)
+	}
+	obj, err := f.getObj(reqPath)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+	h := &fileHandle{
+		fs:       f,
+		reqPath:  reqPath,
+		obj:      obj,
+		writable: writable,
+	}
+	if writable && flags&fuse.O_TRUNC != 0 {
+		if err = h.stage(false); err != nil {
+			return errno(err), ^uint64(0)
+		}
+		h.dirty = true
+	}
+	return 0, f.addHandle(h)
+}
+
+func (f *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if h := f.getHandle(fh); h != nil {
+		fillStat(stat, h.stat())
+		return 0
+	}
+	f.mu.Lock()
+	h := f.pending[reqPath]
+	f.mu.Unlock()
+	if h != nil {
+		fillStat(stat, h.stat())
+		return 0
+	}
+	obj, err := f.getObj(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	fillStat(stat, obj)
+	return 0
+}
+
+func (f *Fs) Truncate(path string, size int64, fh uint64) int {
+	if h := f.getHandle(fh); h != nil {
+		return errno(h.truncate(size))
+	}
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	f.mu.Lock()
+	h := f.pending[reqPath]
+	f.mu.Unlock()
+	if h != nil {
+		return errno(h.truncate(size))
+	}
+	if !f.canWrite(reqPath) {
+		return -fuse.EACCES
+	}
+	obj, err := f.getObj(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR
+	}
+	h = &fileHandle{fs: f, reqPath: reqPath, obj: obj, writable: true}
+	if err = h.truncate(size); err == nil {
+		err = h.flush()
+	}
+	if e := h.release(); err == nil {
+		err = e
+	}
+	return errno(err)
+}
+
+func (f *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.readAt(buff, ofst)
+	if err != nil {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	if !h.writable {
+		return -fuse.EBADF
+	}
+	n, err := h.writeAt(buff, ofst)
+	if err != nil {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Flush(path string, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	return errno(h.flush())
+}
+
+func (f *Fs) Release(path string, fh uint64) int {
+	f.mu.Lock()
+	h, ok := f.handles[fh]
+	if ok {
+		delete(f.handles, fh)
+		if f.pending[h.reqPath] == h {
+			delete(f.pending, h.reqPath)
+		}
+	}
+	f.mu.Unlock()
+	if !ok {
+		return -fuse.EBADF
+	}
+	err := h.flush()
+	if e := h.release(); err == nil {
+		err = e
+	}
+	return errno(err)
+}
+
+func (f *Fs) Fsync(path string, datasync bool, fh uint64) int {
+	return f.Flush(path, fh)
+}
+
+func (f *Fs) Opendir(path string) (int, uint64) {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if !f.canAccess(reqPath) {
+		return -fuse.EACCES, ^uint64(0)
+	}
+	return 0, ^uint64(0)
+}
+
+func (f *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return errno(err)
+	}
+	ctx := context.WithValue(f.ctx(), conf.MetaKey, meta)
+	objs, err := fs.List(ctx, reqPath, &fs.ListArgs{})
+	if err != nil {
+		return errno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	names := make(map[string]struct{}, len(objs))
+	for _, obj := range objs {
+		names[obj.GetName()] = struct{}{}
+		stat := &fuse.Stat_t{}
+		fillStat(stat, obj)
+		if !fill(obj.GetName(), stat, 0) {
+			return 0
+		}
+	}
+	// files that are still being written are not visible to the drivers yet
+	for _, h := range f.pendingIn(reqPath) {
+		if _, ok := names[h.obj.GetName()]; ok {
+			continue
+		}
+		stat := &fuse.Stat_t{}
+		fillStat(stat, h.stat())
+		if !fill(h.obj.GetName(), stat, 0) {
+			return 0
+		}
+	}
+	return 0
+}
+
+func (f *Fs) Releasedir(path string, fh uint64) int {
+	return 0
+}
+
+func (f *Fs) getObj(reqPath string) (model.Obj, error) {
+	return fs.Get(f.ctx(), reqPath, &fs.GetArgs{NoLog: true})
+}
+
+func (f *Fs) addHandle(h *fileHandle) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextFh++
+	f.handles[f.nextFh] = h
+	if h.writable {
+		f.pending[h.reqPath] = h
+	}
+	return f.nextFh
+}
+
+func (f *Fs) getHandle(fh uint64) *fileHandle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.handles[fh]
+}
+
+func (f *Fs) pendingIn(dir string) []*fileHandle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res []*fileHandle
+	for p, h := range f.pending {
+		if utils.PathEqual(stdpath.Dir(p), dir) {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+func fillStat(stat *fuse.Stat_t, obj model.Obj) {
+	*stat = fuse.Stat_t{}
+	if obj.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0o755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0o644
+		stat.Nlink = 1
+		stat.Size = obj.GetSize()
+		stat.Blksize = 4096
+		stat.Blocks = (stat.Size + 511) / 512
+	}
+	mtime := fuse.NewTimespec(obj.ModTime())
+	ctime := mtime
+	if !obj.CreateTime().IsZero() {
+		ctime = fuse.NewTimespec(obj.CreateTime())
+	}
+	stat.Mtim = mtime
+	stat.Atim = mtime
+	stat.Ctim = mtime
+	stat.Birthtim = ctime
+}
+
+// errno maps the errors of the fs package to negative errno values
+func errno(err error) int {
+	if err == nil {
+		return 0
+	}
+	cause := errors.Cause(err)
+	switch {
+	case errs.IsNotFoundError(err):
+		return -fuse.ENOENT
+	case errors.Is(cause, errs.PermissionDenied):
+		return -fuse.EACCES
+	case errors.Is(cause, errs.ObjectAlreadyExists):
+		return -fuse.EEXIST
+	case errors.Is(cause, errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errors.Is(cause, errs.NotFile):
+		return -fuse.EISDIR
+	case errors.Is(cause, errs.UploadNotSupported):
+		return -fuse.EROFS
+	case errors.Is(cause, errs.RelativePath):
+		return -fuse.EINVAL
+	case errs.IsNotImplementError(err), errs.IsNotSupportError(err):
+		return -fuse.ENOSYS
+	}
+	log.Debugf("fuse: %+v", err)
+	return -fuse.EIO
 }
 
 var _ fuse.FileSystemInterface = (*Fs)(nil)
@@ -0,0 +1,96 @@
+package fuse
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+)
+
+func TestRenameAcross(t *testing.T) {
+	rename, move := fsRename, fsMove
+	defer func() {
+		fsRename, fsMove = rename, move
+	}()
+
+	tests := []struct {
+		name    string
+		moveErr error
+		want    []string
+	}{
+		{name: "moved", want: []string{"rename /a/x y", "move /a/y /b/"}},
+		{name: "move failed", moveErr: errors.New("failed"), want: []string{"rename /a/x y", "move /a/y /b/", "rename /a/y x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			fsRename = func(ctx context.Context, srcPath, dstName string, skipHook ...bool) error {
+				calls = append(calls, "rename "+srcPath+" "+dstName)
+				return nil
+			}
+			fsMove = func(ctx context.Context, srcPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error) {
+				calls = append(calls, "move "+srcPath+" "+dstDirPath)
+				return nil, tt.moveErr
+			}
+			err := renameAcross(context.Background(), "/a/x", "/b/", "y")
+			if !errors.Is(err, tt.moveErr) {
+				t.Errorf("expected error %v, got %v", tt.moveErr, err)
+			}
+			if len(calls) != len(tt.want) {
+				t.Fatalf("expected calls %v, got %v", tt.want, calls)
+			}
+			for i := range calls {
+				if calls[i] != tt.want[i] {
+					t.Errorf("expected calls %v, got %v", tt.want, calls)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestReplaceFile(t *testing.T) {
+	rename, remove := fsRename, fsRemove
+	defer func() {
+		fsRename, fsRemove = rename, remove
+	}()
+
+	tests := []struct {
+		name      string
+		renameErr error
+		want      []string
+	}{
+		{name: "replaced", want: []string{"rename /b/y y.openlist_replaced", "put /b/y", "remove /b/y.openlist_replaced"}},
+		{name: "rename failed", renameErr: errors.New("failed"), want: []string{"rename /b/y y.openlist_replaced", "put /b/y", "rename /b/y.openlist_replaced y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			fsRename = func(ctx context.Context, srcPath, dstName string, skipHook ...bool) error {
+				calls = append(calls, "rename "+srcPath+" "+dstName)
+				return nil
+			}
+			fsRemove = func(ctx context.Context, path string) error {
+				calls = append(calls, "remove "+path)
+				return nil
+			}
+			err := replaceFile(context.Background(), "/b/y", func() error {
+				calls = append(calls, "put /b/y")
+				return tt.renameErr
+			})
+			if !errors.Is(err, tt.renameErr) {
+				t.Errorf("expected error %v, got %v", tt.renameErr, err)
+			}
+			if len(calls) != len(tt.want) {
+				t.Fatalf("expected calls %v, got %v", tt.want, calls)
+			}
+			for i := range calls {
+				if calls[i] != tt.want[i] {
+					t.Errorf("expected calls %v, got %v", tt.want, calls)
+					break
+				}
+			}
+		})
+	}
+}
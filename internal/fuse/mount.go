@@ -1,9 +1,32 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/winfsp/cgofuse/fuse"
+)
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
+type MountedFs struct {
+	host *fuse.FileSystemHost
+	done chan bool
+}
+
+// Mount serves mountSrc of the virtual tree at mountDst on behalf of user.
+// It returns immediately, use Wait to block until the file system is unmounted.
+func Mount(mountSrc, mountDst string, user *model.User, opts []string) *MountedFs {
+	fs := NewFs(mountSrc, user)
 	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+	m := &MountedFs{host: host, done: make(chan bool, 1)}
+	go func() {
+		m.done <- host.Mount(mountDst, opts)
+	}()
+	return m
+}
+
+// Wait blocks until the file system is unmounted, it reports whether the mount succeeded
+func (m *MountedFs) Wait() bool {
+	return <-m.done
+}
+
+func (m *MountedFs) Unmount() bool {
+	return m.host.Unmount()
 }
@@ -0,0 +1,256 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	probeTimeout = time.Minute
+	concurrency  = 4
+	// the consecutive failed probes before a storage is reinitialized,
+	// so a single slow or failed list doesn't reinitialize a storage in use
+	reinitAfter = 3
+	// the max delay between the reinitializations of a failing storage
+	maxBackoff = time.Hour * 12
+)
+
+// state is the health of a storage kept in memory
+type state struct {
+	Last *model.StorageHealth
+	// the consecutive failed probes
+	Unhealthy int
+	// the consecutive failed reinitializations
+	Failures int
+	// the failing storage isn't reinitialized again before it
+	NextReinit time.Time
+}
+
+var (
+	states   = make(map[uint]*state)
+	statesMu sync.Mutex
+)
+
+func getState(id uint) *state {
+	statesMu.Lock()
+	defer statesMu.Unlock()
+	s, ok := states[id]
+	if !ok {
+		s = &state{}
+		states[id] = s
+	}
+	return s
+}
+
+func init() {
+	// forget the state of the storages deleted or disabled
+	op.RegisterStorageHook(func(typ string, storage driver.Driver) {
+		if typ != "del" {
+			return
+		}
+		statesMu.Lock()
+		delete(states, storage.GetStorage().ID)
+		statesMu.Unlock()
+	})
+}
+
+func interval() time.Duration {
+	return time.Duration(setting.GetInt(conf.StorageHealthInterval, 0)) * time.Minute
+}
+
+// CheckDue checks the storages whose last check is older than the interval,
+// it does nothing if the health check is disabled
+func CheckDue(ctx context.Context) {
+	d := interval()
+	if d <= 0 {
+		return
+	}
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for _, storage := range op.GetAllStorages() {
+		s := storage.GetStorage()
+		if s.Disabled {
+			continue
+		}
+		st := getState(s.ID)
+		statesMu.Lock()
+		due := st.Last == nil || time.Since(st.Last.Time) >= d
+		statesMu.Unlock()
+		if due {
+			g.Go(func() error {
+				Check(ctx, storage, false)
+				return nil
+			})
+		}
+	}
+	_ = g.Wait()
+}
+
+// Check probes the storage and records the result, a storage failing
+// reinitAfter probes in a row is reinitialized with exponential backoff unless force
+func Check(ctx context.Context, storage driver.Driver, force bool) *model.StorageHealth {
+	s := storage.GetStorage()
+	h := probe(ctx, storage)
+	st := getState(s.ID)
+	if !h.Healthy {
+		statesMu.Lock()
+		st.Unhealthy++
+		reinit := force || st.Unhealthy >= reinitAfter && !time.Now().Before(st.NextReinit)
+		statesMu.Unlock()
+		if reinit {
+			save(h)
+			h = reload(ctx, storage)
+		}
+	}
+	statesMu.Lock()
+	st.Last = h
+	if h.Healthy {
+		st.Unhealthy = 0
+		st.Failures = 0
+		st.NextReinit = time.Time{}
+	} else if h.Reinit {
+		st.Failures++
+		st.NextReinit = h.Time.Add(backoff(st.Failures))
+	}
+	statesMu.Unlock()
+	save(h)
+	return h
+}
+
+func backoff(failures int) time.Duration {
+	d := interval()
+	if d <= 0 {
+		d = time.Minute
+	}
+	for i := 1; i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// probe lists the root of the storage without cache and gets its details if supported
+func probe(ctx context.Context, storage driver.Driver) *model.StorageHealth {
+	s := storage.GetStorage()
+	h := &model.StorageHealth{StorageId: s.ID, Time: time.Now()}
+	err := func() error {
+		if s.Status != op.WORK {
+			return errors.Errorf("storage status: %s", s.Status)
+		}
+		ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+		defer cancel()
+		if _, err := op.List(ctx, storage, "/", model.ListArgs{Refresh: true, SkipHook: true}); err != nil {
+			return errors.WithMessage(err, "failed list root")
+		}
+		if _, err := op.GetStorageDetails(ctx, storage, true); err != nil && !errors.Is(err, errs.NotImplement) {
+			return errors.WithMessage(err, "failed get details")
+		}
+		return nil
+	}()
+	h.Latency = time.Since(h.Time).Milliseconds()
+	h.Healthy = err == nil
+	if err != nil {
+		h.Error = err.Error()
+	}
+	return h
+}
+
+// reload reinitializes the storage and probes it again,
+// the status of the storage is set to the error if it's still failing
+func reload(ctx context.Context, storage driver.Driver) *model.StorageHealth {
+	s := storage.GetStorage()
+	log.Infof("reinitialize unhealthy storage %s", s.MountPath)
+	start := time.Now()
+	err := op.ReloadStorage(ctx, storage)
+	h := &model.StorageHealth{StorageId: s.ID, Time: start, Reinit: true}
+	if err == nil {
+		h = probe(ctx, storage)
+		h.Time = start
+		h.Reinit = true
+		if !h.Healthy {
+			op.SetStorageStatus(storage, h.Error)
+		}
+	} else {
+		h.Error = err.Error()
+	}
+	h.Latency = time.Since(start).Milliseconds()
+	if !h.Healthy {
+		log.Warnf("storage %s is still unhealthy after reinitialized: %s", s.MountPath, h.Error)
+	}
+	return h
+}
+
+func save(h *model.StorageHealth) {
+	if err := db.CreateStorageHealth(h); err != nil {
+		log.Errorf("failed save health of storage %d: %+v", h.StorageId, err)
+	}
+}
+
+// Status is the current health of a storage
+type Status struct {
+	StorageId  uint                 `json:"storage_id"`
+	MountPath  string               `json:"mount_path"`
+	Driver     string               `json:"driver"`
+	Status     string               `json:"status"`
+	Last       *model.StorageHealth `json:"last"`
+	Failures   int                  `json:"failures"`
+	NextReinit *time.Time           `json:"next_reinit"`
+}
+
+// GetStatuses returns the current health of the storages
+func GetStatuses() []Status {
+	storages := op.GetAllStorages()
+	res := make([]Status, 0, len(storages))
+	statesMu.Lock()
+	defer statesMu.Unlock()
+	for _, storage := range storages {
+		s := storage.GetStorage()
+		status := Status{
+			StorageId: s.ID,
+			MountPath: s.MountPath,
+			Driver:    s.Driver,
+			Status:    s.Status,
+		}
+		if st, ok := states[s.ID]; ok {
+			status.Last = st.Last
+			status.Failures = st.Failures
+			if !st.NextReinit.IsZero() {
+				next := st.NextReinit
+				status.NextReinit = &next
+			}
+		}
+		res = append(res, status)
+	}
+	return res
+}
+
+func GetHistory(storageId uint, pageIndex, pageSize int) ([]model.StorageHealth, int64, error) {
+	return db.GetStorageHealths(storageId, pageIndex, pageSize)
+}
+
+// Cleanup removes the health records older than the retention days, 0 means keep forever
+func Cleanup() {
+	days := setting.GetInt(conf.StorageHealthRetention, 0)
+	if days <= 0 {
+		return
+	}
+	n, err := db.DeleteStorageHealthsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Errorf("failed cleanup storage health records: %+v", err)
+		return
+	}
+	if n > 0 {
+		log.Infof("removed %d storage health records older than %d days", n, days)
+	}
+}
@@ -81,6 +81,21 @@ type ArchiveDecompressArgs struct {
 	Overwrite     bool
 }
 
+// the formats of the archives compressed
+const (
+	ArchiveZip   = "zip"
+	ArchiveTarGz = "tar.gz"
+)
+
+type ArchiveCompressArgs struct {
+	Format string
+	// the zip is encrypted by AES-256 if it's not empty
+	Password  string
+	Overwrite bool
+	// the password of the metas of the src objects, the objects the creator can't access are skipped
+	MetaPass string
+}
+
 type SharingListArgs struct {
 	Refresh bool
 	Pwd     string
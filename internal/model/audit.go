@@ -0,0 +1,102 @@
+package model
+
+import "time"
+
+// protocols of audit log entries
+const (
+	ProtocolWeb    = "web"
+	ProtocolWebDAV = "webdav"
+	ProtocolFTP    = "ftp"
+	ProtocolSFTP   = "sftp"
+	ProtocolS3     = "s3"
+	ProtocolFuse   = "fuse"
+	// the operations made by the scheduled sync jobs
+	ProtocolSync = "sync"
+)
+
+// actions of audit log entries
+const (
+	AuditMkdir      = "mkdir"
+	AuditRename     = "rename"
+	AuditMove       = "move"
+	AuditCopy       = "copy"
+	AuditMerge      = "merge"
+	AuditRemove     = "remove"
+	AuditUpload     = "upload"
+	AuditPutURL     = "put_url"
+	AuditDecompress = "decompress"
+	AuditCompress   = "compress"
+
+	AuditShareCreate = "share_create"
+	AuditShareUpdate = "share_update"
+	AuditShareDelete = "share_delete"
+
+	AuditStorageCreate  = "storage_create"
+	AuditStorageUpdate  = "storage_update"
+	AuditStorageDelete  = "storage_delete"
+	AuditStorageEnable  = "storage_enable"
+	AuditStorageDisable = "storage_disable"
+	AuditUserCreate     = "user_create"
+	AuditUserUpdate     = "user_update"
+	AuditUserDelete     = "user_delete"
+	AuditMetaCreate     = "meta_create"
+	AuditMetaUpdate     = "meta_update"
+	AuditMetaDelete     = "meta_delete"
+	AuditSettingSave    = "setting_save"
+	AuditSettingDelete  = "setting_delete"
+	AuditTokenReset     = "token_reset"
+	AuditGroupCreate    = "group_create"
+	AuditGroupUpdate    = "group_update"
+	AuditGroupDelete    = "group_delete"
+	AuditRuleCreate     = "path_rule_create"
+	AuditRuleUpdate     = "path_rule_update"
+	AuditRuleDelete     = "path_rule_delete"
+	AuditQuotaCreate    = "quota_create"
+	AuditQuotaUpdate    = "quota_update"
+	AuditQuotaDelete    = "quota_delete"
+	AuditTrashRestore   = "trash_restore"
+	AuditTrashPurge     = "trash_purge"
+	AuditSyncCreate     = "sync_job_create"
+	AuditSyncUpdate     = "sync_job_update"
+	AuditSyncDelete     = "sync_job_delete"
+	AuditSyncRun        = "sync_job_run"
+	AuditVersionRestore = "version_restore"
+	AuditVersionDelete  = "version_delete"
+	AuditRssCreate      = "rss_create"
+	AuditRssUpdate      = "rss_update"
+	AuditRssDelete      = "rss_delete"
+)
+
+// results of audit log entries
+const (
+	AuditSuccess = "success"
+	AuditFailed  = "failed"
+	// the operation has been submitted as a task
+	AuditQueued = "queued"
+)
+
+type AuditLog struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Time     time.Time `json:"time" gorm:"index"`
+	UserId   uint      `json:"user_id"`
+	Username string    `json:"username" gorm:"index"`
+	IP       string    `json:"ip"`
+	Protocol string    `json:"protocol"`
+	Action   string    `json:"action" gorm:"index"`
+	Src      string    `json:"src" gorm:"type:text"`
+	Dst      string    `json:"dst" gorm:"type:text"`
+	Size     int64     `json:"size"`
+	Result   string    `json:"result"`
+	Message  string    `json:"message" gorm:"type:text"`
+}
+
+type AuditLogFilter struct {
+	Username string    `json:"username" form:"username"`
+	IP       string    `json:"ip" form:"ip"`
+	Protocol string    `json:"protocol" form:"protocol"`
+	Action   string    `json:"action" form:"action"`
+	Path     string    `json:"path" form:"path"`
+	Result   string    `json:"result" form:"result"`
+	Start    time.Time `json:"start" form:"start"`
+	End      time.Time `json:"end" form:"end"`
+}
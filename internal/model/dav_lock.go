@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// DavLock is a WebDAV lock, the write operations of other users through
+// any protocol are refused while it's alive.
+// Root is the full virtual path of the locked resource.
+type DavLock struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	Token     string `json:"token" gorm:"unique"`
+	Root      string `json:"root" gorm:"index"`
+	ZeroDepth bool   `json:"zero_depth"`
+	OwnerXML  string `json:"owner_xml" gorm:"type:text"`
+	UserId    uint   `json:"user_id"`
+	// Duration is the timeout of the lock, negative means infinite
+	Duration time.Duration `json:"duration"`
+	// Expiry is the unix milliseconds the lock expires at, 0 means never
+	Expiry int64 `json:"expiry" gorm:"index"`
+}
+
+// SetDuration sets the timeout of the lock starting from now
+func (l *DavLock) SetDuration(now time.Time, duration time.Duration) {
+	l.Duration = duration
+	l.Expiry = 0
+	if duration >= 0 {
+		l.Expiry = now.Add(duration).UnixMilli()
+	}
+}
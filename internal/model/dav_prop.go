@@ -0,0 +1,12 @@
+package model
+
+// DavProp is a dead property of a WebDAV resource, set by PROPPATCH.
+// Path is the full virtual path of the resource.
+type DavProp struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Path     string `json:"path" gorm:"index"`
+	Space    string `json:"space"`
+	Local    string `json:"local"`
+	Lang     string `json:"lang"`
+	InnerXML string `json:"inner_xml" gorm:"type:text"`
+}
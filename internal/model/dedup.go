@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// DedupFile is a file found by the last deduplication scan.
+// The files of the same HashKey are duplicates.
+type DedupFile struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	HashKey  string    `json:"hash_key" gorm:"index"` // the smallest <hash type>:<hash> of the files sharing any hash, or size:<size>:<name> if the file has no hash
+	Path     string    `json:"path" gorm:"index"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+type DedupGroup struct {
+	HashKey string      `json:"hash_key"`
+	Size    int64       `json:"size"`
+	Count   int         `json:"count"`
+	Files   []DedupFile `json:"files" gorm:"-"`
+}
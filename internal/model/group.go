@@ -0,0 +1,68 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// Group is a named set of permissions shared by its members,
+// the permission of a user is the union of its own and its groups'
+type Group struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	Name       string `json:"name" gorm:"unique" binding:"required"`
+	Permission int32  `json:"permission"`
+	Remark     string `json:"remark"`
+}
+
+// PathRule grants or denies permission bits on a subtree, for a group or for a user
+type PathRule struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Path    string `json:"path" gorm:"index" binding:"required"`
+	GroupId uint   `json:"group_id"`
+	UserId  uint   `json:"user_id"`
+	Allow   int32  `json:"allow"`
+	Deny    int32  `json:"deny"`
+	Remark  string `json:"remark"`
+}
+
+func (r *PathRule) AppliesTo(u *User) bool {
+	if r.UserId != 0 {
+		return r.UserId == u.ID
+	}
+	return r.GroupId != 0 && u.InGroup(r.GroupId)
+}
+
+// ResolvePermission applies the rules matching the user and reqPath on top of base.
+// Rules on a deeper path override the ones on its parents,
+// and on the same path the user rules override the group rules.
+func ResolvePermission(u *User, base int32, rules []PathRule, reqPath string) int32 {
+	reqPath = utils.FixAndCleanPath(reqPath)
+	matched := make([]PathRule, 0)
+	for _, r := range rules {
+		if utils.IsSubPath(r.Path, reqPath) && r.AppliesTo(u) {
+			matched = append(matched, r)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		di, dj := pathDepth(matched[i].Path), pathDepth(matched[j].Path)
+		if di != dj {
+			return di < dj
+		}
+		return matched[i].UserId == 0 && matched[j].UserId != 0
+	})
+	perm := base
+	for _, r := range matched {
+		perm = (perm | r.Allow) &^ r.Deny
+	}
+	return perm
+}
+
+func pathDepth(path string) int {
+	path = utils.FixAndCleanPath(path)
+	if path == "/" {
+		return 0
+	}
+	return strings.Count(path, "/")
+}
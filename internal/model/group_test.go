@@ -0,0 +1,29 @@
+package model
+
+import "testing"
+
+func TestResolvePermission(t *testing.T) {
+	user := &User{ID: 2, GroupIds: []uint{1}}
+	rules := []PathRule{
+		{Path: "/team", GroupId: 1, Allow: 1 << 3},
+		{Path: "/team/private", GroupId: 1, Deny: 1<<15 | 1<<3},
+		{Path: "/team/private/alice", UserId: 2, Allow: 1 << 15},
+		{Path: "/team", UserId: 2, Deny: 1 << 3},
+		{Path: "/team", GroupId: 3, Allow: 1 << 7},
+	}
+	datas := []struct {
+		path   string
+		result int32
+	}{
+		{path: "/", result: 1 << 15},
+		{path: "/teams", result: 1 << 15},
+		{path: "/team/a", result: 1 << 15},
+		{path: "/team/private/b", result: 0},
+		{path: "/team/private/alice/c", result: 1 << 15},
+	}
+	for i, data := range datas {
+		if res := ResolvePermission(user, 1<<15, rules, data.path); res != data.result {
+			t.Errorf("TestResolvePermission %d failed, expect %b, got %b", i, data.result, res)
+		}
+	}
+}
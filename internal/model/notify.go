@@ -0,0 +1,67 @@
+package model
+
+import "slices"
+
+// the types of the events a user can subscribe to
+const (
+	NotifyTaskSucceeded     = "task_succeeded"
+	NotifyTaskFailed        = "task_failed"
+	NotifyStorageError      = "storage_error"
+	NotifyStorageRecovered  = "storage_recovered"
+	NotifySharingViewed     = "sharing_viewed"
+	NotifySharingDownloaded = "sharing_downloaded"
+	NotifySharingUploaded   = "sharing_uploaded"
+)
+
+var NotifyEvents = []string{
+	NotifyTaskSucceeded, NotifyTaskFailed,
+	NotifyStorageError, NotifyStorageRecovered,
+	NotifySharingViewed, NotifySharingDownloaded, NotifySharingUploaded,
+}
+
+const (
+	// NotifyWebhook posts the events as json, signed with the secret
+	NotifyWebhook = "webhook"
+	// NotifyEmail sends the events to the email address of the subscription
+	NotifyEmail = "email"
+	// NotifyTemplate sends http requests rendered from the templates, e.g. to telegram bots or bark
+	NotifyTemplate = "template"
+)
+
+// NotifyChannel is a destination of the events, it's managed by the admin
+type NotifyChannel struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" gorm:"unique" binding:"required"`
+	Type     string `json:"type" binding:"required"`
+	Disabled bool   `json:"disabled"`
+	// webhook and template, the url of template channels is a template too
+	URL string `json:"url"`
+	// the key of the HMAC signature of webhook channels
+	Secret string `json:"secret"`
+	// template, the headers are "Name: Value" lines
+	Method  string `json:"method"`
+	Headers string `json:"headers" gorm:"type:text"`
+	Body    string `json:"body" gorm:"type:text"`
+	// email, STARTTLS is used if the server supports it when SMTPSSL is false
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPPassword string `json:"smtp_password"`
+	SMTPFrom     string `json:"smtp_from"`
+	SMTPSSL      bool   `json:"smtp_ssl"`
+}
+
+// NotifySubscription subscribes a user to some types of the events through a channel
+type NotifySubscription struct {
+	ID        uint     `json:"id" gorm:"primaryKey"`
+	UserId    uint     `json:"-" gorm:"index"`
+	ChannelId uint     `json:"channel_id" gorm:"index" binding:"required"`
+	Events    []string `json:"events" gorm:"type:text;serializer:json"`
+	// the recipient in the channel, e.g. the email address or the chat id of telegram
+	Target   string `json:"target"`
+	Disabled bool   `json:"disabled"`
+}
+
+func (s *NotifySubscription) Subscribed(event string) bool {
+	return !s.Disabled && slices.Contains(s.Events, event)
+}
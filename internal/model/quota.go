@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Quota limits the content under Path.
+// The usage is all the content under Path, a quota with UserId only limits the writes of that user,
+// otherwise it limits everyone.
+type Quota struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Path       string     `json:"path" gorm:"index" binding:"required"`
+	UserId     uint       `json:"user_id"`
+	MaxSize    int64      `json:"max_size"`  // in bytes, 0 means unlimited
+	MaxFiles   int64      `json:"max_files"` // 0 means unlimited
+	UsedSize   int64      `json:"used_size"`
+	UsedFiles  int64      `json:"used_files"`
+	Reconciled *time.Time `json:"reconciled"`
+	Remark     string     `json:"remark"`
+}
+
+// Exceeded reports whether adding size bytes and files files goes beyond the limits
+func (q *Quota) Exceeded(size, files int64) bool {
+	if q.MaxSize > 0 && size > 0 && q.UsedSize+size > q.MaxSize {
+		return true
+	}
+	return q.MaxFiles > 0 && files > 0 && q.UsedFiles+files > q.MaxFiles
+}
+
+func (q *Quota) AppliesTo(u *User) bool {
+	return q.UserId == 0 || (u != nil && q.UserId == u.ID)
+}
@@ -0,0 +1,25 @@
+package model
+
+import "testing"
+
+func TestQuotaExceeded(t *testing.T) {
+	q := &Quota{MaxSize: 100, MaxFiles: 2, UsedSize: 60, UsedFiles: 1}
+	tests := []struct {
+		size, files int64
+		want        bool
+	}{
+		{40, 1, false},
+		{41, 0, true},
+		{0, 2, true},
+		{-10, 0, false},
+		{200, -1, true},
+	}
+	for _, tt := range tests {
+		if got := q.Exceeded(tt.size, tt.files); got != tt.want {
+			t.Errorf("Exceeded(%d, %d) = %v, want %v", tt.size, tt.files, got, tt.want)
+		}
+	}
+	if (&Quota{}).Exceeded(1<<40, 1<<20) {
+		t.Errorf("a quota without limits should never be exceeded")
+	}
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// RssSubscription adds the new items of an RSS or Atom feed as offline download tasks
+type RssSubscription struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" binding:"required"`
+	Url  string `json:"url" binding:"required"`
+	// regular expressions matched against the titles of the items,
+	// an empty Include matches all, and an item matched by Exclude is skipped
+	Include      string     `json:"include"`
+	Exclude      string     `json:"exclude"`
+	DstPath      string     `json:"dst_path" binding:"required"`
+	Tool         string     `json:"tool" binding:"required"`
+	DeletePolicy string     `json:"delete_policy"`
+	Interval     int        `json:"interval"` // poll interval in minutes
+	Disabled     bool       `json:"disabled"`
+	LastCheck    *time.Time `json:"last_check"`
+	LastError    string     `json:"last_error" gorm:"type:text"`
+	Remark       string     `json:"remark"`
+	// the items in the feed when it's subscribed are skipped unless DownloadExisting,
+	// so only the items published later are added
+	DownloadExisting bool `json:"download_existing" gorm:"-"`
+}
+
+// RssItem is an item of a feed added as a task already, or skipped as it's
+// in the feed when subscribed, it's not added again
+type RssItem struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SubscriptionId uint      `json:"subscription_id" gorm:"index"`
+	Guid           string    `json:"guid" gorm:"type:text"`
+	Title          string    `json:"title" gorm:"type:text"`
+	Url            string    `json:"url" gorm:"type:text"`
+	Time           time.Time `json:"time"`
+	Skipped        bool      `json:"skipped"`
+}
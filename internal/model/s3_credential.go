@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+const (
+	S3BucketRead  = "read"
+	S3BucketWrite = "write"
+	S3BucketList  = "list"
+)
+
+// S3Credential is an access key pair of a user for the built-in s3 server.
+// The secret is needed to verify the signatures, so it's stored as is and shown once on creation.
+type S3Credential struct {
+	ID              uint   `json:"id" gorm:"primaryKey"`
+	UserId          uint   `json:"-" gorm:"index"`
+	Name            string `json:"name"`
+	AccessKeyId     string `json:"access_key_id" gorm:"unique;size:64"`
+	SecretAccessKey string `json:"-"`
+	// the buckets the key is restricted to, empty means all buckets with full access
+	Buckets   []S3BucketPolicy `json:"buckets" gorm:"type:text;serializer:json"`
+	Disabled  bool             `json:"disabled"`
+	AddedTime time.Time        `json:"added_time"`
+}
+
+type S3BucketPolicy struct {
+	Bucket string `json:"bucket"`
+	Read   bool   `json:"read"`
+	Write  bool   `json:"write"`
+	List   bool   `json:"list"`
+}
+
+// Allowed reports whether the key can do the action (one of S3BucketRead, S3BucketWrite and S3BucketList) in the bucket,
+// the permissions of the owner are checked separately
+func (c *S3Credential) Allowed(bucket, action string) bool {
+	if len(c.Buckets) == 0 {
+		return true
+	}
+	for _, p := range c.Buckets {
+		if p.Bucket != bucket {
+			continue
+		}
+		switch action {
+		case S3BucketRead:
+			return p.Read
+		case S3BucketWrite:
+			return p.Write
+		case S3BucketList:
+			return p.List
+		}
+	}
+	return false
+}
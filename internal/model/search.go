@@ -17,14 +17,33 @@ type SearchReq struct {
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	// size range in bytes, 0 means unlimited
+	MinSize int64 `json:"min_size"`
+	MaxSize int64 `json:"max_size"`
+	// modified time range
+	ModifiedAfter  *time.Time `json:"modified_after"`
+	ModifiedBefore *time.Time `json:"modified_before"`
+	// types of objects, e.g. conf.VIDEO, empty for all
+	Types []int `json:"types"`
+	// mount path of the storage, empty for all
+	Storage string `json:"storage"`
+	// name, size or modified, empty for the default order of the searcher
+	OrderBy string `json:"order_by"`
+	// asc or desc
+	OrderDirection string `json:"order_direction"`
 	PageReq
 }
 
 type SearchNode struct {
-	Parent string `json:"parent" gorm:"index"`
-	Name   string `json:"name"`
-	IsDir  bool   `json:"is_dir"`
-	Size   int64  `json:"size"`
+	Parent   string    `json:"parent" gorm:"index"`
+	Name     string    `json:"name"`
+	IsDir    bool      `json:"is_dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	HashInfo string    `json:"hashinfo"`
+	ObjType  int       `json:"obj_type"` // see utils.GetObjType
+	Storage  string    `json:"storage"`  // mount path of the storage
+	Driver   string    `json:"driver"`
 }
 
 func (p *SearchReq) Validate() error {
@@ -34,6 +53,18 @@ func (p *SearchReq) Validate() error {
 	if p.PerPage < 1 {
 		return fmt.Errorf("per_page can't < 1")
 	}
+	switch p.OrderBy {
+	case "", "name", "size", "modified":
+	default:
+		return fmt.Errorf("can't order by %s", p.OrderBy)
+	}
+	switch p.OrderDirection {
+	case "":
+		p.OrderDirection = "asc"
+	case "asc", "desc":
+	default:
+		return fmt.Errorf("order direction can only be asc or desc")
+	}
 	return nil
 }
 
@@ -1,12 +1,21 @@
 package model
 
-import "time"
+import (
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
 
 type SharingDB struct {
 	ID          string     `json:"id" gorm:"type:char(12);primaryKey"`
 	FilesRaw    string     `json:"-" gorm:"type:text"`
 	Expires     *time.Time `json:"expires"`
-	Pwd         string     `json:"pwd"`
+	Pwd         string     `json:"pwd"` // only the plaintext passwords of old versions, see SetPwd
+	PwdHash     string     `json:"-"`
+	Salt        string     `json:"-"`
 	Accessed    int        `json:"accessed"`
 	MaxAccessed int        `json:"max_accessed"`
 	CreatorId   uint       `json:"-"`
@@ -14,7 +23,61 @@ type SharingDB struct {
 	Remark      string     `json:"remark"`
 	Readme      string     `json:"readme" gorm:"type:text"`
 	Header      string     `json:"header" gorm:"type:text"`
+	// the limits of downloading each file, 0 means unlimited
+	FileMaxDownloads int   `json:"file_max_downloads"`
+	FileMaxBytes     int64 `json:"file_max_bytes"`
 	Sort
+	// an upload sharing lets the visitors upload files to its only dir without seeing the files in it
+	Upload           bool       `json:"upload"`
+	UploadMaxSize    int64      `json:"upload_max_size"`
+	UploadExtensions string     `json:"upload_extensions"`
+	UploadPrefix     string     `json:"upload_prefix"`
+	Uploaded         int        `json:"uploaded"`
+	LastUploaded     *time.Time `json:"last_uploaded"`
+	// the restrictions on the visitors, the cidrs are separated by commas or new lines
+	AllowedCIDRs    string `json:"allowed_cidrs" gorm:"type:text"`
+	RequireLogin    bool   `json:"require_login"`
+	AllowedUserIds  []uint `json:"allowed_user_ids" gorm:"type:text;serializer:json"`
+	AllowedGroupIds []uint `json:"allowed_group_ids" gorm:"type:text;serializer:json"`
+}
+
+// HasPwd reports whether the sharing is protected by a password
+func (s *SharingDB) HasPwd() bool {
+	return s.PwdHash != "" || s.Pwd != ""
+}
+
+// SetPwd keeps the hash of pwd only, an empty pwd removes the password
+func (s *SharingDB) SetPwd(pwd string) {
+	s.Pwd = ""
+	if pwd == "" {
+		s.PwdHash, s.Salt = "", ""
+		return
+	}
+	s.Salt = random.String(16)
+	s.PwdHash = TwoHashPwd(pwd, s.Salt)
+}
+
+// GetAllowedPrefixes parses AllowedCIDRs, a single ip is taken as a prefix of itself
+func (s *SharingDB) GetAllowedPrefixes() ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, cidr := range strings.FieldsFunc(s.AllowedCIDRs, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' '
+	}) {
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
 }
 
 type Sharing struct {
@@ -30,10 +93,11 @@ func (s *Sharing) Valid() bool {
 	if s.MaxAccessed > 0 && s.Accessed >= s.MaxAccessed {
 		return false
 	}
-	if len(s.Files) == 0 {
+	if len(s.Files) == 0 || (s.Upload && len(s.Files) != 1) {
 		return false
 	}
-	if s.Creator == nil || !s.Creator.CanShare() {
+	// the share permission of the creator on the files is checked by op.ValidSharing
+	if s.Creator == nil {
 		return false
 	}
 	if s.Expires != nil && !s.Expires.IsZero() && s.Expires.Before(time.Now()) {
@@ -43,5 +107,59 @@ func (s *Sharing) Valid() bool {
 }
 
 func (s *Sharing) Verify(pwd string) bool {
+	if s.PwdHash != "" {
+		return s.PwdHash == TwoHashPwd(pwd, s.Salt)
+	}
 	return s.Pwd == "" || s.Pwd == pwd
 }
+
+// AllowIP reports whether the visitors from ip can access the sharing
+func (s *Sharing) AllowIP(ip string) bool {
+	if s.AllowedCIDRs == "" {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	// the invalid cidrs are rejected when the sharing is saved
+	prefixes, _ := s.GetAllowedPrefixes()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedLogin reports whether only some logged-in users can access the sharing
+func (s *Sharing) NeedLogin() bool {
+	return s.RequireLogin || len(s.AllowedUserIds) > 0 || len(s.AllowedGroupIds) > 0
+}
+
+// AllowUser reports whether the user can access the sharing, user is nil or the guest for an anonymous visitor
+func (s *Sharing) AllowUser(user *User) bool {
+	if !s.NeedLogin() {
+		return true
+	}
+	if user == nil || user.IsGuest() {
+		return false
+	}
+	if len(s.AllowedUserIds) == 0 && len(s.AllowedGroupIds) == 0 {
+		return true
+	}
+	if user.ID == s.CreatorId {
+		return true
+	}
+	for _, id := range s.AllowedUserIds {
+		if id == user.ID {
+			return true
+		}
+	}
+	for _, id := range s.AllowedGroupIds {
+		if user.InGroup(id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// types of sharing access log entries
+const (
+	// a new visitor opened the sharing
+	SharingAccessView = "view"
+	// a file is downloaded by /sd
+	SharingAccessDown = "down"
+	// a file in an archive is downloaded by /sad
+	SharingAccessExtract = "extract"
+)
+
+type SharingAccess struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SharingId string    `json:"-" gorm:"type:char(12);index"`
+	Time      time.Time `json:"time" gorm:"index"`
+	Type      string    `json:"type"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	// the path in the sharing
+	Path      string `json:"path" gorm:"type:text"`
+	InnerPath string `json:"inner_path" gorm:"type:text"`
+	Bytes     int64  `json:"bytes"`
+	// the client is redirected to the file, so Bytes is the size of the file or the range
+	// rather than the bytes it has actually pulled
+	Redirected bool `json:"redirected"`
+	// a range request resuming a download, it isn't counted as a download
+	Partial bool `json:"partial"`
+}
+
+// SharingStats aggregates the access log of a sharing
+type SharingStats struct {
+	SharingId    string     `json:"-"`
+	Views        int64      `json:"views"`
+	Downloads    int64      `json:"downloads"`
+	Bytes        int64      `json:"bytes"`
+	Visitors     int64      `json:"visitors"`
+	LastAccessed *time.Time `json:"last_accessed" gorm:"-"`
+	LastId       uint       `json:"-"`
+}
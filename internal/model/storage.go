@@ -3,6 +3,8 @@ package model
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
 
 type Storage struct {
@@ -21,6 +23,8 @@ type Storage struct {
 	EnableSign          bool      `json:"enable_sign"`
 	Sort
 	Proxy
+	Trash
+	Versioning
 }
 
 type Sort struct {
@@ -38,6 +42,36 @@ type Proxy struct {
 	DisableProxySign bool `json:"disable_proxy_sign"`
 }
 
+// Trash turns the removes of a storage into moves to the trash directory
+type Trash struct {
+	TrashEnabled       bool   `json:"trash_enabled"`
+	TrashPath          string `json:"trash_path"`           // relative to the storage root
+	TrashRetentionDays int    `json:"trash_retention_days"` // 0 means keep forever
+}
+
+func (t Trash) GetTrashPath() string {
+	if t.TrashPath == "" {
+		return "/.openlist-trash"
+	}
+	return utils.FixAndCleanPath(t.TrashPath)
+}
+
+// Versioning keeps the previous versions of the files overwritten in a storage
+type Versioning struct {
+	VersioningEnabled bool   `json:"versioning_enabled"`
+	VersionsStorage   string `json:"versions_storage"`   // mount path of the storage keeping the versions, empty means this storage
+	VersionsPath      string `json:"versions_path"`      // relative to the root of the storage keeping the versions
+	VersionsMaxCount  int    `json:"versions_max_count"` // versions to keep of a file, 0 means unlimited
+	VersionsRetention int    `json:"versions_retention"` // days to keep a version, 0 means forever
+}
+
+func (v Versioning) GetVersionsPath() string {
+	if v.VersionsPath == "" {
+		return "/.versions"
+	}
+	return utils.FixAndCleanPath(v.VersionsPath)
+}
+
 func (s *Storage) GetStorage() *Storage {
 	return s
 }
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// StorageHealth is a result of the health check of a storage
+type StorageHealth struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	StorageId uint      `json:"storage_id" gorm:"index"`
+	Time      time.Time `json:"time" gorm:"index"`
+	// the storage is reinitialized for the failure, the result is of the check after that
+	Reinit  bool `json:"reinit"`
+	Healthy bool `json:"healthy"`
+	// in milliseconds
+	Latency int64  `json:"latency"`
+	Error   string `json:"error" gorm:"type:text"`
+}
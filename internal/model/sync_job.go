@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// modes of sync jobs
+const (
+	// SyncCopyNew copies the files missing or changed in the destination
+	SyncCopyNew = "copy_new"
+	// SyncMirror is like SyncCopyNew, and removes the objects not in the source from the destination
+	SyncMirror = "mirror"
+	// SyncTwoWay copies the files missing on either side, the newer one wins if both changed.
+	// The removes are not synchronized.
+	SyncTwoWay = "two_way"
+)
+
+// statuses of sync runs
+const (
+	SyncRunning = "running"
+	SyncSuccess = "success"
+	SyncFailed  = "failed"
+)
+
+type SyncJob struct {
+	ID       uint       `json:"id" gorm:"primaryKey"`
+	Name     string     `json:"name" binding:"required"`
+	SrcPath  string     `json:"src_path" binding:"required"`
+	DstPath  string     `json:"dst_path" binding:"required"`
+	Schedule string     `json:"schedule"` // cron expression, empty means run manually only
+	Mode     string     `json:"mode"`
+	Disabled bool       `json:"disabled"`
+	LastRun  *time.Time `json:"last_run"` // the end of the last successful run
+	Remark   string     `json:"remark"`
+}
+
+func (j *SyncJob) ValidMode() bool {
+	switch j.Mode {
+	case SyncCopyNew, SyncMirror, SyncTwoWay:
+		return true
+	default:
+		return false
+	}
+}
+
+// SyncRun is the report of a run of a sync job
+type SyncRun struct {
+	ID      uint       `json:"id" gorm:"primaryKey"`
+	JobId   uint       `json:"job_id" gorm:"index"`
+	Start   time.Time  `json:"start"`
+	End     *time.Time `json:"end"`
+	Status  string     `json:"status"`
+	Copied  int        `json:"copied"`
+	Removed int        `json:"removed"`
+	Skipped int        `json:"skipped"`
+	Failed  int        `json:"failed"`
+	Bytes   int64      `json:"bytes"`
+	Errors  string     `json:"errors" gorm:"type:text"` // one error per line
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// ApiTokenPrefix is the prefix of every personal access token,
+// so that they can be told apart from jwt and the admin token
+const ApiTokenPrefix = "olpat_"
+
+// ApiToken is a personal access token of a user.
+// Only the hash of the token is stored, the token itself is shown once on creation.
+type ApiToken struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserId    uint   `json:"-" gorm:"index"`
+	Name      string `json:"name"`
+	TokenHash string `json:"-" gorm:"unique;size:64"`
+	// the first characters of the token, to help users recognize it
+	Hint string `json:"hint"`
+	// the subset of User.Permission granted to the token
+	Permission int32 `json:"permission"`
+	// a path relative to User.BasePath that the token is limited to
+	BasePath string `json:"base_path"`
+	// whether the token can use admin apis, only effective for admin users
+	Admin        bool       `json:"admin"`
+	Expires      *time.Time `json:"expires"`
+	AddedTime    time.Time  `json:"added_time"`
+	LastUsedTime time.Time  `json:"last_used_time"`
+}
+
+func HashApiToken(token string) string {
+	return utils.HashData(utils.SHA256, []byte(token))
+}
+
+func (t *ApiToken) Expired() bool {
+	return t.Expires != nil && !t.Expires.IsZero() && t.Expires.Before(time.Now())
+}
+
+// ScopeUser returns a copy of the owner limited to the permissions and path of the token
+func (t *ApiToken) ScopeUser(owner *User) (*User, error) {
+	user := *owner
+	user.Permission = owner.Permission & t.Permission
+	// reading is always allowed under the base path of the token
+	mask := t.Permission | 1<<15
+	if owner.permMask != nil {
+		mask &= *owner.permMask
+	}
+	user.permMask = &mask
+	basePath, err := owner.JoinPath(t.BasePath)
+	if err != nil {
+		return nil, err
+	}
+	user.BasePath = basePath
+	if user.IsAdmin() && !t.Admin {
+		user.Role = GENERAL
+	}
+	return &user, nil
+}
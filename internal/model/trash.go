@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+// TrashItem records an object moved to the trash directory of a storage.
+// The object is kept as Dir/<name of Path>, so that the items of the same name don't conflict.
+type TrashItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	StorageId  uint      `json:"storage_id" gorm:"index"`
+	Path       string    `json:"path"`        // the original mount path
+	ActualPath string    `json:"actual_path"` // the original path in the storage
+	Dir        string    `json:"dir"`         // the directory in the trash holding the object
+	IsDir      bool      `json:"is_dir"`
+	Size       int64     `json:"size"`
+	UserId     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	Deleted    time.Time `json:"deleted" gorm:"index"`
+}
+
+type TrashFilter struct {
+	StorageId uint   `json:"storage_id" form:"storage_id"`
+	Path      string `json:"path" form:"path"` // prefix of the original mount path
+}
@@ -63,11 +63,15 @@ type User struct {
 	//   12: can read archives
 	//   13: can decompress archives
 	//   14: can share
+	//   15: can read, every user has it unless a path rule denies it
 	Permission int32  `json:"permission"`
+	GroupIds   []uint `json:"group_ids" gorm:"type:text;serializer:json"`
 	OtpSecret  string `json:"-"`
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
 	AllowLdap  bool   `json:"allow_ldap" gorm:"default:true"`
+	// permMask limits the permission resolved from groups and path rules, see ApiToken.ScopeUser
+	permMask *int32
 }
 
 func (u *User) IsGuest() bool {
@@ -219,6 +223,27 @@ func (u *User) CanShare() bool {
 	return CanShare(u.Permission)
 }
 
+func CanRead(permission int32) bool {
+	return (permission>>15)&1 == 1
+}
+
+func (u *User) InGroup(groupId uint) bool {
+	for _, id := range u.GroupIds {
+		if id == groupId {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskPermission limits the permission resolved from groups and path rules
+func (u *User) MaskPermission(perm int32) int32 {
+	if u.permMask != nil {
+		return perm & *u.permMask
+	}
+	return perm
+}
+
 func (u *User) JoinPath(reqPath string) (string, error) {
 	return utils.JoinBasePath(u.BasePath, reqPath)
 }
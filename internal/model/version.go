@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// FileVersion records a previous version of a file, kept before the file was overwritten.
+// The content is kept as Dir/Name in the storage holding the versions.
+type FileVersion struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	StorageId        uint      `json:"-" gorm:"index"` // the storage of the file
+	Path             string    `json:"-" gorm:"index"` // the mount path of the file
+	VersionStorageId uint      `json:"-" gorm:"index"`
+	Dir              string    `json:"-"`
+	Name             string    `json:"name"` // the name of the file when the version was kept
+	Size             int64     `json:"size"`
+	Modified         time.Time `json:"modified"` // the modified time of the version
+	UserId           uint      `json:"user_id"`  // the user who overwrote the version
+	Username         string    `json:"username"`
+	Created          time.Time `json:"created" gorm:"index"`
+}
@@ -0,0 +1,231 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type sender func(ctx context.Context, ch *model.NotifyChannel, target string, e *Event) error
+
+var senders = map[string]sender{
+	model.NotifyWebhook:  sendWebhook,
+	model.NotifyEmail:    sendEmail,
+	model.NotifyTemplate: sendTemplate,
+}
+
+// ValidateChannel checks the config of the channel
+func ValidateChannel(ch *model.NotifyChannel) error {
+	switch ch.Type {
+	case model.NotifyWebhook:
+		if ch.URL == "" {
+			return errors.New("the url is required")
+		}
+	case model.NotifyEmail:
+		if ch.SMTPHost == "" || ch.SMTPFrom == "" {
+			return errors.New("the smtp host and the sender are required")
+		}
+	case model.NotifyTemplate:
+		if ch.URL == "" {
+			return errors.New("the url is required")
+		}
+		for _, text := range []string{ch.URL, ch.Headers, ch.Body} {
+			if _, err := parseTemplate(text); err != nil {
+				return errors.WithMessage(err, "invalid template")
+			}
+		}
+	default:
+		return errors.Errorf("unknown channel type: %s", ch.Type)
+	}
+	return nil
+}
+
+// ValidateTarget checks the target of a subscription to the channel
+func ValidateTarget(ch *model.NotifyChannel, target string) error {
+	if ch.Type == model.NotifyEmail && !utils.IsEmailFormat(strings.ToLower(target)) {
+		return errors.Errorf("invalid email address: %s", target)
+	}
+	return nil
+}
+
+// sendWebhook posts the event as json, the body is signed with the secret of the channel
+// in the X-OpenList-Signature header, in the format of pkg/sign with 0 expire
+func sendWebhook(ctx context.Context, ch *model.NotifyChannel, target string, e *Event) error {
+	body, err := utils.Json.Marshal(struct {
+		*Event
+		Target string `json:"target,omitempty"`
+	}{e, target})
+	if err != nil {
+		return err
+	}
+	req := base.RestyClient.R().SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-OpenList-Event", e.Type).
+		SetBody(body)
+	if ch.Secret != "" {
+		req.SetHeader("X-OpenList-Signature", sign.NewHMACSign([]byte(ch.Secret)).Sign(string(body), 0))
+	}
+	res, err := req.Post(ch.URL)
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		return errors.Errorf("webhook responded %s", res.Status())
+	}
+	return nil
+}
+
+// templateData is what the templates of the template channels are rendered with,
+// e.g. {{.Title}}, {{.Content}} and {{.Target}}
+type templateData struct {
+	*Event
+	Target string
+}
+
+var templateFuncs = template.FuncMap{
+	// json quotes the value for the json bodies, e.g. {"text": {{json .Content}}}
+	"json": func(v any) (string, error) {
+		b, err := utils.Json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("notify").Funcs(templateFuncs).Parse(text)
+}
+
+func render(text string, data templateData) (string, error) {
+	t, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sendTemplate sends the request rendered from the templates of the channel, for example:
+//
+//	telegram: POST https://api.telegram.org/bot<token>/sendMessage
+//	  with body {"chat_id": {{json .Target}}, "text": {{json .Title}}}
+//	bark: GET https://api.day.app/{{.Target}}/{{urlquery .Title}}/{{urlquery .Content}}
+func sendTemplate(ctx context.Context, ch *model.NotifyChannel, target string, e *Event) error {
+	data := templateData{Event: e, Target: target}
+	url, err := render(ch.URL, data)
+	if err != nil {
+		return errors.WithMessage(err, "failed render url")
+	}
+	body, err := render(ch.Body, data)
+	if err != nil {
+		return errors.WithMessage(err, "failed render body")
+	}
+	headers, err := render(ch.Headers, data)
+	if err != nil {
+		return errors.WithMessage(err, "failed render headers")
+	}
+	req := base.RestyClient.R().SetContext(ctx)
+	scanner := bufio.NewScanner(strings.NewReader(headers))
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(k) != "" {
+			req.SetHeader(strings.TrimSpace(k), strings.TrimSpace(v))
+		}
+	}
+	if body != "" {
+		req.SetBody(body)
+	}
+	method := strings.ToUpper(ch.Method)
+	if method == "" {
+		method = "POST"
+	}
+	res, err := req.Execute(method, url)
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		return errors.Errorf("the server responded %s: %s", res.Status(), res.String())
+	}
+	return nil
+}
+
+// sendEmail sends the event to the email address of the subscription
+func sendEmail(ctx context.Context, ch *model.NotifyChannel, target string, e *Event) error {
+	port := ch.SMTPPort
+	if port == 0 {
+		port = 25
+		if ch.SMTPSSL {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(ch.SMTPHost, strconv.Itoa(port))
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if ch.SMTPSSL {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: ch.SMTPHost}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, ch.SMTPHost)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && !ch.SMTPSSL {
+		if err = c.StartTLS(&tls.Config{ServerName: ch.SMTPHost}); err != nil {
+			return err
+		}
+	}
+	if ch.SMTPUsername != "" {
+		if err = c.Auth(smtp.PlainAuth("", ch.SMTPUsername, ch.SMTPPassword, ch.SMTPHost)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(ch.SMTPFrom); err != nil {
+		return err
+	}
+	if err = c.Rcpt(target); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("[%s] %s", setting.GetStr(conf.SiteTitle), e.Title)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n\r\n%s\r\n",
+		ch.SMTPFrom, target, mime.QEncoding.Encode("utf-8", subject), e.Time.Format(time.RFC1123Z),
+		strings.ReplaceAll(e.Content, "\n", "\r\n"), e.Time.Format(time.DateTime))
+	if _, err = w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+)
+
+// TaskDone emits the event of the task which is finished, typ tells what the task is, e.g. copy.
+// It's called in the OnSucceeded and OnFailed hooks of the tasks, the canceled tasks are ignored
+func TaskDone(t task.TaskExtensionInfo, typ string, succeeded bool) {
+	if !succeeded && t.Ctx() != nil && t.Ctx().Err() != nil {
+		return
+	}
+	e := &Event{
+		Type: model.NotifyTaskSucceeded,
+		Data: map[string]any{
+			"id":        t.GetID(),
+			"name":      t.GetName(),
+			"task_type": typ,
+		},
+	}
+	if creator := t.GetCreator(); creator != nil {
+		e.UserId = creator.ID
+	}
+	if succeeded {
+		e.Title = fmt.Sprintf("%s task succeeded", typ)
+		e.Content = t.GetName()
+	} else {
+		e.Type = model.NotifyTaskFailed
+		e.Title = fmt.Sprintf("%s task failed", typ)
+		e.Content = t.GetName()
+		if err := t.GetErr(); err != nil {
+			e.Content += "\n" + err.Error()
+			e.Data["error"] = err.Error()
+		}
+	}
+	Emit(e)
+}
+
+// BatchDone emits the event of the batch of tasks which are all finished, with the errors of the failed ones
+func BatchDone(userId uint, typ, batchID string, succeeded int, failed []string) {
+	e := &Event{
+		Type:   model.NotifyTaskSucceeded,
+		Title:  fmt.Sprintf("%s batch finished", typ),
+		UserId: userId,
+		Data: map[string]any{
+			"batch_id":  batchID,
+			"task_type": typ,
+			"succeeded": succeeded,
+			"failed":    len(failed),
+		},
+	}
+	e.Content = fmt.Sprintf("%d tasks succeeded, %d failed", succeeded, len(failed))
+	if len(failed) > 0 {
+		e.Type = model.NotifyTaskFailed
+		e.Content += "\n" + strings.Join(failed, "\n")
+	}
+	Emit(e)
+}
+
+// SharingAccessed emits the event of the access to the sharing, to its creator
+func SharingAccessed(s *model.Sharing, a *model.SharingAccess) {
+	e := &Event{
+		Time:   a.Time,
+		UserId: s.CreatorId,
+		Data: map[string]any{
+			"sharing_id": s.ID,
+			"ip":         a.IP,
+			"user_agent": a.UserAgent,
+		},
+	}
+	switch a.Type {
+	case model.SharingAccessView:
+		e.Type = model.NotifySharingViewed
+		e.Title = fmt.Sprintf("sharing %s is viewed", s.ID)
+		e.Content = fmt.Sprintf("visited by %s", a.IP)
+	default:
+		e.Type = model.NotifySharingDownloaded
+		e.Title = fmt.Sprintf("sharing %s is downloaded", s.ID)
+		e.Content = fmt.Sprintf("%s is downloaded by %s", a.Path, a.IP)
+		e.Data["path"] = a.Path
+		e.Data["inner_path"] = a.InnerPath
+		e.Data["bytes"] = a.Bytes
+	}
+	Emit(e)
+}
+
+// SharingUploaded emits the event of the file uploaded to the upload sharing, to its creator
+func SharingUploaded(s *model.Sharing, path string, size int64) {
+	Emit(&Event{
+		Type:    model.NotifySharingUploaded,
+		UserId:  s.CreatorId,
+		Title:   fmt.Sprintf("a file is uploaded to sharing %s", s.ID),
+		Content: fmt.Sprintf("%s (%d bytes)", path, size),
+		Data: map[string]any{
+			"sharing_id": s.ID,
+			"path":       path,
+			"size":       size,
+		},
+	})
+}
+
+// storageStatusChanged emits the events of the storages going wrong or back to work, to the admins
+func storageStatusChanged(storage driver.Driver, old string) {
+	s := storage.GetStorage()
+	data := map[string]any{
+		"id":         s.ID,
+		"mount_path": s.MountPath,
+		"driver":     s.Driver,
+		"status":     s.Status,
+	}
+	switch {
+	case s.Status == op.WORK && old != "" && old != op.WORK && old != op.DISABLED:
+		Emit(&Event{
+			Type:    model.NotifyStorageRecovered,
+			Title:   fmt.Sprintf("storage %s is back to work", s.MountPath),
+			Content: "the previous status: " + old,
+			Data:    data,
+		})
+	case s.Status != op.WORK && s.Status != op.DISABLED:
+		Emit(&Event{
+			Type:    model.NotifyStorageError,
+			Title:   fmt.Sprintf("storage %s is in error", s.MountPath),
+			Content: s.Status,
+			Data:    data,
+		})
+	}
+}
+
+func init() {
+	op.RegisterStorageStatusHook(storageStatusChanged)
+}
@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Event is what happened, it's sent to the channels the owner subscribed to with its type
+type Event struct {
+	Type    string         `json:"type"`
+	Time    time.Time      `json:"time"`
+	Title   string         `json:"title"`
+	Content string         `json:"content"`
+	Data    map[string]any `json:"data,omitempty"`
+	// the user the event belongs to, 0 means a system event which goes to the admins
+	UserId uint `json:"-"`
+}
+
+const (
+	queueSize   = 256
+	sendTimeout = time.Second * 30
+)
+
+var (
+	queue     = make(chan *Event, queueSize)
+	startOnce sync.Once
+)
+
+// Emit sends the event to the subscribers asynchronously
+func Emit(e *Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	startOnce.Do(func() {
+		go worker()
+	})
+	select {
+	case queue <- e:
+	default:
+		log.Warnf("the notify queue is full, drop event %s: %s", e.Type, e.Title)
+	}
+}
+
+func worker() {
+	for e := range queue {
+		dispatch(e)
+	}
+}
+
+func dispatch(e *Event) {
+	var subs []model.NotifySubscription
+	var err error
+	if e.UserId == 0 {
+		subs, err = db.GetEnabledNotifySubscriptions()
+	} else {
+		subs, err = db.GetEnabledNotifySubscriptions(e.UserId)
+	}
+	if err != nil {
+		log.Errorf("failed get subscriptions of event %s: %+v", e.Type, err)
+		return
+	}
+	channels := make(map[uint]*model.NotifyChannel)
+	for i := range subs {
+		sub := &subs[i]
+		if !sub.Subscribed(e.Type) || !allowed(sub.UserId, e) {
+			continue
+		}
+		ch, ok := channels[sub.ChannelId]
+		if !ok {
+			ch, err = db.GetNotifyChannelById(sub.ChannelId)
+			if err != nil {
+				log.Errorf("failed get notify channel %d: %+v", sub.ChannelId, err)
+			}
+			channels[sub.ChannelId] = ch
+		}
+		if ch == nil || ch.Disabled {
+			continue
+		}
+		if err = send(ch, sub.Target, e); err != nil {
+			log.Errorf("failed send event %s to channel %s: %+v", e.Type, ch.Name, err)
+		}
+	}
+}
+
+// allowed reports whether the user can receive the event
+func allowed(userId uint, e *Event) bool {
+	user, err := op.GetUserById(userId)
+	if err != nil || user.Disabled {
+		return false
+	}
+	return e.UserId == user.ID || (e.UserId == 0 && user.IsAdmin())
+}
+
+func send(ch *model.NotifyChannel, target string, e *Event) error {
+	s, ok := senders[ch.Type]
+	if !ok {
+		return errors.Errorf("unknown channel type: %s", ch.Type)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return s(ctx, ch, target, e)
+}
+
+// Test sends a test event to the target through the channel synchronously
+func Test(ch *model.NotifyChannel, target string) error {
+	return send(ch, target, &Event{
+		Type:    "test",
+		Time:    time.Now(),
+		Title:   "Test notification",
+		Content: "The notify channel " + ch.Name + " works.",
+	})
+}
@@ -0,0 +1,319 @@
+//go:build !cgo || nosqlite
+
+package bittorrent
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/net"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	g "github.com/anacrolix/generics"
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// BitTorrent downloads the torrents with the embedded client,
+// so that no external client is needed for magnets and .torrent files.
+// The sqlite piece completion of the client links another sqlite than the one of the db,
+// so the tool is built only if it's disabled by the nosqlite tag, see build.sh
+type BitTorrent struct {
+	// the client is replaced by Init, it's got by getClient
+	client *torrent.Client
+	port   int
+	mu     sync.Mutex
+	// the time the torrents are downloaded, by the info hash
+	downloaded map[metainfo.Hash]time.Time
+}
+
+func (b *BitTorrent) Run(task *tool.DownloadTask) error {
+	return errs.NotSupport
+}
+
+func (b *BitTorrent) Name() string {
+	return "BitTorrent"
+}
+
+func (b *BitTorrent) Items() []model.SettingItem {
+	return []model.SettingItem{
+		{Key: conf.TorrentListenPort, Value: "0", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: `0 means a random port`},
+		{Key: conf.TorrentSeedRatio, Value: "0", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: `seed until the uploaded bytes reach the ratio of the downloaded ones, 0 means no limit`},
+		{Key: conf.TorrentSeedtime, Value: "0", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: `seed for the minutes at most, 0 means no limit`},
+	}
+}
+
+func (b *BitTorrent) Init() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	port := setting.GetInt(conf.TorrentListenPort, 0)
+	// the seeding limits are got when they are used, only the port needs a new client,
+	// which drops the torrents being downloaded
+	if b.client != nil {
+		if port == b.port {
+			return fmt.Sprintf("listening on port %d", b.client.LocalPort()), nil
+		}
+		b.client.Close()
+		b.client = nil
+	}
+	cfg := torrent.NewDefaultClientConfig()
+	cfg.ListenPort = port
+	cfg.Seed = true
+	// every torrent has its own storage in the temp dir of the task
+	cfg.DefaultStorage = newStorage(filepath.Join(conf.Conf.TempDir, "BitTorrent"))
+	c, err := torrent.NewClient(cfg)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to init bittorrent client")
+	}
+	b.client = c
+	b.port = port
+	b.downloaded = make(map[metainfo.Hash]time.Time)
+	pruneMetainfo(nil)
+	return fmt.Sprintf("listening on port %d", c.LocalPort()), nil
+}
+
+func (b *BitTorrent) IsReady() bool {
+	_, err := b.getClient()
+	return err == nil
+}
+
+// getClient returns the current client, Init may close and replace it at any time
+func (b *BitTorrent) getClient() (*torrent.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.client == nil {
+		return nil, errors.New("the bittorrent client is not initialized")
+	}
+	return b.client, nil
+}
+
+func (b *BitTorrent) AddURL(args *tool.AddUrlArgs) (string, error) {
+	client, err := b.getClient()
+	if err != nil {
+		return "", err
+	}
+	pruneMetainfo(func(ih metainfo.Hash) bool {
+		_, ok := client.Torrent(ih)
+		return ok
+	})
+	spec, err := getSpec(args.Url)
+	if err != nil {
+		return "", err
+	}
+	spec.Storage = newStorage(args.TempDir)
+	t, _, err := client.AddTorrentSpec(spec)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to add torrent")
+	}
+	go func() {
+		select {
+		case <-t.GotInfo():
+		case <-t.Closed():
+			return
+		}
+		if err := saveMetainfo(t.Metainfo()); err != nil {
+			log.Warnf("failed save the metainfo of %s: %+v", t.InfoHash(), err)
+		}
+		for _, f := range t.Files() {
+			if selected(f.DisplayPath(), args.Files) {
+				f.Download()
+			}
+		}
+	}()
+	return t.InfoHash().HexString(), nil
+}
+
+func (b *BitTorrent) Remove(task *tool.DownloadTask) error {
+	t, err := b.getTorrent(task.GID)
+	if err != nil {
+		return err
+	}
+	t.Drop()
+	b.mu.Lock()
+	delete(b.downloaded, t.InfoHash())
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *BitTorrent) Status(task *tool.DownloadTask) (*tool.Status, error) {
+	t, err := b.getTorrent(task.GID)
+	if err != nil {
+		return nil, err
+	}
+	s := &tool.Status{}
+	if t.Info() == nil {
+		s.Status = "getting metadata"
+		return s, nil
+	}
+	var total, completed int64
+	for _, f := range t.Files() {
+		if selected(f.DisplayPath(), task.Files) {
+			total += f.Length()
+			completed += f.BytesCompleted()
+		}
+	}
+	if total == 0 {
+		s.Err = errors.New("no file of the torrent is selected")
+		return s, nil
+	}
+	s.TotalBytes = total
+	s.Progress = float64(completed) / float64(total) * 100
+	if completed < total {
+		stats := t.Stats()
+		s.Status = fmt.Sprintf("downloading, %d peers", stats.ActivePeers)
+		return s, nil
+	}
+	b.mu.Lock()
+	downloaded, ok := b.downloaded[t.InfoHash()]
+	if !ok {
+		downloaded = time.Now()
+		b.downloaded[t.InfoHash()] = downloaded
+	}
+	b.mu.Unlock()
+	// seed before the files are transferred, they may be deleted after that
+	stats := t.Stats()
+	ratio := float64(stats.BytesWrittenData.Int64()) / float64(total)
+	if seeding(ratio, time.Since(downloaded)) {
+		s.Status = fmt.Sprintf("seeding, ratio %.2f", ratio)
+		return s, nil
+	}
+	if err = b.Remove(task); err != nil {
+		return nil, err
+	}
+	removeUnselected(task.TempDir, t, task.Files)
+	removeMetainfo(t.InfoHash())
+	s.Completed = true
+	return s, nil
+}
+
+func (b *BitTorrent) getTorrent(gid string) (*torrent.Torrent, error) {
+	var ih metainfo.Hash
+	if err := ih.FromHexString(gid); err != nil {
+		return nil, errors.Wrapf(err, "invalid gid: %s", gid)
+	}
+	client, err := b.getClient()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := client.Torrent(ih)
+	if !ok {
+		return nil, errors.Errorf("torrent %s not found", gid)
+	}
+	return t, nil
+}
+
+// seeding reports whether the downloaded torrent should be seeded still
+func seeding(ratio float64, elapsed time.Duration) bool {
+	seedRatio := setting.GetFloat(conf.TorrentSeedRatio, 0)
+	seedtime := setting.GetInt(conf.TorrentSeedtime, 0)
+	if seedRatio <= 0 && seedtime <= 0 {
+		return false
+	}
+	if seedRatio > 0 && ratio >= seedRatio {
+		return false
+	}
+	if seedtime > 0 && elapsed >= time.Duration(seedtime)*time.Minute {
+		return false
+	}
+	return true
+}
+
+// torrentFetchTimeout is the timeout of getting a .torrent file by its http url
+const torrentFetchTimeout = 30 * time.Second
+
+// getSpec returns the torrent of the magnet or the http url of a .torrent file
+func getSpec(uri string) (*torrent.TorrentSpec, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse torrent uri")
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		client := net.NewHttpClient()
+		client.Timeout = torrentFetchTimeout
+		resp, err := client.Get(uri)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get .torrent file")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("failed to get .torrent file: http status code %d", resp.StatusCode)
+		}
+		mi, err := readMetainfo(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return torrent.TorrentSpecFromMetaInfoErr(mi)
+	}
+	spec, err := torrent.TorrentSpecFromMagnetUri(uri)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse magnet uri")
+	}
+	// the metadata doesn't need to be got from the peers again if it's uploaded or got before
+	if mi, err := loadMetainfo(spec.InfoHash); err == nil {
+		spec.InfoBytes = mi.InfoBytes
+	}
+	return spec, nil
+}
+
+func newStorage(dir string) storage.ClientImplCloser {
+	// the pieces are checked again if the files exist already, e.g. the task is retried
+	return storage.NewFileOpts(storage.NewFileClientOpts{
+		ClientBaseDir:   dir,
+		PieceCompletion: storage.NewMapPieceCompletion(),
+		UsePartFiles:    g.Some(false),
+	})
+}
+
+// selected reports whether the file of the path in the torrent is selected by the paths,
+// a dir selects all the files in it
+func selected(path string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		p = strings.Trim(p, "/")
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// removeUnselected removes the files not selected from the temp dir,
+// they may be written partially because of the pieces they share with the selected files
+func removeUnselected(dir string, t *torrent.Torrent, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	for _, f := range t.Files() {
+		if selected(f.DisplayPath(), paths) {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(f.Path()))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Warnf("failed remove the unselected file %s: %+v", f.Path(), err)
+			continue
+		}
+		// the dirs left empty are not transferred either
+		for p := filepath.Dir(path); p != dir && os.Remove(p) == nil; p = filepath.Dir(p) {
+		}
+	}
+}
+
+var _ tool.Tool = (*BitTorrent)(nil)
+
+func init() {
+	tool.Tools.Add(&BitTorrent{})
+}
@@ -0,0 +1,55 @@
+//go:build cgo && !nosqlite
+
+package bittorrent
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/pkg/errors"
+)
+
+// BitTorrent is the placeholder of the tool compiled out,
+// the sqlite of the client conflicts with the one of the db if it's built without the nosqlite tag, see build.sh
+type BitTorrent struct{}
+
+var errDisabled = errors.New("the BitTorrent tool is not built in, build with the nosqlite tag to enable it")
+
+func (b *BitTorrent) Name() string {
+	return "BitTorrent"
+}
+
+func (b *BitTorrent) Items() []model.SettingItem {
+	return nil
+}
+
+func (b *BitTorrent) Init() (string, error) {
+	return "", errDisabled
+}
+
+func (b *BitTorrent) IsReady() bool {
+	return false
+}
+
+func (b *BitTorrent) AddURL(args *tool.AddUrlArgs) (string, error) {
+	return "", errDisabled
+}
+
+func (b *BitTorrent) Remove(task *tool.DownloadTask) error {
+	return errDisabled
+}
+
+func (b *BitTorrent) Status(task *tool.DownloadTask) (*tool.Status, error) {
+	return nil, errDisabled
+}
+
+func (b *BitTorrent) Run(task *tool.DownloadTask) error {
+	return errs.NotSupport
+}
+
+var _ tool.Tool = (*BitTorrent)(nil)
+
+// the error of Init is logged on startup
+func init() {
+	tool.Tools.Add(&BitTorrent{})
+}
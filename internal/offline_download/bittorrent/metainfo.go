@@ -0,0 +1,137 @@
+package bittorrent
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the metainfo of the torrents is kept in <data>/torrents/<info hash>.torrent until they are downloaded,
+// so that the uploaded .torrent files can be added as magnets and the tasks don't get the metadata again on retry
+
+const (
+	// the .torrent files are small, the larger ones are refused
+	maxTorrentSize = 10 << 20
+	// the metainfo of the torrents not being downloaded is removed after it,
+	// e.g. the .torrent file is parsed but not added, or the task is canceled
+	metainfoExpiration = 24 * time.Hour
+)
+
+func metainfoDir() string {
+	return filepath.Join(flags.DataDir, "torrents")
+}
+
+func metainfoPath(ih metainfo.Hash) string {
+	return filepath.Join(metainfoDir(), ih.HexString()+".torrent")
+}
+
+// readMetainfo loads the metainfo of the .torrent file no larger than maxTorrentSize
+func readMetainfo(r io.Reader) (*metainfo.MetaInfo, error) {
+	b, err := io.ReadAll(io.LimitReader(r, maxTorrentSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read .torrent file")
+	}
+	if len(b) > maxTorrentSize {
+		return nil, errors.Errorf("the .torrent file is larger than %d bytes", maxTorrentSize)
+	}
+	mi, err := metainfo.Load(bytes.NewReader(b))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load .torrent file")
+	}
+	return mi, nil
+}
+
+func saveMetainfo(mi metainfo.MetaInfo) error {
+	path := metainfoPath(mi.HashInfoBytes())
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	return errors.WithStack(mi.Write(f))
+}
+
+func loadMetainfo(ih metainfo.Hash) (*metainfo.MetaInfo, error) {
+	return metainfo.LoadFromFile(metainfoPath(ih))
+}
+
+func removeMetainfo(ih metainfo.Hash) {
+	_ = os.Remove(metainfoPath(ih))
+}
+
+// pruneMetainfo removes the expired metainfo of the torrents, except the ones kept
+func pruneMetainfo(keep func(ih metainfo.Hash) bool) {
+	entries, err := os.ReadDir(metainfoDir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		var ih metainfo.Hash
+		hex, ok := strings.CutSuffix(e.Name(), ".torrent")
+		if e.IsDir() || !ok || ih.FromHexString(hex) != nil || (keep != nil && keep(ih)) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) < metainfoExpiration {
+			continue
+		}
+		if err = os.Remove(metainfoPath(ih)); err != nil {
+			log.Warnf("failed remove the metainfo of %s: %+v", hex, err)
+		}
+	}
+}
+
+type File struct {
+	// the path in the torrent, to select the file to download
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type Torrent struct {
+	Magnet string `json:"magnet"`
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Files  []File `json:"files"`
+}
+
+// ParseTorrent parses the .torrent file and keeps its metainfo,
+// the returned magnet can be added as the url of the BitTorrent tool then
+func ParseTorrent(r io.Reader) (*Torrent, error) {
+	mi, err := readMetainfo(r)
+	if err != nil {
+		return nil, err
+	}
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load .torrent file")
+	}
+	if !info.HasV1() {
+		return nil, errors.New("the v2 only torrents are not supported")
+	}
+	if err = saveMetainfo(*mi); err != nil {
+		return nil, err
+	}
+	res := &Torrent{
+		Magnet: mi.Magnet(nil, &info).String(),
+		Name:   info.BestName(),
+		Size:   info.TotalLength(),
+	}
+	for _, f := range info.UpvertedFiles() {
+		res.Files = append(res.Files, File{
+			Path: f.DisplayPath(&info),
+			Size: f.Length,
+		})
+	}
+	return res, nil
+}
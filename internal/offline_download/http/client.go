@@ -2,6 +2,7 @@ package http
 
 import (
 	"fmt"
+	"io"
 	"math/rand/v2"
 	"net/http"
 	"os"
@@ -11,12 +12,19 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/net"
 	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
 
+// the interval to update the progress of a ranged download,
+// every update postpones persisting the tasks, so they must be sparse enough to persist the progress
+const progressInterval = time.Second * 5
+
 type SimpleHttp struct {
 	client http.Client
 }
@@ -26,7 +34,9 @@ func (s SimpleHttp) Name() string {
 }
 
 func (s SimpleHttp) Items() []model.SettingItem {
-	return nil
+	return []model.SettingItem{
+		{Key: conf.SimpleHttpConnections, Value: "4", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: `the connections of a download if the server supports ranges`},
+	}
 }
 
 func (s SimpleHttp) Init() (string, error) {
@@ -59,10 +69,9 @@ func (s SimpleHttp) Run(task *tool.DownloadTask) error {
 	if err != nil {
 		return err
 	}
-	req.Header.Set("User-Agent", base.UserAgent)
-	if streamPut {
-		req.Header.Set("Range", "bytes=0-")
-	}
+	req.Header = requestHeader(task)
+	// the response of the ranged request tells whether the server supports ranges
+	req.Header.Set("Range", "bytes=0-")
 	resp, err := s.client.Do(req)
 	if err != nil {
 		return err
@@ -71,20 +80,22 @@ func (s SimpleHttp) Run(task *tool.DownloadTask) error {
 	if resp.StatusCode >= 400 {
 		return fmt.Errorf("http status code %d", resp.StatusCode)
 	}
-	filename, err := parseFilenameFromContentDisposition(resp.Header.Get("Content-Disposition"))
-	if err != nil {
-		filename = path.Base(resp.Request.URL.Path)
+	filename := task.Name
+	if filename == "" {
+		filename, err = parseFilenameFromContentDisposition(resp.Header.Get("Content-Disposition"))
+		if err != nil {
+			filename = path.Base(resp.Request.URL.Path)
+		}
 	}
 	filename = strings.Trim(filename, "/")
 	if len(filename) == 0 {
 		filename = fmt.Sprintf("%s-%d-%x", strings.ReplaceAll(req.URL.Host, ".", "_"), time.Now().UnixMilli(), rand.Uint32())
 	}
 	fileSize := resp.ContentLength
+	if size, ok := parseTotalSize(resp.Header.Get("Content-Range")); ok {
+		fileSize = size
+	}
 	if streamPut {
-		if fileSize == 0 {
-			start, end, _ := http_range.ParseContentRange(resp.Header.Get("Content-Range"))
-			fileSize = start + end
-		}
 		task.SetTotalBytes(fileSize)
 		task.TempDir = filename
 		return nil
@@ -92,14 +103,134 @@ func (s SimpleHttp) Run(task *tool.DownloadTask) error {
 	task.SetTotalBytes(fileSize)
 	// save to temp dir
 	_ = os.MkdirAll(task.TempDir, os.ModePerm)
+	if resp.StatusCode == http.StatusPartialContent && fileSize > 0 {
+		_ = resp.Body.Close()
+		return s.downloadRanges(task, filename, fileSize, resp.Header)
+	}
+	// the server doesn't support ranges, download the file at once
+	task.Partial = nil
 	filePath := filepath.Join(task.TempDir, filename)
 	file, err := os.Create(filePath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 	err = utils.CopyWithCtx(task.Ctx(), file, resp.Body, fileSize, task.SetProgress)
-	return err
+	_ = file.Close()
+	if err != nil {
+		return err
+	}
+	return verifyChecksum(task, filePath)
+}
+
+// downloadRanges downloads the file with multiple connections,
+// it continues from the persisted progress if the file isn't changed
+func (s SimpleHttp) downloadRanges(task *tool.DownloadTask, filename string, size int64, header http.Header) error {
+	p := &tool.PartialDownload{
+		Name:         filename,
+		Size:         size,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+	if old := task.Partial; old != nil && old.Size == p.Size && old.ETag == p.ETag && old.LastModified == p.LastModified {
+		p = old
+	}
+	filePath := filepath.Join(task.TempDir, p.Name)
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE, 0o666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// the bytes after the persisted progress may be incomplete, drop them
+	if info, err := file.Stat(); err != nil || info.Size() < p.Written {
+		p.Written = 0
+	}
+	if err = file.Truncate(p.Written); err != nil {
+		return err
+	}
+	if _, err = file.Seek(p.Written, io.SeekStart); err != nil {
+		return err
+	}
+	task.Partial = p
+	task.Persist()
+	if p.Written < p.Size {
+		d := net.NewDownloader(func(d *net.Downloader) {
+			d.Concurrency = max(setting.GetInt(conf.SimpleHttpConnections, 4), 1)
+			// the limit is of the proxied downloads of the users
+			d.ConcurrencyLimit = nil
+		})
+		rc, err := d.Download(task.Ctx(), &net.HttpRequestParams{
+			URL:       task.Url,
+			Range:     http_range.Range{Start: p.Written, Length: p.Size - p.Written},
+			HeaderRef: requestHeader(task),
+			Size:      p.Size,
+		})
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = utils.CopyWithBuffer(&progressWriter{file: file, task: task, p: p, updated: time.Now()}, rc)
+		task.SetProgress(float64(p.Written) * 100 / float64(p.Size))
+		if err != nil {
+			return err
+		}
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return verifyChecksum(task, filePath)
+}
+
+// progressWriter writes the file and keeps the progress of the task
+type progressWriter struct {
+	file    *os.File
+	task    *tool.DownloadTask
+	p       *tool.PartialDownload
+	updated time.Time
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.file.Write(b)
+	w.p.Written += int64(n)
+	if time.Since(w.updated) > progressInterval {
+		w.task.SetProgress(float64(w.p.Written) * 100 / float64(w.p.Size))
+		w.updated = time.Now()
+	}
+	return n, err
+}
+
+func requestHeader(task *tool.DownloadTask) http.Header {
+	header := task.Header()
+	if header.Get("User-Agent") == "" {
+		header.Set("User-Agent", base.UserAgent)
+	}
+	return header
+}
+
+// verifyChecksum checks the downloaded file against the checksum of the task,
+// the file is removed if it's unmatched so that a retry downloads it again
+func verifyChecksum(task *tool.DownloadTask, filePath string) error {
+	if task.Checksum == "" {
+		return nil
+	}
+	ht, expected, err := tool.ParseChecksum(task.Checksum)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	sum, err := utils.HashFile(ht, file)
+	_ = file.Close()
+	if err != nil {
+		return err
+	}
+	if sum != expected {
+		task.Partial = nil
+		_ = os.Remove(filePath)
+		return fmt.Errorf("%s checksum mismatch, expected %s, got %s", ht.Name, expected, sum)
+	}
+	return nil
 }
 
 func init() {
@@ -3,6 +3,8 @@ package http
 import (
 	"fmt"
 	"mime"
+	"strconv"
+	"strings"
 )
 
 func parseFilenameFromContentDisposition(contentDisposition string) (string, error) {
@@ -19,3 +21,13 @@ func parseFilenameFromContentDisposition(contentDisposition string) (string, err
 	}
 	return filename, nil
 }
+
+// parseTotalSize parses the total size from the Content-Range header like "bytes 0-99/1000"
+func parseTotalSize(contentRange string) (int64, bool) {
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok || total == "*" {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	return size, err == nil
+}
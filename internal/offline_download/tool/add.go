@@ -5,6 +5,7 @@ import (
 	"net/url"
 	stdpath "path"
 	"path/filepath"
+	"strings"
 
 	_115 "github.com/OpenListTeam/OpenList/v4/drivers/115"
 	_115_open "github.com/OpenListTeam/OpenList/v4/drivers/115_open"
@@ -21,6 +22,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -41,6 +43,31 @@ type AddURLArgs struct {
 	DstDirPath   string
 	Tool         string
 	DeletePolicy DeletePolicy
+	// the request headers and the expected checksum of the file, only for SimpleHttp
+	Headers  map[string]string
+	Checksum string
+	// the paths of the files to download in a torrent, only for BitTorrent
+	Files []string
+	// the name of the downloaded file, optional
+	Name string
+	// the batch the task is added in, see ImportURLs
+	BatchID string
+}
+
+// ParseChecksum parses the checksum like sha256:<hex>, md5 and sha1 are supported too
+func ParseChecksum(checksum string) (*utils.HashType, string, error) {
+	name, sum, ok := strings.Cut(checksum, ":")
+	if !ok || sum == "" {
+		return nil, "", errors.Errorf("invalid checksum: %s", checksum)
+	}
+	ht, ok := utils.GetHashByName(strings.ToLower(name))
+	if !ok {
+		return nil, "", errors.Errorf("unsupported checksum type: %s", name)
+	}
+	if len(sum) != ht.Width {
+		return nil, "", errors.Errorf("invalid %s checksum: %s", ht.Name, sum)
+	}
+	return ht, strings.ToLower(sum), nil
 }
 
 func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, error) {
@@ -65,9 +92,30 @@ func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, erro
 			return nil, errors.WithStack(errs.NotFolder)
 		}
 	}
-	// try putting url
-	if args.Tool == "SimpleHttp" {
-		err = tryPutUrl(ctx, args.DstDirPath, args.URL)
+	// only SimpleHttp requests with the headers and verifies the checksum, the other tools would ignore them
+	if (len(args.Headers) > 0 || args.Checksum != "") && args.Tool != "SimpleHttp" {
+		return nil, errors.Errorf("the headers and the checksum can't be applied by the tool %s", args.Tool)
+	}
+	if args.Checksum != "" {
+		// the streamed file is put before it could be verified
+		if args.DeletePolicy == UploadDownloadStream {
+			return nil, errors.New("the checksum can't be verified if the download is streamed to the storage")
+		}
+		if _, _, err = ParseChecksum(args.Checksum); err != nil {
+			return nil, err
+		}
+	}
+	if args.Name != "" {
+		if !validName(args.Name) {
+			return nil, errors.Errorf("invalid name: %s", args.Name)
+		}
+		if isCloudTool(args.Tool) {
+			return nil, errors.Errorf("the name can't be set for the tool %s", args.Tool)
+		}
+	}
+	// try putting url, the storage can't request with the headers or verify the checksum
+	if args.Tool == "SimpleHttp" && len(args.Headers) == 0 && args.Checksum == "" {
+		err = tryPutUrl(ctx, args.DstDirPath, args.URL, args.Name)
 		if err == nil || !errors.Is(err, errs.NotImplement) {
 			return nil, err
 		}
@@ -156,18 +204,26 @@ func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, erro
 		DeletePolicy: deletePolicy,
 		Toolname:     args.Tool,
 		tool:         tool,
+		Headers:      args.Headers,
+		Checksum:     args.Checksum,
+		Files:        args.Files,
+		Name:         args.Name,
+		batchID:      args.BatchID,
 	}
+	batchAdd(t.batchID)
 	DownloadTaskManager.Add(t)
 	return t, nil
 }
 
-func tryPutUrl(ctx context.Context, path, urlStr string) error {
-	var dstName string
-	u, err := url.Parse(urlStr)
-	if err == nil {
-		dstName = stdpath.Base(u.Path)
-	} else {
-		dstName = "UnnamedURL"
+func tryPutUrl(ctx context.Context, path, urlStr, name string) error {
+	dstName := name
+	if dstName == "" {
+		u, err := url.Parse(urlStr)
+		if err == nil {
+			dstName = stdpath.Base(u.Path)
+		} else {
+			dstName = "UnnamedURL"
+		}
 	}
 	return fs.PutURL(ctx, path, dstName, urlStr)
 }
@@ -9,6 +9,8 @@ type AddUrlArgs struct {
 	UID     string
 	TempDir string
 	Signal  chan int
+	// the paths of the files to download in a torrent, all the files if empty
+	Files []string
 }
 
 type Status struct {
@@ -2,13 +2,17 @@ package tool
 
 import (
 	"fmt"
+	"net/http"
+	"os"
 	"path"
+	"path/filepath"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
@@ -30,6 +34,54 @@ type DownloadTask struct {
 	GID               string       `json:"-"`
 	tool              Tool
 	callStatusRetried int
+
+	// the custom request headers, e.g. Cookie and Referer
+	Headers map[string]string `json:"headers,omitempty"`
+	// the expected checksum of the downloaded file, e.g. sha256:<hex>
+	Checksum string `json:"checksum,omitempty"`
+	// the paths of the files to download in a torrent, all the files if empty
+	Files []string `json:"files,omitempty"`
+	// the progress of the ranged download, persisted to resume it
+	Partial *PartialDownload `json:"partial,omitempty"`
+	// the name of the downloaded file, the name of the url or the tool is used if empty
+	Name string `json:"name,omitempty"`
+	// the batch the task is imported in, it's not restored after restart like the groups of transfers,
+	// so the batch isn't notified if the task is retried after that
+	batchID string
+}
+
+// PartialDownload is the state of a file downloaded by ranges to the temp dir
+type PartialDownload struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	// the bytes written to the file from the start
+	Written int64 `json:"written"`
+	// the validators of the file, the download restarts if they are changed
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// HasPartialDownloads reports whether any unfinished task keeps a partially downloaded file in the temp dir
+func HasPartialDownloads() bool {
+	for _, t := range DownloadTaskManager.GetAll() {
+		switch t.GetState() {
+		case tache.StateSucceeded, tache.StateCanceled, tache.StateErrored, tache.StateFailed:
+			continue
+		}
+		if t.Partial != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Header returns the request headers of the task
+func (t *DownloadTask) Header() http.Header {
+	header := http.Header{}
+	for k, v := range t.Headers {
+		header.Set(k, v)
+	}
+	return header
 }
 
 func (t *DownloadTask) Run() error {
@@ -58,6 +110,7 @@ func (t *DownloadTask) Run() error {
 		UID:     t.ID,
 		TempDir: t.TempDir,
 		Signal:  t.Signal,
+		Files:   t.Files,
 	})
 	if err != nil {
 		return err
@@ -174,11 +227,10 @@ func (t *DownloadTask) Update() (bool, error) {
 }
 
 func (t *DownloadTask) Transfer() error {
-	toolName := t.tool.Name()
-	if toolName == "115 Cloud" || toolName == "115 Open" || toolName == "123 Open" || toolName == "123Pan" || toolName == "PikPak" || toolName == "Thunder" || toolName == "ThunderX" || toolName == "ThunderBrowser" {
+	if isCloudTool(t.tool.Name()) {
 		// 如果不是直接下载到目标路径，则进行转存
 		if t.TempDir != t.DstDirPath {
-			return transferObj(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy)
+			return transferObj(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy, t.batchID)
 		}
 		return nil
 	}
@@ -201,14 +253,58 @@ func (t *DownloadTask) Transfer() error {
 			},
 			DeletePolicy: t.DeletePolicy,
 			Url:          t.Url,
+			Headers:      t.Headers,
 		}
 		tsk.SetTotalBytes(t.GetTotalBytes())
 		tsk.groupID = path.Join(tsk.DstStorageMp, tsk.DstActualPath)
 		task_group.TransferCoordinator.AddTask(tsk.groupID, nil)
+		tsk.batchID = t.batchID
+		batchAdd(tsk.batchID)
 		TransferTaskManager.Add(tsk)
 		return nil
 	}
-	return transferStd(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy)
+	if t.Name != "" {
+		if err := nameDownloaded(t.TempDir, t.Name); err != nil {
+			return errors.WithMessage(err, "failed to rename downloaded file")
+		}
+	}
+	return transferStd(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy, t.batchID)
+}
+
+// isCloudTool reports whether the tool downloads to its cloud, the downloaded objects are transferred as they are
+func isCloudTool(name string) bool {
+	switch name {
+	case "115 Cloud", "115 Open", "123 Open", "123Pan", "PikPak", "Thunder", "ThunderX", "ThunderBrowser":
+		return true
+	}
+	return false
+}
+
+// nameDownloaded renames the downloaded file in the temp dir to the name,
+// the name is ignored if the download is not a single file, e.g. a torrent of many files
+func nameDownloaded(tempDir, name string) error {
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 1 || entries[0].IsDir() {
+		log.Warnf("the name %s is ignored, the download in %s is not a single file", name, tempDir)
+		return nil
+	}
+	if entries[0].Name() == name {
+		return nil
+	}
+	return os.Rename(filepath.Join(tempDir, entries[0].Name()), filepath.Join(tempDir, name))
+}
+
+func (t *DownloadTask) OnSucceeded() {
+	notify.TaskDone(t, "offline_download", true)
+	batchDone(t, t.batchID, true)
+}
+
+func (t *DownloadTask) OnFailed() {
+	notify.TaskDone(t, "offline_download", false)
+	batchDone(t, t.batchID, false)
 }
 
 func (t *DownloadTask) GetName() string {
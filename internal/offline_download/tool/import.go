@@ -0,0 +1,199 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// ImportEntry is a line of the manifest of a batch import
+type ImportEntry struct {
+	URL string `json:"url"`
+	// the destination dir, relative to the path of the import if it's not absolute
+	Dir      string            `json:"dir"`
+	Name     string            `json:"name"`
+	Headers  map[string]string `json:"headers"`
+	Checksum string            `json:"checksum"`
+}
+
+// ParseManifest parses the manifest of a batch import, the formats are:
+//   - json: an array of ImportEntry
+//   - csv: the header row names the columns url, dir, name and checksum,
+//     the other columns are the request headers named by them
+//   - aria2: the input file of aria2, the options dir, out, header and checksum are used
+func ParseManifest(format string, r io.Reader) ([]ImportEntry, error) {
+	switch format {
+	case "json":
+		var entries []ImportEntry
+		if err := utils.Json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, errors.Wrap(err, "invalid json manifest")
+		}
+		return entries, nil
+	case "csv":
+		return parseCSVManifest(r)
+	case "aria2":
+		return parseAria2Manifest(r)
+	default:
+		return nil, errors.Errorf("unsupported manifest format: %s", format)
+	}
+}
+
+func parseCSVManifest(r io.Reader) ([]ImportEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid csv manifest")
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := records[0]
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+	var entries []ImportEntry
+	for _, record := range records[1:] {
+		var e ImportEntry
+		for i, v := range record {
+			v = strings.TrimSpace(v)
+			if i >= len(columns) || v == "" {
+				continue
+			}
+			switch strings.ToLower(columns[i]) {
+			case "url":
+				e.URL = v
+			case "dir":
+				e.Dir = v
+			case "name":
+				e.Name = v
+			case "checksum":
+				e.Checksum = v
+			default:
+				if e.Headers == nil {
+					e.Headers = make(map[string]string)
+				}
+				e.Headers[columns[i]] = v
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func parseAria2Manifest(r io.Reader) ([]ImportEntry, error) {
+	var entries []ImportEntry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		// the lines of the options of the uri start with white spaces
+		if line[0] != ' ' && line[0] != '\t' {
+			// the mirrors of the file are separated by tabs, only the first one is used
+			uri, _, _ := strings.Cut(trimmed, "\t")
+			entries = append(entries, ImportEntry{URL: uri})
+			continue
+		}
+		if len(entries) == 0 {
+			return nil, errors.Errorf("option without uri: %s", trimmed)
+		}
+		e := &entries[len(entries)-1]
+		key, value, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid option: %s", trimmed)
+		}
+		switch key {
+		case "dir":
+			e.Dir = value
+		case "out":
+			e.Name = value
+		case "header":
+			if name, v, ok := strings.Cut(value, ":"); ok {
+				if e.Headers == nil {
+					e.Headers = make(map[string]string)
+				}
+				e.Headers[strings.TrimSpace(name)] = strings.TrimSpace(v)
+			}
+		case "checksum":
+			// aria2 writes the checksum like sha-256=<hex>
+			if typ, sum, ok := strings.Cut(value, "="); ok {
+				e.Checksum = strings.ReplaceAll(strings.ToLower(typ), "-", "") + ":" + sum
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrap(err, "invalid aria2 manifest")
+	}
+	return entries, nil
+}
+
+// ImportURLs adds the tasks in a batch, the completion of the batch is notified once
+// all the tasks and the transfers created by them are done.
+// The results are in the order of the args, the task is nil if the url is put to the storage directly.
+func ImportURLs(ctx context.Context, args []*AddURLArgs) (string, []task.TaskExtensionInfo, []error) {
+	batchID := uuid.NewString()
+	info := task_group.BatchInfo{Type: "offline_download"}
+	if creator, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		info.UserId = creator.ID
+	}
+	// the guard keeps the batch from being completed before all the tasks are added
+	task_group.BatchCoordinator.AddTask(batchID, info)
+	tasks := make([]task.TaskExtensionInfo, len(args))
+	errs := make([]error, len(args))
+	added := false
+	for i, arg := range args {
+		arg.BatchID = batchID
+		t, err := AddURL(ctx, arg)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if t != nil {
+			tasks[i] = t
+			added = true
+		}
+	}
+	// the batch is notified even if all the tasks fail later, but not if none of them is added
+	task_group.BatchCoordinator.Done(context.WithoutCancel(ctx), batchID, added)
+	return batchID, tasks, errs
+}
+
+func batchAdd(batchID string) {
+	if batchID != "" {
+		task_group.BatchCoordinator.AddTask(batchID, nil)
+	}
+}
+
+// batchDone reports the result of the task to its batch, if it's in a batch
+func batchDone(t task.TaskExtensionInfo, batchID string, succeeded bool) {
+	if batchID == "" {
+		return
+	}
+	r := task_group.BatchResult{Name: t.GetName(), Succeeded: succeeded}
+	if err := t.GetErr(); err != nil && !succeeded {
+		r.Error = err.Error()
+	}
+	task_group.BatchCoordinator.AppendPayload(batchID, r)
+	task_group.BatchCoordinator.Done(context.WithoutCancel(t.Ctx()), batchID, succeeded)
+}
+
+// validName reports whether the name can be the name of the downloaded file
+func validName(name string) bool {
+	return name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
@@ -0,0 +1,47 @@
+package tool
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	want := []ImportEntry{
+		{URL: "https://example.com/a.bin", Dir: "sub", Name: "b.bin", Checksum: "sha256:abc", Headers: map[string]string{"Referer": "https://example.com/"}},
+		{URL: "https://example.com/c.bin"},
+	}
+	tests := []struct {
+		format, manifest string
+	}{
+		{"json", `[{"url":"https://example.com/a.bin","dir":"sub","name":"b.bin","checksum":"sha256:abc","headers":{"Referer":"https://example.com/"}},{"url":"https://example.com/c.bin"}]`},
+		{"csv", "url,dir,name,checksum,Referer\n" +
+			"https://example.com/a.bin, sub, b.bin, sha256:abc, https://example.com/\n" +
+			"# comment\n" +
+			"https://example.com/c.bin,,,\n"},
+		{"aria2", "# comment\n" +
+			"https://example.com/a.bin\thttps://mirror.example.com/a.bin\n" +
+			"  dir=sub\n" +
+			"  out=b.bin\n" +
+			"  checksum=sha-256=abc\n" +
+			"  header=Referer: https://example.com/\n" +
+			"  split=4\n" +
+			"\n" +
+			"https://example.com/c.bin\n"},
+	}
+	for _, tt := range tests {
+		got, err := ParseManifest(tt.format, strings.NewReader(tt.manifest))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", tt.format, got, want)
+		}
+	}
+	if _, err := ParseManifest("xml", strings.NewReader("")); err == nil {
+		t.Errorf("unsupported format should fail")
+	}
+	if _, err := ParseManifest("aria2", strings.NewReader("  dir=sub\n")); err == nil {
+		t.Errorf("option without uri should fail")
+	}
+}
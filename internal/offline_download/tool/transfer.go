@@ -3,6 +3,7 @@ package tool
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path"
 	stdpath "path"
@@ -12,6 +13,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
@@ -29,6 +31,10 @@ type TransferTask struct {
 	DeletePolicy DeletePolicy `json:"delete_policy"`
 	Url          string       `json:"url"`
 	groupID      string       `json:"-"`
+	// the request headers of Url
+	Headers map[string]string `json:"headers,omitempty"`
+	// the batch of the download task, see DownloadTask
+	batchID string
 }
 
 func (t *TransferTask) Run() error {
@@ -51,7 +57,11 @@ func (t *TransferTask) Run() error {
 	defer func() { t.SetEndTime(time.Now()) }()
 	if t.SrcStorage == nil {
 		if t.DeletePolicy == UploadDownloadStream {
-			rr, err := stream.GetRangeReaderFromLink(t.GetTotalBytes(), &model.Link{URL: t.Url})
+			header := http.Header{}
+			for k, v := range t.Headers {
+				header.Set(k, v)
+			}
+			rr, err := stream.GetRangeReaderFromLink(t.GetTotalBytes(), &model.Link{URL: t.Url, Header: header})
 			if err != nil {
 				return err
 			}
@@ -96,6 +106,8 @@ func (t *TransferTask) OnSucceeded() {
 		}
 	}
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
+	notify.TaskDone(t, "offline_download_transfer", true)
+	batchDone(t, t.batchID, true)
 }
 
 func (t *TransferTask) OnFailed() {
@@ -107,6 +119,8 @@ func (t *TransferTask) OnFailed() {
 		}
 	}
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
+	notify.TaskDone(t, "offline_download_transfer", false)
+	batchDone(t, t.batchID, false)
 }
 
 func (t *TransferTask) SetRetry(retry int, maxRetry int) {
@@ -123,7 +137,7 @@ var (
 	TransferTaskManager *tache.Manager[*TransferTask]
 )
 
-func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy DeletePolicy) error {
+func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy DeletePolicy, batchID string) error {
 	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get dst storage")
@@ -146,9 +160,11 @@ func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy D
 				DstStorageMp:  dstStorage.GetStorage().MountPath,
 			},
 			DeletePolicy: deletePolicy,
+			batchID:      batchID,
 		}
 		t.groupID = path.Join(t.DstStorageMp, t.DstActualPath)
 		task_group.TransferCoordinator.AddTask(t.groupID, nil)
+		batchAdd(batchID)
 		TransferTaskManager.Add(t)
 	}
 	return nil
@@ -184,8 +200,10 @@ func transferStdPath(t *TransferTask) error {
 				},
 				groupID:      t.groupID,
 				DeletePolicy: t.DeletePolicy,
+				batchID:      t.batchID,
 			}
 			task_group.TransferCoordinator.AddTask(t.groupID, nil)
+			batchAdd(t.batchID)
 			TransferTaskManager.Add(task)
 		}
 		t.Status = "src object is dir, added all transfer tasks of files"
@@ -230,7 +248,7 @@ func removeStdTemp(t *TransferTask) {
 	}
 }
 
-func transferObj(ctx context.Context, tempDir, dstDirPath string, deletePolicy DeletePolicy) error {
+func transferObj(ctx context.Context, tempDir, dstDirPath string, deletePolicy DeletePolicy, batchID string) error {
 	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(tempDir)
 	if err != nil {
 		return errors.WithMessage(err, "failed get src storage")
@@ -259,9 +277,11 @@ func transferObj(ctx context.Context, tempDir, dstDirPath string, deletePolicy D
 				DstStorageMp:  dstStorage.GetStorage().MountPath,
 			},
 			DeletePolicy: deletePolicy,
+			batchID:      batchID,
 		}
 		t.groupID = path.Join(t.DstStorageMp, t.DstActualPath)
 		task_group.TransferCoordinator.AddTask(t.groupID, nil)
+		batchAdd(batchID)
 		TransferTaskManager.Add(t)
 	}
 	return nil
@@ -287,6 +307,7 @@ func transferObjPath(t *TransferTask) error {
 			}
 			srcObjPath := stdpath.Join(t.SrcActualPath, obj.GetName())
 			task_group.TransferCoordinator.AddTask(t.groupID, nil)
+			batchAdd(t.batchID)
 			TransferTaskManager.Add(&TransferTask{
 				TaskData: fs.TaskData{
 					TaskExtension: task.TaskExtension{
@@ -302,6 +323,7 @@ func transferObjPath(t *TransferTask) error {
 				},
 				groupID:      t.groupID,
 				DeletePolicy: t.DeletePolicy,
+				batchID:      t.batchID,
 			})
 		}
 		t.Status = "src object is dir, added all transfer tasks of objs"
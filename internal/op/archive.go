@@ -530,6 +530,18 @@ func ArchiveDecompress(ctx context.Context, storage driver.Driver, srcPath, dstD
 	default:
 		return errs.NotImplement
 	}
+	if err == nil {
+		// without the result, the whole dst dir has to be reindexed
+		if len(newObjs) > 0 {
+			for _, newObj := range newObjs {
+				emitObjEvent(ctx, storage, ObjEventAdd, stdpath.Join(dstDirPath, newObj.GetName()))
+			}
+		} else if args.PutIntoNewDir {
+			emitObjEvent(ctx, storage, ObjEventAdd, stdpath.Join(dstDirPath, strings.TrimSuffix(srcObj.GetName(), stdpath.Ext(srcObj.GetName()))))
+		} else {
+			emitObjEvent(ctx, storage, ObjEventAdd, dstDirPath)
+		}
+	}
 	if !utils.IsBool(lazyCache...) && err == nil && needHandleObjsUpdateHook() {
 		onlyList := false
 		targetPath := dstDirPath
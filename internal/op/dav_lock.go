@@ -0,0 +1,108 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// the check and the creation of a lock are serialized in this process,
+// the other instances sharing the database only see the created locks
+var davLockMu sync.Mutex
+
+// GetDavLocks returns the alive locks applying to path: the lock of path itself
+// and the infinite depth locks of its parents. With children, the locks of
+// the paths under path are returned too.
+func GetDavLocks(path string, children bool, now time.Time) ([]model.DavLock, error) {
+	path = utils.FixAndCleanPath(path)
+	roots := []string{path}
+	for p := path; p != "/"; {
+		p = stdpath.Dir(p)
+		roots = append(roots, p)
+	}
+	locks, err := db.GetDavLocksByRoots(roots, now)
+	if err != nil {
+		return nil, err
+	}
+	res := locks[:0]
+	for _, l := range locks {
+		if l.Root == path || !l.ZeroDepth {
+			res = append(res, l)
+		}
+	}
+	if !children {
+		return res, nil
+	}
+	under, err := db.GetDavLocksUnder(path, now)
+	if err != nil {
+		return nil, err
+	}
+	return append(res, under...), nil
+}
+
+// CreateDavLock creates the lock or returns errs.ObjectLocked if
+// the resource or a part of it is locked already
+func CreateDavLock(l *model.DavLock, now time.Time) error {
+	l.Root = utils.FixAndCleanPath(l.Root)
+	davLockMu.Lock()
+	defer davLockMu.Unlock()
+	if err := db.DeleteExpiredDavLocks(now); err != nil {
+		log.Warnf("failed delete expired dav locks: %+v", err)
+	}
+	locks, err := GetDavLocks(l.Root, !l.ZeroDepth, now)
+	if err != nil {
+		return err
+	}
+	if len(locks) > 0 {
+		return errors.WithStack(errs.ObjectLocked)
+	}
+	return db.CreateDavLock(l)
+}
+
+// GetDavLockByToken returns the alive lock of token, or nil if there isn't one
+func GetDavLockByToken(token string, now time.Time) (*model.DavLock, error) {
+	l, err := db.GetDavLockByToken(token, now)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return l, err
+}
+
+func UpdateDavLock(l *model.DavLock) error {
+	return db.UpdateDavLock(l)
+}
+
+func DeleteDavLockByToken(token string) error {
+	return db.DeleteDavLockByToken(token)
+}
+
+// CheckDavLocks returns errs.ObjectLocked if any of the paths or their children
+// are locked by WebDAV clients of another user than the one in ctx.
+// It's an advisory check for the write operations not made by WebDAV,
+// the WebDAV requests confirm the locks by their tokens.
+func CheckDavLocks(ctx context.Context, paths ...string) error {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	now := time.Now()
+	for _, path := range paths {
+		locks, err := GetDavLocks(path, true, now)
+		if err != nil {
+			return err
+		}
+		for _, l := range locks {
+			if user == nil || l.UserId != user.ID {
+				return errs.NewErr(errs.ObjectLocked, "%s is locked by WebDAV", l.Root)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func GetDavProps(path string) ([]model.DavProp, error) {
+	return db.GetDavPropsByPath(utils.FixAndCleanPath(path))
+}
+
+func SetDavProps(path string, props []model.DavProp) error {
+	return db.SetDavProps(utils.FixAndCleanPath(path), props)
+}
+
+func MoveDavProps(src, dst string) error {
+	return db.MoveDavProps(utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst))
+}
+
+func CopyDavProps(src, dst string) error {
+	return db.CopyDavProps(utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst))
+}
+
+func DeleteDavProps(path string) error {
+	return db.DeleteDavProps(utils.FixAndCleanPath(path))
+}
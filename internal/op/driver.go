@@ -173,6 +173,48 @@ func getMainItems(config driver.Config) []driver.Item {
 		Default:  "false",
 		Required: true,
 	})
+	if !config.NoUpload {
+		items = append(items, []driver.Item{{
+			Name:    "trash_enabled",
+			Type:    conf.TypeBool,
+			Default: "false",
+			Help:    "Move the removed files to the trash directory instead of deleting them",
+		}, {
+			Name:    "trash_path",
+			Type:    conf.TypeString,
+			Default: "/.openlist-trash",
+			Help:    "The trash directory, relative to the root of this storage",
+		}, {
+			Name:    "trash_retention_days",
+			Type:    conf.TypeNumber,
+			Default: "30",
+			Help:    "Days to keep the files in the trash, 0 means forever",
+		}, {
+			Name:    "versioning_enabled",
+			Type:    conf.TypeBool,
+			Default: "false",
+			Help:    "Keep the previous versions of the overwritten files",
+		}, {
+			Name: "versions_storage",
+			Type: conf.TypeString,
+			Help: "Mount path of the storage keeping the versions, empty means this storage",
+		}, {
+			Name:    "versions_path",
+			Type:    conf.TypeString,
+			Default: "/.versions",
+			Help:    "The versions directory, relative to the root of the storage keeping the versions",
+		}, {
+			Name:    "versions_max_count",
+			Type:    conf.TypeNumber,
+			Default: "10",
+			Help:    "Versions to keep of a file, 0 means unlimited",
+		}, {
+			Name:    "versions_retention",
+			Type:    conf.TypeNumber,
+			Default: "30",
+			Help:    "Days to keep a version, 0 means forever",
+		}}...)
+	}
 	return items
 }
 func getAdditionalItems(t reflect.Type, defaultRoot string) []driver.Item {
@@ -346,6 +346,7 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string) error {
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
+		emitObjEvent(ctx, storage, ObjEventAdd, path)
 		if storage.Config().NoCache {
 			return nil, nil
 		}
@@ -395,6 +396,12 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if model.ObjHasMask(dstDir, model.NoWrite) {
 		return errors.WithStack(errs.PermissionDenied)
 	}
+	if !srcRawObj.IsDir() {
+		err = CheckQuota(ctx, Key(storage, dstDirPath), srcRawObj.GetSize(), 1, Key(storage, srcDirPath))
+		if err != nil {
+			return err
+		}
+	}
 
 	var newObj model.Obj
 	switch s := storage.(type) {
@@ -408,12 +415,15 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	emitObjEvent(ctx, storage, ObjEventMove, srcPath, stdpath.Join(dstDirPath, srcRawObj.GetName()))
 
 	srcKey := Key(storage, srcDirPath)
 	dstKey := Key(storage, dstDirPath)
 	if !srcRawObj.IsDir() {
 		Cache.linkCache.DeleteKey(stdpath.Join(srcKey, srcRawObj.GetName()))
 		Cache.linkCache.DeleteKey(stdpath.Join(dstKey, srcRawObj.GetName()))
+		AddQuotaUsage(srcKey, -srcRawObj.GetSize(), -1)
+		AddQuotaUsage(dstKey, srcRawObj.GetSize(), 1)
 	}
 	if !storage.Config().NoCache {
 		if cache, exist := Cache.dirCache.Get(srcKey); exist {
@@ -472,6 +482,7 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	emitObjEvent(ctx, storage, ObjEventMove, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName))
 
 	dirKey := Key(storage, stdpath.Dir(srcPath))
 	if !srcRawObj.IsDir() {
@@ -528,6 +539,11 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if model.ObjHasMask(dstDir, model.NoWrite) {
 		return errors.WithStack(errs.PermissionDenied)
 	}
+	if !srcRawObj.IsDir() {
+		if err = CheckQuota(ctx, Key(storage, dstDirPath), srcRawObj.GetSize(), 1); err != nil {
+			return err
+		}
+	}
 
 	var newObj model.Obj
 	switch s := storage.(type) {
@@ -541,10 +557,12 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	emitObjEvent(ctx, storage, ObjEventAdd, stdpath.Join(dstDirPath, srcRawObj.GetName()))
 
 	dstKey := Key(storage, dstDirPath)
 	if !srcRawObj.IsDir() {
 		Cache.linkCache.DeleteKey(stdpath.Join(dstKey, srcRawObj.GetName()))
+		AddQuotaUsage(dstKey, srcRawObj.GetSize(), 1)
 	}
 	if !storage.Config().NoCache {
 		if cache, exist := Cache.dirCache.Get(dstKey); exist {
@@ -569,6 +587,12 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 }
 
 func Remove(ctx context.Context, storage driver.Driver, path string) error {
+	return remove(ctx, storage, path, true)
+}
+
+// remove removes the object, the usage of the quotas is not changed if updateQuota is false,
+// which is used when the object is replaced by a put that accounts for it
+func remove(ctx context.Context, storage driver.Driver, path string, updateQuota bool) error {
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
 	}
@@ -594,7 +618,12 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	case driver.Remove:
 		err = s.Remove(ctx, model.UnwrapObjName(rawObj))
 		if err == nil {
+			emitObjEvent(ctx, storage, ObjEventDelete, path)
 			Cache.removeDirectoryObject(storage, dirPath, rawObj)
+			// the usage of a removed directory is corrected by ReconcileQuotas
+			if updateQuota && !rawObj.IsDir() {
+				AddQuotaUsage(Key(storage, path), -rawObj.GetSize(), -1)
+			}
 		}
 	default:
 		return errs.NotImplement
@@ -617,27 +646,15 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		dstDirPath, link = urlTreeSplitLineFormPath(stdpath.Join(dstDirPath, file.GetName()))
 		file = &stream.FileStream{Obj: &model.Object{Name: link}, Closers: utils.Closers{file}}
 	}
-	// if file exist and size = 0, delete it
 	dstDirPath = utils.FixAndCleanPath(dstDirPath)
 	dstPath := stdpath.Join(dstDirPath, file.GetName())
 	tempName := file.GetName() + ".openlist_to_delete"
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
+	// the existing file is overwritten, its usage is replaced by the one of the new file
+	var old model.Obj
 	if err == nil {
-		if fi.GetSize() == 0 {
-			err = Remove(ctx, storage, dstPath)
-			if err != nil {
-				return errors.WithMessagef(err, "while uploading, failed remove existing file which size = 0")
-			}
-		} else if storage.Config().NoOverwriteUpload {
-			// try to rename old obj
-			err = Rename(ctx, storage, dstPath, tempName)
-			if err != nil {
-				return err
-			}
-		} else {
-			file.SetExist(fi)
-		}
+		old = fi
 	}
 	err = MakeDir(ctx, storage, dstDirPath)
 	if err != nil {
@@ -662,6 +679,40 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		file.CacheFullAndWriter(nil, nil)
 	}
 
+	// the quota is checked before the existing file is touched, so a rejected put keeps it
+	quotaSize, quotaFiles, err := checkPutQuota(ctx, storage, dstDirPath, file.GetSize(), old)
+	if err != nil {
+		return err
+	}
+
+	// the existing file is replaced rather than removed, so the temp changes aren't obj events
+	internalCtx := context.WithValue(ctx, conf.SkipObjEventKey, struct{}{})
+	putDone := false
+	if old != nil {
+		// if file exist and size = 0, delete it
+		if fi.GetSize() == 0 {
+			err = remove(internalCtx, storage, dstPath, false)
+			if err != nil {
+				return errors.WithMessagef(err, "while uploading, failed remove existing file which size = 0")
+			}
+			// the removed file is still counted for the new one, it's dropped if the put fails
+			defer func() {
+				if !putDone {
+					emitObjEvent(ctx, storage, ObjEventDelete, dstPath)
+					AddQuotaUsage(Key(storage, dstPath), 0, -1)
+				}
+			}()
+		} else if storage.Config().NoOverwriteUpload {
+			// try to rename old obj
+			err = Rename(internalCtx, storage, dstPath, tempName)
+			if err != nil {
+				return err
+			}
+		} else {
+			file.SetExist(fi)
+		}
+	}
+
 	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.PutResult:
@@ -672,6 +723,9 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		return errs.NotImplement
 	}
 	if err == nil {
+		putDone = true
+		emitObjEvent(ctx, storage, ObjEventAdd, dstPath)
+		AddQuotaUsage(Key(storage, dstPath), quotaSize, quotaFiles)
 		Cache.linkCache.DeleteKey(Key(storage, dstPath))
 		if !storage.Config().NoCache {
 			if cache, exist := Cache.dirCache.Get(Key(storage, dstDirPath)); exist {
@@ -697,13 +751,13 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
-			err := Rename(ctx, storage, tempPath, file.GetName())
+			err := Rename(internalCtx, storage, tempPath, file.GetName())
 			if err != nil {
 				log.Errorf("failed recover old obj: %+v", err)
 			}
 		} else {
-			// upload success, remove old obj
-			err = Remove(ctx, storage, tempPath)
+			// upload success, remove old obj, which is accounted by the put already
+			err = remove(internalCtx, storage, tempPath, false)
 		}
 	}
 	return errors.WithStack(err)
@@ -740,6 +794,7 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 		return errors.WithStack(errs.NotImplement)
 	}
 	if err == nil {
+		emitObjEvent(ctx, storage, ObjEventAdd, dstPath)
 		Cache.linkCache.DeleteKey(Key(storage, dstPath))
 		if !storage.Config().NoCache {
 			if cache, exist := Cache.dirCache.Get(Key(storage, dstDirPath)); exist {
@@ -0,0 +1,136 @@
+package op
+
+import (
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// the groups and path rules are few and read on every access check,
+// so all of them are kept in memory and reloaded after any change
+var (
+	aclMu     sync.RWMutex
+	aclLoaded bool
+	groupMap  map[uint]model.Group
+	pathRules []model.PathRule
+)
+
+func loadAcl() error {
+	aclMu.RLock()
+	loaded := aclLoaded
+	aclMu.RUnlock()
+	if loaded {
+		return nil
+	}
+	aclMu.Lock()
+	defer aclMu.Unlock()
+	if aclLoaded {
+		return nil
+	}
+	groups, err := db.GetAllGroups()
+	if err != nil {
+		return err
+	}
+	rules, err := db.GetAllPathRules()
+	if err != nil {
+		return err
+	}
+	groupMap = make(map[uint]model.Group, len(groups))
+	for _, g := range groups {
+		groupMap[g.ID] = g
+	}
+	pathRules = rules
+	aclLoaded = true
+	return nil
+}
+
+func resetAcl() {
+	aclMu.Lock()
+	aclLoaded = false
+	aclMu.Unlock()
+}
+
+// GetUserPermission returns the effective permission of the user on reqPath,
+// it's the permission of the user and its groups with the path rules applied
+func GetUserPermission(u *model.User, reqPath string) int32 {
+	// reading is allowed by default, path rules may deny it
+	base := u.Permission | 1<<15
+	if u.IsAdmin() {
+		return u.MaskPermission(base)
+	}
+	if err := loadAcl(); err != nil {
+		log.Errorf("failed load groups and path rules: %+v", err)
+		return 0
+	}
+	aclMu.RLock()
+	defer aclMu.RUnlock()
+	for _, id := range u.GroupIds {
+		if g, ok := groupMap[id]; ok {
+			base |= g.Permission
+		}
+	}
+	if len(pathRules) == 0 {
+		return u.MaskPermission(base)
+	}
+	return u.MaskPermission(model.ResolvePermission(u, base, pathRules, reqPath))
+}
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+func GetGroupById(id uint) (*model.Group, error) {
+	return db.GetGroupById(id)
+}
+
+func CreateGroup(g *model.Group) error {
+	defer resetAcl()
+	return db.CreateGroup(g)
+}
+
+func UpdateGroup(g *model.Group) error {
+	if _, err := db.GetGroupById(g.ID); err != nil {
+		return err
+	}
+	defer resetAcl()
+	return db.UpdateGroup(g)
+}
+
+func DeleteGroupById(id uint) error {
+	defer resetAcl()
+	if err := db.DeletePathRulesByGroupId(id); err != nil {
+		return err
+	}
+	return db.DeleteGroupById(id)
+}
+
+func GetPathRules(pageIndex, pageSize int) ([]model.PathRule, int64, error) {
+	return db.GetPathRules(pageIndex, pageSize)
+}
+
+func GetPathRuleById(id uint) (*model.PathRule, error) {
+	return db.GetPathRuleById(id)
+}
+
+func CreatePathRule(r *model.PathRule) error {
+	r.Path = utils.FixAndCleanPath(r.Path)
+	defer resetAcl()
+	return db.CreatePathRule(r)
+}
+
+func UpdatePathRule(r *model.PathRule) error {
+	r.Path = utils.FixAndCleanPath(r.Path)
+	if _, err := db.GetPathRuleById(r.ID); err != nil {
+		return err
+	}
+	defer resetAcl()
+	return db.UpdatePathRule(r)
+}
+
+func DeletePathRuleById(id uint) error {
+	defer resetAcl()
+	return db.DeletePathRuleById(id)
+}
@@ -30,6 +30,55 @@ func HandleObjsUpdateHook(ctx context.Context, parent string, objs []model.Obj)
 	}
 }
 
+// ObjEvent describes a change made by a write operation, the paths are mount paths
+type ObjEvent struct {
+	Type    string
+	Path    string
+	DstPath string // only for ObjEventMove, the new path of Path
+}
+
+const (
+	// ObjEventAdd means the object at Path was created or overwritten, a dir is created with its subtree
+	ObjEventAdd = "add"
+	// ObjEventDelete means the object at Path was removed with its subtree
+	ObjEventDelete = "delete"
+	// ObjEventMove means the object at Path was moved or renamed to DstPath
+	ObjEventMove = "move"
+)
+
+type ObjEventHook = func(ctx context.Context, event ObjEvent)
+
+var (
+	objEventHooks = make([]ObjEventHook, 0)
+)
+
+func RegisterObjEventHook(hook ObjEventHook) {
+	objEventHooks = append(objEventHooks, hook)
+}
+
+func HandleObjEventHook(ctx context.Context, event ObjEvent) {
+	for _, hook := range objEventHooks {
+		hook(ctx, event)
+	}
+}
+
+// emitObjEvent converts the actual paths of the storage to mount paths and handles the event,
+// the internal changes made with conf.SkipObjEventKey in ctx aren't events
+func emitObjEvent(ctx context.Context, storage driver.Driver, typ, path string, dstPath ...string) {
+	if len(objEventHooks) < 1 || ctx.Value(conf.SkipObjEventKey) != nil {
+		return
+	}
+	mountPath := storage.GetStorage().MountPath
+	event := ObjEvent{
+		Type: typ,
+		Path: utils.GetFullPath(mountPath, path),
+	}
+	if len(dstPath) > 0 {
+		event.DstPath = utils.GetFullPath(mountPath, dstPath[0])
+	}
+	HandleObjEventHook(ctx, event)
+}
+
 // Setting
 type SettingItemHook func(item *model.SettingItem) error
 
@@ -110,3 +159,18 @@ func callStorageHooks(typ string, storage driver.Driver) {
 func RegisterStorageHook(hook StorageHook) {
 	storageHooks = append(storageHooks, hook)
 }
+
+// StorageStatusHook is called when the status of the storage is changed from old
+type StorageStatusHook func(storage driver.Driver, old string)
+
+var storageStatusHooks = make([]StorageStatusHook, 0)
+
+func callStorageStatusHooks(storage driver.Driver, old string) {
+	for _, hook := range storageStatusHooks {
+		hook(storage, old)
+	}
+}
+
+func RegisterStorageStatusHook(hook StorageStatusHook) {
+	storageStatusHooks = append(storageStatusHooks, hook)
+}
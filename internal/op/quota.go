@@ -0,0 +1,245 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// quotas are checked on every write, so they are kept in memory,
+// the usage is updated in memory and in the database at the same time
+var (
+	quotaMu     sync.Mutex
+	quotaLoaded bool
+	quotas      []*model.Quota
+)
+
+func loadQuotas() error {
+	if quotaLoaded {
+		return nil
+	}
+	all, err := db.GetAllQuotas()
+	if err != nil {
+		return err
+	}
+	quotas = make([]*model.Quota, 0, len(all))
+	for i := range all {
+		quotas = append(quotas, &all[i])
+	}
+	quotaLoaded = true
+	return nil
+}
+
+func resetQuotas() {
+	quotaMu.Lock()
+	quotaLoaded = false
+	quotaMu.Unlock()
+}
+
+// CheckQuota returns errs.QuotaExceeded if adding size bytes and files files under path
+// goes beyond a quota that limits the user in ctx.
+// The quotas also containing from are skipped, as moving inside them doesn't change their usage.
+func CheckQuota(ctx context.Context, path string, size, files int64, from ...string) error {
+	if size <= 0 && files <= 0 {
+		return nil
+	}
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	quotaMu.Lock()
+	defer quotaMu.Unlock()
+	if err := loadQuotas(); err != nil {
+		return errors.WithMessage(err, "failed load quotas")
+	}
+	for _, q := range quotas {
+		if !q.AppliesTo(user) || !utils.IsSubPath(q.Path, path) {
+			continue
+		}
+		if len(from) > 0 && utils.IsSubPath(q.Path, from[0]) {
+			continue
+		}
+		if q.Exceeded(size, files) {
+			return errors.WithStack(errs.QuotaExceeded)
+		}
+	}
+	return nil
+}
+
+// AddQuotaUsage adds size bytes and files files to the usage of all the quotas containing path,
+// the quotas also containing from are skipped.
+func AddQuotaUsage(path string, size, files int64, from ...string) {
+	if size == 0 && files == 0 {
+		return
+	}
+	quotaMu.Lock()
+	defer quotaMu.Unlock()
+	if err := loadQuotas(); err != nil {
+		log.Errorf("failed load quotas: %+v", err)
+		return
+	}
+	for _, q := range quotas {
+		if !utils.IsSubPath(q.Path, path) {
+			continue
+		}
+		if len(from) > 0 && utils.IsSubPath(q.Path, from[0]) {
+			continue
+		}
+		q.UsedSize += size
+		q.UsedFiles += files
+		if err := db.AddQuotaUsage(q.ID, size, files); err != nil {
+			log.Errorf("failed update usage of quota [%s]: %+v", q.Path, err)
+		}
+	}
+}
+
+// checkPutQuota checks the quota for putting file to dstDirPath of the storage,
+// an existing file of the same name will be overwritten, so its size is deducted
+func checkPutQuota(ctx context.Context, storage driver.Driver, dstDirPath string, size int64, old model.Obj) (int64, int64, error) {
+	files := int64(1)
+	if old != nil && !old.IsDir() {
+		size -= old.GetSize()
+		files = 0
+	}
+	path := Key(storage, dstDirPath)
+	return size, files, CheckQuota(ctx, path, size, files)
+}
+
+// CheckPutQuota checks the quota before accepting an upload to dstDirActualPath of the storage,
+// it's used to reject an upload as early as possible, Put checks it again.
+func CheckPutQuota(ctx context.Context, storage driver.Driver, dstDirActualPath string, file model.FileStreamer) error {
+	old, _ := GetUnwrap(ctx, storage, stdpath.Join(dstDirActualPath, file.GetName()))
+	_, _, err := checkPutQuota(ctx, storage, dstDirActualPath, file.GetSize(), old)
+	return err
+}
+
+func GetQuotas(pageIndex, pageSize int) ([]model.Quota, int64, error) {
+	return db.GetQuotas(pageIndex, pageSize)
+}
+
+func GetQuotaById(id uint) (*model.Quota, error) {
+	return db.GetQuotaById(id)
+}
+
+func CreateQuota(q *model.Quota) error {
+	q.Path = utils.FixAndCleanPath(q.Path)
+	defer resetQuotas()
+	return db.CreateQuota(q)
+}
+
+func UpdateQuota(q *model.Quota) error {
+	q.Path = utils.FixAndCleanPath(q.Path)
+	if _, err := db.GetQuotaById(q.ID); err != nil {
+		return err
+	}
+	defer resetQuotas()
+	return db.UpdateQuota(q)
+}
+
+func DeleteQuotaById(id uint) error {
+	defer resetQuotas()
+	return db.DeleteQuotaById(id)
+}
+
+// ReconcileQuotas recounts the usage of all quotas by scanning their paths,
+// as the incremental usage misses the changes made outside or by moving directories
+func ReconcileQuotas(ctx context.Context) error {
+	all, err := db.GetAllQuotas()
+	if err != nil {
+		return err
+	}
+	for _, q := range all {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		if err := RecursivelyList(ctx, q.Path, 0, nil); err != nil {
+			log.Errorf("failed scan quota [%s]: %+v", q.Path, err)
+			continue
+		}
+		size, files, err := statTree(ctx, q.Path)
+		if err != nil {
+			log.Errorf("failed count usage of quota [%s]: %+v", q.Path, err)
+			continue
+		}
+		vSize, vFiles, err := versionsUsage(q.Path)
+		if err != nil {
+			log.Errorf("failed count versions usage of quota [%s]: %+v", q.Path, err)
+			continue
+		}
+		size, files = size+vSize, files+vFiles
+		if err = db.SetQuotaUsage(q.ID, size, files, time.Now()); err != nil {
+			log.Errorf("failed save usage of quota [%s]: %+v", q.Path, err)
+		}
+	}
+	resetQuotas()
+	return nil
+}
+
+// versionsUsage counts the versions of the files under path kept outside path,
+// they are charged to the quotas of the files
+func versionsUsage(path string) (size, files int64, err error) {
+	versions, err := db.GetFileVersionsUnder(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	mountPaths := make(map[uint]string)
+	for _, storage := range GetAllStorages() {
+		mountPaths[storage.GetStorage().ID] = storage.GetStorage().MountPath
+	}
+	for _, v := range versions {
+		mountPath, ok := mountPaths[v.VersionStorageId]
+		if !ok || utils.IsSubPath(path, utils.GetFullPath(mountPath, v.Dir)) {
+			continue
+		}
+		size, files = size+v.Size, files+1
+	}
+	return size, files, nil
+}
+
+// statTree counts the size and the number of files under the mount path
+func statTree(ctx context.Context, path string) (size, files int64, err error) {
+	storage, actualPath, err := GetStorageAndActualPath(path)
+	if err == nil {
+		return statStorageTree(ctx, storage, actualPath)
+	}
+	if !errors.Is(err, errs.StorageNotFound) {
+		return 0, 0, err
+	}
+	for _, obj := range GetStorageVirtualFilesByPath(path) {
+		s, f, err := statTree(ctx, stdpath.Join(path, obj.GetName()))
+		if err != nil {
+			return 0, 0, err
+		}
+		size, files = size+s, files+f
+	}
+	return size, files, nil
+}
+
+func statStorageTree(ctx context.Context, storage driver.Driver, actualPath string) (size, files int64, err error) {
+	objs, err := List(ctx, storage, actualPath, model.ListArgs{})
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, obj := range objs {
+		if utils.IsCanceled(ctx) {
+			return 0, 0, ctx.Err()
+		}
+		if !obj.IsDir() {
+			size, files = size+obj.GetSize(), files+1
+			continue
+		}
+		s, f, err := statStorageTree(ctx, storage, stdpath.Join(actualPath, obj.GetName()))
+		if err != nil {
+			return 0, 0, err
+		}
+		size, files = size+s, files+f
+	}
+	return size, files, nil
+}
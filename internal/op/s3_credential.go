@@ -0,0 +1,66 @@
+package op
+
+import (
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// s3AccessKeyPrefix tells the keys of users apart from the global key in the settings
+const s3AccessKeyPrefix = "OL"
+
+// CreateS3Credential generates the key pair of c and saves it, the secret can't be shown again later
+func CreateS3Credential(c *model.S3Credential) error {
+	c.AccessKeyId = s3AccessKeyPrefix + strings.ToUpper(random.String(18))
+	c.SecretAccessKey = random.String(40)
+	c.AddedTime = time.Now()
+	return db.CreateS3Credential(c)
+}
+
+func GetS3CredentialsByUserId(userId uint, pageIndex, pageSize int) ([]model.S3Credential, int64, error) {
+	return db.GetS3CredentialsByUserId(userId, pageIndex, pageSize)
+}
+
+func GetS3CredentialByIdAndUserId(id uint, userId uint) (*model.S3Credential, error) {
+	c, err := db.GetS3CredentialById(id)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserId != userId {
+		return nil, errors.New("failed get s3 credential")
+	}
+	return c, nil
+}
+
+func GetEnabledS3Credentials() ([]model.S3Credential, error) {
+	return db.GetEnabledS3Credentials()
+}
+
+func UpdateS3Credential(c *model.S3Credential) error {
+	return db.UpdateS3Credential(c)
+}
+
+func DeleteS3CredentialById(id uint) error {
+	return db.DeleteS3CredentialById(id)
+}
+
+// GetUserByS3AccessKey returns the enabled credential of the access key and its owner
+func GetUserByS3AccessKey(accessKeyId string) (*model.User, *model.S3Credential, error) {
+	c, err := db.GetS3CredentialByAccessKey(accessKeyId)
+	if err != nil || c.Disabled {
+		return nil, nil, errs.InvalidS3Key
+	}
+	user, err := GetUserById(c.UserId)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed get s3 credential owner")
+	}
+	if user.Disabled {
+		return nil, nil, errs.InvalidS3Key
+	}
+	return user, c, nil
+}
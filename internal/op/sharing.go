@@ -39,6 +39,23 @@ func makeJoined(sdb []model.SharingDB) []model.Sharing {
 	})
 }
 
+// ValidSharing checks the sharing is valid and its creator still has the permission to share its files
+func ValidSharing(s *model.Sharing) bool {
+	if !s.Valid() {
+		return false
+	}
+	if s.Creator.IsAdmin() {
+		return true
+	}
+	for _, f := range s.Files {
+		perm := GetUserPermission(s.Creator, f)
+		if !model.CanShare(perm) || (s.Upload && !model.CanWrite(perm)) {
+			return false
+		}
+	}
+	return true
+}
+
 var sharingCache = cache.NewMemCache(cache.WithShards[*model.Sharing](8))
 var sharingG singleflight.Group[*model.Sharing]
 
@@ -135,9 +152,15 @@ func UpdateSharing(sharing *model.Sharing, skipMarshal ...bool) (err error) {
 
 func DeleteSharing(sid string) error {
 	sharingCache.Del(sid)
+	if err := db.DeleteSharingAccesses(sid); err != nil {
+		return errors.WithMessage(err, "failed delete access logs of sharing")
+	}
 	return db.DeleteSharingById(sid)
 }
 
 func DeleteSharingsByCreatorId(creatorId uint) error {
+	if err := db.DeleteSharingAccessesByCreatorId(creatorId); err != nil {
+		return errors.WithMessage(err, "failed delete access logs of sharings")
+	}
 	return db.DeleteSharingsByCreatorId(creatorId)
 }
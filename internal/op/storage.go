@@ -26,6 +26,10 @@ import (
 // so it should actually be a storage, just wrapped by the driver
 var storagesMap generic_sync.MapOf[string, driver.Driver]
 
+// storagesMu serializes the changes of the loaded storages,
+// so a storage isn't reloaded while it's updated or deleted
+var storagesMu sync.Mutex
+
 func GetAllStorages() []driver.Driver {
 	return storagesMap.Values()
 }
@@ -96,8 +100,18 @@ func getCurrentGoroutineStack() string {
 
 // initStorage initialize the driver and store to storagesMap
 func initStorage(ctx context.Context, storage model.Storage, storageDriver driver.Driver) (err error) {
+	// the status before updating is kept by the driver, the loaded storages have it saved
+	oldStatus := storage.Status
+	if s := storageDriver.GetStorage(); s != nil && s.ID == storage.ID {
+		oldStatus = s.Status
+	}
 	storageDriver.SetStorage(storage)
 	driverStorage := storageDriver.GetStorage()
+	defer func() {
+		if driverStorage.Status != oldStatus {
+			go callStorageStatusHooks(storageDriver, oldStatus)
+		}
+	}()
 	defer func() {
 		if err := recover(); err != nil {
 			errInfo := fmt.Sprintf("[panic] err: %v\nstack: %s\n", err, getCurrentGoroutineStack())
@@ -169,6 +183,8 @@ func IsUseOnlineAPI(storageDriver driver.Driver) bool {
 }
 
 func EnableStorage(ctx context.Context, id uint) error {
+	storagesMu.Lock()
+	defer storagesMu.Unlock()
 	storage, err := db.GetStorageById(id)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -189,6 +205,8 @@ func EnableStorage(ctx context.Context, id uint) error {
 }
 
 func DisableStorage(ctx context.Context, id uint) error {
+	storagesMu.Lock()
+	defer storagesMu.Unlock()
 	storage, err := db.GetStorageById(id)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -220,6 +238,8 @@ func DisableStorage(ctx context.Context, id uint) error {
 // get old storage first
 // drop the storage then reinitialize
 func UpdateStorage(ctx context.Context, storage model.Storage) error {
+	storagesMu.Lock()
+	defer storagesMu.Unlock()
 	oldStorage, err := db.GetStorageById(storage.ID)
 	if err != nil {
 		return errors.WithMessage(err, "failed get old storage")
@@ -257,7 +277,38 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	return err
 }
 
+// ReloadStorage drops the storage and initializes it again with the same config,
+// e.g. to recover it from an expired token
+func ReloadStorage(ctx context.Context, storageDriver driver.Driver) error {
+	storagesMu.Lock()
+	defer storagesMu.Unlock()
+	mountPath := storageDriver.GetStorage().MountPath
+	if loaded, ok := storagesMap.Load(mountPath); !ok || loaded != storageDriver {
+		return errors.Errorf("storage %s has been changed or removed", mountPath)
+	}
+	if err := storageDriver.Drop(ctx); err != nil {
+		log.Warnf("failed drop storage %s: %+v", storageDriver.GetStorage().MountPath, err)
+	}
+	Cache.DeleteDirectoryTree(storageDriver, "/")
+	Cache.InvalidateStorageDetails(storageDriver)
+	return initStorage(ctx, *storageDriver.GetStorage(), storageDriver)
+}
+
+// SetStorageStatus saves the status of the storage, the status hooks are called if it's changed
+func SetStorageStatus(storageDriver driver.Driver, status string) {
+	s := storageDriver.GetStorage()
+	if s.Status == status {
+		return
+	}
+	old := s.Status
+	s.SetStatus(status)
+	MustSaveDriverStorage(storageDriver)
+	go callStorageStatusHooks(storageDriver, old)
+}
+
 func DeleteStorageById(ctx context.Context, id uint) error {
+	storagesMu.Lock()
+	defer storagesMu.Unlock()
 	storage, err := db.GetStorageById(id)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -282,6 +333,15 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	if err := db.DeleteTrashItemsByStorageId(id); err != nil {
+		return errors.WithMessage(err, "failed delete trash items of storage")
+	}
+	if err := db.DeleteFileVersionsByStorageId(id); err != nil {
+		return errors.WithMessage(err, "failed delete file versions of storage")
+	}
+	if err := db.DeleteStorageHealthsByStorageId(id); err != nil {
+		return errors.WithMessage(err, "failed delete health records of storage")
+	}
 	return dropErr
 }
 
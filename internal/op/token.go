@@ -0,0 +1,79 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// lastUsedInterval limits how often the last used time of a token is written to the db
+const lastUsedInterval = time.Minute
+
+// CreateApiToken saves the token and returns the plain token, which can't be recovered later
+func CreateApiToken(t *model.ApiToken) (string, error) {
+	if _, err := db.GetApiTokenByUserName(t.UserId, t.Name); err == nil {
+		return "", errors.New("token with the same name already exists")
+	}
+	token := model.ApiTokenPrefix + random.String(40)
+	t.TokenHash = model.HashApiToken(token)
+	t.Hint = token[:len(model.ApiTokenPrefix)+4]
+	t.BasePath = utils.FixAndCleanPath(t.BasePath)
+	t.AddedTime = time.Now()
+	return token, db.CreateApiToken(t)
+}
+
+func GetApiTokensByUserId(userId uint, pageIndex, pageSize int) ([]model.ApiToken, int64, error) {
+	return db.GetApiTokensByUserId(userId, pageIndex, pageSize)
+}
+
+func GetApiTokenByIdAndUserId(id uint, userId uint) (*model.ApiToken, error) {
+	t, err := db.GetApiTokenById(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.UserId != userId {
+		return nil, errors.New("failed get token")
+	}
+	return t, nil
+}
+
+func UpdateApiToken(t *model.ApiToken) error {
+	t.BasePath = utils.FixAndCleanPath(t.BasePath)
+	return db.UpdateApiToken(t)
+}
+
+func DeleteApiTokenById(id uint) error {
+	return db.DeleteApiTokenById(id)
+}
+
+// GetUserByApiToken validates the token and returns its owner scoped by the token
+func GetUserByApiToken(token string) (*model.User, *model.ApiToken, error) {
+	t, err := db.GetApiTokenByHash(model.HashApiToken(token))
+	if err != nil {
+		return nil, nil, errs.InvalidApiToken
+	}
+	if t.Expired() {
+		return nil, nil, errs.ApiTokenExpired
+	}
+	owner, err := GetUserById(t.UserId)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed get token owner")
+	}
+	user, err := t.ScopeUser(owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	if now := time.Now(); now.Sub(t.LastUsedTime) > lastUsedInterval {
+		t.LastUsedTime = now
+		if err := db.UpdateApiToken(t); err != nil {
+			log.Warnf("failed update last used time of token [%d]: %+v", t.ID, err)
+		}
+	}
+	return user, t, nil
+}
@@ -82,6 +82,23 @@ func DeleteUserById(id uint) error {
 	if err := DeleteSharingsByCreatorId(id); err != nil {
 		return errors.WithMessage(err, "failed to delete user's sharings")
 	}
+	if err := db.DeleteApiTokensByUserId(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's api tokens")
+	}
+	if err := db.DeleteS3CredentialsByUserId(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's s3 credentials")
+	}
+	if err := db.DeleteNotifySubscriptionsByUserId(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's notify subscriptions")
+	}
+	if err := db.DeletePathRulesByUserId(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's path rules")
+	}
+	resetAcl()
+	if err := db.DeleteQuotasByUserId(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's quotas")
+	}
+	resetQuotas()
 	return db.DeleteUserById(id)
 }
 
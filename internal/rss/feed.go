@@ -0,0 +1,93 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html/charset"
+)
+
+// Item is an entry of an RSS or Atom feed
+type Item struct {
+	Guid  string
+	Title string
+	// the url to download, the enclosure is preferred to the link,
+	// since the torrent feeds put the .torrent files or the magnets there
+	Url string
+}
+
+type rssItem struct {
+	Title     string `xml:"title"`
+	Link      string `xml:"link"`
+	Guid      string `xml:"guid"`
+	Enclosure struct {
+		Url string `xml:"url,attr"`
+	} `xml:"enclosure"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	Id    string `xml:"id"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+}
+
+// feed matches RSS 2.0, whose items are in the channel, RSS 1.0, whose items are
+// the children of the root, and Atom
+type feed struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// parseFeed returns the items of the feed in the order of the document, usually the newest first
+func parseFeed(data []byte) ([]Item, error) {
+	var f feed
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.CharsetReader = charset.NewReaderLabel
+	// the feeds in the wild are not always well-formed
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	if err := d.Decode(&f); err != nil {
+		return nil, errors.Wrap(err, "failed to parse feed")
+	}
+	var items []Item
+	for _, it := range append(f.Channel.Items, f.Items...) {
+		items = append(items, newItem(it.Guid, it.Title, it.Enclosure.Url, it.Link))
+	}
+	for _, e := range f.Entries {
+		var enclosure, link string
+		for _, l := range e.Links {
+			switch l.Rel {
+			case "enclosure":
+				enclosure = l.Href
+			case "", "alternate":
+				link = l.Href
+			}
+		}
+		items = append(items, newItem(e.Id, e.Title, enclosure, link))
+	}
+	return items, nil
+}
+
+func newItem(guid, title, enclosure, link string) Item {
+	item := Item{
+		Title: strings.TrimSpace(title),
+		Url:   strings.TrimSpace(enclosure),
+	}
+	if item.Url == "" {
+		item.Url = strings.TrimSpace(link)
+	}
+	// the url identifies the item if the feed has no guids
+	item.Guid = strings.TrimSpace(guid)
+	if item.Guid == "" {
+		item.Guid = item.Url
+	}
+	return item
+}
@@ -0,0 +1,46 @@
+package rss
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Item
+	}{
+		{"rss 2.0", `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>t</title>
+<item><title>Show 02 &amp; more</title><link>https://example.com/2</link><guid>g2</guid>
+<enclosure url="magnet:?xt=urn:btih:0000000000000000000000000000000000000002" type="application/x-bittorrent"/></item>
+<item><title> Show 01 </title><link>https://example.com/1.mkv</link></item>
+</channel></rss>`, []Item{
+			{Guid: "g2", Title: "Show 02 & more", Url: "magnet:?xt=urn:btih:0000000000000000000000000000000000000002"},
+			{Guid: "https://example.com/1.mkv", Title: "Show 01", Url: "https://example.com/1.mkv"},
+		}},
+		{"rss 1.0", `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+<channel><title>t</title></channel>
+<item><title>a&nbsp;b</title><link>https://example.com/a</link></item>
+</rdf:RDF>`, []Item{
+			{Guid: "https://example.com/a", Title: "a b", Url: "https://example.com/a"},
+		}},
+		{"atom", `<feed xmlns="http://www.w3.org/2005/Atom"><title>t</title>
+<entry><title>e1</title><id>urn:e1</id><link href="https://example.com/e1"/><link rel="enclosure" href="https://example.com/e1.torrent"/></entry>
+<entry><title>e2</title><id>urn:e2</id><link rel="alternate" href="https://example.com/e2"/></entry>
+</feed>`, []Item{
+			{Guid: "urn:e1", Title: "e1", Url: "https://example.com/e1.torrent"},
+			{Guid: "urn:e2", Title: "e2", Url: "https://example.com/e2"},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := parseFeed([]byte(tt.data))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
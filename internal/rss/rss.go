@@ -0,0 +1,280 @@
+package rss
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// in minutes
+	defaultInterval = 30
+	fetchTimeout    = time.Minute
+	concurrency     = 4
+)
+
+var (
+	mu       sync.Mutex
+	checking = map[uint]struct{}{}
+)
+
+// CheckDue checks the enabled subscriptions whose last check is older than their interval
+func CheckDue(ctx context.Context) {
+	subs, err := db.GetAllRssSubscriptions()
+	if err != nil {
+		log.Errorf("failed get rss subscriptions: %+v", err)
+		return
+	}
+	// a slow feed doesn't delay the others
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i := range subs {
+		sub := &subs[i]
+		if sub.Disabled {
+			continue
+		}
+		if sub.LastCheck != nil && time.Since(*sub.LastCheck) < time.Duration(sub.Interval)*time.Minute {
+			continue
+		}
+		g.Go(func() error {
+			if _, err := Check(ctx, sub); err != nil {
+				log.Warnf("failed check rss subscription [%s]: %+v", sub.Name, err)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// Check adds the new items of the feed matched by the rules as offline download tasks,
+// and returns the count of them. Only the added items and the ones in the feed when subscribed
+// are remembered, so the items skipped by the rules are added if the rules are changed
+// to match them later, and the failed ones are retried.
+func Check(ctx context.Context, sub *model.RssSubscription) (int, error) {
+	mu.Lock()
+	if _, ok := checking[sub.ID]; ok {
+		mu.Unlock()
+		return 0, errors.New("the subscription is being checked")
+	}
+	checking[sub.ID] = struct{}{}
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		delete(checking, sub.ID)
+		mu.Unlock()
+	}()
+
+	added, err := check(ctx, sub)
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if err := db.SetRssSubscriptionCheck(sub.ID, time.Now(), errMsg); err != nil {
+		log.Errorf("failed update rss subscription %d: %+v", sub.ID, err)
+	}
+	return added, err
+}
+
+func check(ctx context.Context, sub *model.RssSubscription) (int, error) {
+	include, exclude, err := compileRules(sub)
+	if err != nil {
+		return 0, err
+	}
+	items, err := fetch(ctx, sub.Url)
+	if err != nil {
+		return 0, err
+	}
+	seen, err := db.GetRssItemGuids(sub.ID)
+	if err != nil {
+		return 0, err
+	}
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed get admin")
+	}
+	// the tasks are created by admin
+	ctx = context.WithValue(ctx, conf.UserKey, admin)
+	ctx = context.WithValue(ctx, conf.ApiUrlKey, common.GetApiUrlFromRequest(nil))
+	var (
+		added  int
+		failed []string
+	)
+	// the feeds list the newest items first, the oldest ones are added first
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if item.Url == "" {
+			continue
+		}
+		if _, ok := seen[item.Guid]; ok {
+			continue
+		}
+		if (include != nil && !include.MatchString(item.Title)) || (exclude != nil && exclude.MatchString(item.Title)) {
+			continue
+		}
+		_, err := tool.AddURL(ctx, &tool.AddURLArgs{
+			URL:          item.Url,
+			DstDirPath:   sub.DstPath,
+			Tool:         sub.Tool,
+			DeletePolicy: tool.DeletePolicy(sub.DeletePolicy),
+		})
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", item.Title, err))
+			continue
+		}
+		seen[item.Guid] = struct{}{}
+		added++
+		err = db.CreateRssItem(&model.RssItem{
+			SubscriptionId: sub.ID,
+			Guid:           item.Guid,
+			Title:          item.Title,
+			Url:            item.Url,
+			Time:           time.Now(),
+		})
+		if err != nil {
+			log.Errorf("failed save rss item [%s]: %+v", item.Title, err)
+		}
+	}
+	if len(failed) > 0 {
+		// one error per line
+		return added, errors.Errorf("failed add %d items:\n%s", len(failed), strings.Join(failed, "\n"))
+	}
+	return added, nil
+}
+
+func fetch(ctx context.Context, feedUrl string) ([]Item, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	res, err := base.RestyClient.R().SetContext(ctx).Get(feedUrl)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get feed")
+	}
+	if res.IsError() {
+		return nil, errors.Errorf("failed to get feed: %s", res.Status())
+	}
+	return parseFeed(res.Body())
+}
+
+func compileRules(sub *model.RssSubscription) (include, exclude *regexp.Regexp, err error) {
+	if sub.Include != "" {
+		if include, err = regexp.Compile(sub.Include); err != nil {
+			return nil, nil, errors.Wrap(err, "invalid include rule")
+		}
+	}
+	if sub.Exclude != "" {
+		if exclude, err = regexp.Compile(sub.Exclude); err != nil {
+			return nil, nil, errors.Wrap(err, "invalid exclude rule")
+		}
+	}
+	return include, exclude, nil
+}
+
+func validate(sub *model.RssSubscription) error {
+	u, err := url.Parse(sub.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.Errorf("invalid feed url: %s", sub.Url)
+	}
+	if _, _, err = compileRules(sub); err != nil {
+		return err
+	}
+	if _, err = tool.Tools.Get(sub.Tool); err != nil {
+		return errors.WithStack(err)
+	}
+	switch tool.DeletePolicy(sub.DeletePolicy) {
+	case "":
+		sub.DeletePolicy = string(tool.DeleteOnUploadSucceed)
+	case tool.DeleteOnUploadSucceed, tool.DeleteOnUploadFailed, tool.DeleteNever, tool.DeleteAlways, tool.UploadDownloadStream:
+	default:
+		return errors.Errorf("invalid delete policy: %s", sub.DeletePolicy)
+	}
+	if sub.Interval <= 0 {
+		sub.Interval = defaultInterval
+	}
+	sub.DstPath = utils.FixAndCleanPath(sub.DstPath)
+	return nil
+}
+
+// skipExisting remembers the items in the feed as skipped,
+// so a new subscription doesn't add the whole feed at once
+func skipExisting(sub *model.RssSubscription, items []Item) error {
+	seen, err := db.GetRssItemGuids(sub.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var skipped []model.RssItem
+	for _, item := range items {
+		if _, ok := seen[item.Guid]; ok || item.Url == "" {
+			continue
+		}
+		seen[item.Guid] = struct{}{}
+		skipped = append(skipped, model.RssItem{
+			SubscriptionId: sub.ID,
+			Guid:           item.Guid,
+			Title:          item.Title,
+			Url:            item.Url,
+			Time:           now,
+			Skipped:        true,
+		})
+	}
+	return db.CreateRssItems(skipped)
+}
+
+func CreateSubscription(ctx context.Context, sub *model.RssSubscription) error {
+	if err := validate(sub); err != nil {
+		return err
+	}
+	var items []Item
+	if !sub.DownloadExisting {
+		var err error
+		if items, err = fetch(ctx, sub.Url); err != nil {
+			return err
+		}
+	}
+	if err := db.CreateRssSubscription(sub); err != nil {
+		return err
+	}
+	return skipExisting(sub, items)
+}
+
+func UpdateSubscription(ctx context.Context, sub *model.RssSubscription) error {
+	old, err := db.GetRssSubscriptionById(sub.ID)
+	if err != nil {
+		return err
+	}
+	if err = validate(sub); err != nil {
+		return err
+	}
+	// a changed url is a new feed
+	var items []Item
+	if old.Url != sub.Url && !sub.DownloadExisting {
+		if items, err = fetch(ctx, sub.Url); err != nil {
+			return err
+		}
+	}
+	sub.LastCheck = old.LastCheck
+	sub.LastError = old.LastError
+	if err = db.UpdateRssSubscription(sub); err != nil {
+		return err
+	}
+	return skipExisting(sub, items)
+}
+
+func DeleteSubscription(id uint) error {
+	return db.DeleteRssSubscriptionById(id)
+}
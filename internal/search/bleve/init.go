@@ -4,6 +4,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -11,25 +12,47 @@ var config = searcher.Config{
 	Name: "bleve",
 }
 
+// the index of the version identifies the documents by their paths and doesn't analyze their parents,
+// so that the nodes can be got and deleted, which the indexes of old versions can't until they are rebuilt
+const (
+	indexVersionKey = "openlist_index_version"
+	indexVersion    = "2"
+)
+
+func newIndexMapping() *mapping.IndexMappingImpl {
+	indexMapping := bleve.NewIndexMapping()
+	searchNodeMapping := bleve.NewDocumentMapping()
+	searchNodeMapping.AddFieldMappingsAt("is_dir", bleve.NewBooleanFieldMapping())
+	// the parent is matched as a whole to get and delete the nodes
+	searchNodeMapping.AddFieldMappingsAt("parent", bleve.NewKeywordFieldMapping())
+	// TODO: appoint analyzer
+	nameFieldMapping := bleve.NewKeywordFieldMapping()
+	searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping)
+	searchNodeMapping.AddFieldMappingsAt("size", bleve.NewNumericFieldMapping())
+	searchNodeMapping.AddFieldMappingsAt("modified", bleve.NewDateTimeFieldMapping())
+	searchNodeMapping.AddFieldMappingsAt("obj_type", bleve.NewNumericFieldMapping())
+	searchNodeMapping.AddFieldMappingsAt("storage", bleve.NewKeywordFieldMapping())
+	searchNodeMapping.AddFieldMappingsAt("driver", bleve.NewKeywordFieldMapping())
+	indexMapping.AddDocumentMapping("SearchNode", searchNodeMapping)
+	// the nodes are indexed by value, which is not the Classifier implemented by the pointer,
+	// so they are of the default mapping
+	indexMapping.DefaultMapping.AddFieldMappingsAt("parent", bleve.NewKeywordFieldMapping())
+	return indexMapping
+}
+
 func Init(indexPath *string) (bleve.Index, error) {
 	log.Debugf("bleve path: %s", *indexPath)
 	fileIndex, err := bleve.Open(*indexPath)
 	if err == bleve.ErrorIndexPathDoesNotExist {
 		log.Infof("Creating new index...")
-		indexMapping := bleve.NewIndexMapping()
-		searchNodeMapping := bleve.NewDocumentMapping()
-		searchNodeMapping.AddFieldMappingsAt("is_dir", bleve.NewBooleanFieldMapping())
-		// TODO: appoint analyzer
-		parentFieldMapping := bleve.NewTextFieldMapping()
-		searchNodeMapping.AddFieldMappingsAt("parent", parentFieldMapping)
-		// TODO: appoint analyzer
-		nameFieldMapping := bleve.NewKeywordFieldMapping()
-		searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping)
-		indexMapping.AddDocumentMapping("SearchNode", searchNodeMapping)
-		fileIndex, err = bleve.New(*indexPath, indexMapping)
+		fileIndex, err = bleve.New(*indexPath, newIndexMapping())
 		if err != nil {
 			return nil, err
 		}
+		if err = fileIndex.SetInternal([]byte(indexVersionKey), []byte(indexVersion)); err != nil {
+			_ = fileIndex.Close()
+			return nil, err
+		}
 	} else if err != nil {
 		return nil, err
 	}
@@ -42,6 +65,15 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
-		return &Bleve{BIndex: b}, nil
+		return newBleve(b), nil
 	})
 }
+
+func newBleve(index bleve.Index) *Bleve {
+	v, _ := index.GetInternal([]byte(indexVersionKey))
+	b := &Bleve{BIndex: index, updatable: string(v) == indexVersion}
+	if !b.updatable {
+		log.Warnf("the bleve index is built by an old version, rebuild it to update it automatically")
+	}
+	return b
+}
@@ -3,6 +3,9 @@ package bleve
 import (
 	"context"
 	"os"
+	"path"
+	"strings"
+	"time"
 
 	query2 "github.com/blevesearch/bleve/v2/search/query"
 
@@ -17,27 +20,46 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// the count of the nodes got by a search of Get and Del
+const pageSize = 1000
+
 type Bleve struct {
 	BIndex bleve.Index
+	// whether the nodes can be got and deleted, see indexVersion
+	updatable bool
 }
 
 func (b *Bleve) Config() searcher.Config {
-	return config
+	c := config
+	c.AutoUpdate = b.updatable
+	return c
 }
 
 func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	var queries []query2.Query
-	query := bleve.NewMatchQuery(req.Keywords)
-	query.SetField("name")
-	queries = append(queries, query)
+	if req.Keywords != "" {
+		query := bleve.NewMatchQuery(req.Keywords)
+		query.SetField("name")
+		queries = append(queries, query)
+	} else {
+		queries = append(queries, bleve.NewMatchAllQuery())
+	}
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
 		queries = append(queries, isDirQuery)
 	}
+	queries = append(queries, filterQueries(req)...)
 	reqQuery := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(reqQuery)
-	search.SortBy([]string{"name"})
+	orderBy := "name"
+	if req.OrderBy != "" {
+		orderBy = req.OrderBy
+	}
+	if req.OrderDirection == "desc" {
+		orderBy = "-" + orderBy
+	}
+	search.SortBy([]string{orderBy})
 	search.From = (req.Page - 1) * req.PerPage
 	search.Size = req.PerPage
 	search.Fields = []string{"*"}
@@ -46,35 +68,149 @@ func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.Search
 		log.Errorf("search error: %+v", err)
 		return nil, 0, err
 	}
-	res, err := utils.SliceConvert(searchResults.Hits, func(src *search2.DocumentMatch) (model.SearchNode, error) {
-		return model.SearchNode{
-			Parent: src.Fields["parent"].(string),
-			Name:   src.Fields["name"].(string),
-			IsDir:  src.Fields["is_dir"].(bool),
-			Size:   int64(src.Fields["size"].(float64)),
-		}, nil
-	})
+	res, err := utils.SliceConvert(searchResults.Hits, toNode)
 	return res, int64(searchResults.Total), nil
 }
 
+func toNode(src *search2.DocumentMatch) (model.SearchNode, error) {
+	node := model.SearchNode{
+		Parent: src.Fields["parent"].(string),
+		Name:   src.Fields["name"].(string),
+		IsDir:  src.Fields["is_dir"].(bool),
+		Size:   int64(src.Fields["size"].(float64)),
+	}
+	// the fields below are missing in the index built by old versions
+	if modified, ok := src.Fields["modified"].(string); ok {
+		node.Modified, _ = time.Parse(time.RFC3339, modified)
+	}
+	if objType, ok := src.Fields["obj_type"].(float64); ok {
+		node.ObjType = int(objType)
+	}
+	node.HashInfo, _ = src.Fields["hashinfo"].(string)
+	node.Storage, _ = src.Fields["storage"].(string)
+	node.Driver, _ = src.Fields["driver"].(string)
+	return node, nil
+}
+
+func filterQueries(req model.SearchReq) []query2.Query {
+	var queries []query2.Query
+	inclusive := true
+	if req.MinSize > 0 || req.MaxSize > 0 {
+		var minSize, maxSize *float64
+		if req.MinSize > 0 {
+			v := float64(req.MinSize)
+			minSize = &v
+		}
+		if req.MaxSize > 0 {
+			v := float64(req.MaxSize)
+			maxSize = &v
+		}
+		query := bleve.NewNumericRangeInclusiveQuery(minSize, maxSize, &inclusive, &inclusive)
+		query.SetField("size")
+		queries = append(queries, query)
+	}
+	if req.ModifiedAfter != nil || req.ModifiedBefore != nil {
+		var start, end time.Time
+		if req.ModifiedAfter != nil {
+			start = *req.ModifiedAfter
+		}
+		if req.ModifiedBefore != nil {
+			end = *req.ModifiedBefore
+		}
+		query := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &inclusive)
+		query.SetField("modified")
+		queries = append(queries, query)
+	}
+	if len(req.Types) > 0 {
+		typeQueries := make([]query2.Query, 0, len(req.Types))
+		for _, t := range req.Types {
+			v := float64(t)
+			query := bleve.NewNumericRangeInclusiveQuery(&v, &v, &inclusive, &inclusive)
+			query.SetField("obj_type")
+			typeQueries = append(typeQueries, query)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(typeQueries...))
+	}
+	if req.Storage != "" {
+		query := bleve.NewTermQuery(utils.FixAndCleanPath(req.Storage))
+		query.SetField("storage")
+		queries = append(queries, query)
+	}
+	return queries
+}
+
+func (b *Bleve) docID(node model.SearchNode) string {
+	if b.updatable {
+		return path.Join(node.Parent, node.Name)
+	}
+	return uuid.NewString()
+}
+
 func (b *Bleve) Index(ctx context.Context, node model.SearchNode) error {
-	return b.BIndex.Index(uuid.NewString(), node)
+	return b.BIndex.Index(b.docID(node), node)
 }
 
 func (b *Bleve) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
 	batch := b.BIndex.NewBatch()
 	for _, node := range nodes {
-		batch.Index(uuid.NewString(), node)
+		batch.Index(b.docID(node), node)
 	}
 	return b.BIndex.Batch(batch)
 }
 
 func (b *Bleve) Get(ctx context.Context, parent string) ([]model.SearchNode, error) {
-	return nil, errs.NotSupport
+	if !b.updatable {
+		return nil, errs.NotSupport
+	}
+	query := bleve.NewTermQuery(parent)
+	query.SetField("parent")
+	var nodes []model.SearchNode
+	for from := 0; ; from += pageSize {
+		search := bleve.NewSearchRequestOptions(query, pageSize, from, false)
+		search.Fields = []string{"*"}
+		res, err := b.BIndex.SearchInContext(ctx, search)
+		if err != nil {
+			return nil, err
+		}
+		for _, hit := range res.Hits {
+			node, _ := toNode(hit)
+			nodes = append(nodes, node)
+		}
+		if len(res.Hits) < pageSize {
+			return nodes, nil
+		}
+	}
 }
 
+// Del deletes the node at prefix and the nodes in it
 func (b *Bleve) Del(ctx context.Context, prefix string) error {
-	return errs.NotSupport
+	if !b.updatable {
+		return errs.NotSupport
+	}
+	if err := b.BIndex.Delete(prefix); err != nil {
+		return err
+	}
+	inDir := bleve.NewTermQuery(prefix)
+	inDir.SetField("parent")
+	inSubDir := bleve.NewPrefixQuery(strings.TrimSuffix(prefix, "/") + "/")
+	inSubDir.SetField("parent")
+	query := bleve.NewDisjunctionQuery(inDir, inSubDir)
+	for {
+		res, err := b.BIndex.SearchInContext(ctx, bleve.NewSearchRequestOptions(query, pageSize, 0, false))
+		if err != nil {
+			return err
+		}
+		if len(res.Hits) == 0 {
+			return nil
+		}
+		batch := b.BIndex.NewBatch()
+		for _, hit := range res.Hits {
+			batch.Delete(hit.ID)
+		}
+		if err = b.BIndex.Batch(batch); err != nil {
+			return err
+		}
+	}
 }
 
 func (b *Bleve) Release(ctx context.Context) error {
@@ -98,7 +234,7 @@ func (b *Bleve) Clear(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	b.BIndex = bIndex
+	*b = *newBleve(bIndex)
 	return nil
 }
 
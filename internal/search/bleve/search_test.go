@@ -0,0 +1,57 @@
+package bleve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/blevesearch/bleve/v2"
+)
+
+func TestGetDel(t *testing.T) {
+	index, err := bleve.NewMemOnly(newIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = index.SetInternal([]byte(indexVersionKey), []byte(indexVersion)); err != nil {
+		t.Fatal(err)
+	}
+	b := newBleve(index)
+	defer b.Release(context.Background())
+	ctx := context.Background()
+	err = b.BatchIndex(ctx, []model.SearchNode{
+		{Parent: "/", Name: "a b", IsDir: true},
+		{Parent: "/a b", Name: "c.txt"},
+		{Parent: "/a b", Name: "d", IsDir: true},
+		{Parent: "/a b/d", Name: "e.txt"},
+		{Parent: "/", Name: "a bc", IsDir: true},
+		{Parent: "/a bc", Name: "f.txt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := b.Get(ctx, "/a b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 {
+		t.Errorf("expected 2 nodes in /a b, got %+v", nodes)
+	}
+	if err = b.Del(ctx, "/a b"); err != nil {
+		t.Fatal(err)
+	}
+	count, err := b.BIndex.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 nodes left, got %d", count)
+	}
+	nodes, err = b.Get(ctx, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].Name != "a bc" {
+		t.Errorf("expected only /a bc left in /, got %+v", nodes)
+	}
+}
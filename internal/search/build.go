@@ -199,20 +199,22 @@ func Config(ctx context.Context) searcher.Config {
 	return instance.Config()
 }
 
-func Update(ctx context.Context, parent string, objs []model.Obj) {
+// canAutoUpdate reports whether the index should follow the changes of the file system
+func canAutoUpdate() bool {
 	if instance == nil || !instance.Config().AutoUpdate || !setting.GetBool(conf.AutoUpdateIndex) || Running() {
-		return
-	}
-	if isIgnorePath(parent) {
-		return
+		return false
 	}
 	// only update when index have built
 	progress, err := Progress()
 	if err != nil {
 		log.Errorf("update search index error while get progress: %+v", err)
-		return
+		return false
 	}
-	if !progress.IsDone {
+	return progress.IsDone
+}
+
+func Update(ctx context.Context, parent string, objs []model.Obj) {
+	if isIgnorePath(parent) || !canAutoUpdate() {
 		return
 	}
 
@@ -274,4 +276,5 @@ func Update(ctx context.Context, parent string, objs []model.Obj) {
 
 func init() {
 	op.RegisterObjsUpdateHook(Update)
+	op.RegisterObjEventHook(HandleObjEvent)
 }
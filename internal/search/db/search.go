@@ -34,6 +34,10 @@ func (D DB) Del(ctx context.Context, path string) error {
 	return db.DeleteSearchNodesByParent(path)
 }
 
+func (D DB) Rename(ctx context.Context, src, dst string) error {
+	return db.RenameSearchNodes(src, dst)
+}
+
 func (D DB) Release(ctx context.Context) error {
 	return nil
 }
@@ -43,3 +47,4 @@ func (D DB) Clear(ctx context.Context) error {
 }
 
 var _ searcher.Searcher = (*DB)(nil)
+var _ searcher.Renamer = (*DB)(nil)
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// the events of write operations are applied by a single worker in the order they happened,
+// so that e.g. a put followed by a rename of the same file ends up indexed under the new name
+var (
+	eventMu     sync.Mutex
+	eventQueue  []op.ObjEvent
+	eventNotify = make(chan struct{}, 1)
+	eventOnce   sync.Once
+)
+
+// HandleObjEvent enqueues an event of a write operation to update the index
+func HandleObjEvent(ctx context.Context, event op.ObjEvent) {
+	if isIgnorePath(event.Path) && (event.Type != op.ObjEventMove || isIgnorePath(event.DstPath)) {
+		return
+	}
+	if !canAutoUpdate() {
+		return
+	}
+	eventOnce.Do(func() {
+		go eventWorker()
+	})
+	eventMu.Lock()
+	eventQueue = append(eventQueue, event)
+	eventMu.Unlock()
+	select {
+	case eventNotify <- struct{}{}:
+	default:
+	}
+}
+
+func eventWorker() {
+	for range eventNotify {
+		for {
+			eventMu.Lock()
+			events := eventQueue
+			eventQueue = nil
+			eventMu.Unlock()
+			if len(events) == 0 {
+				break
+			}
+			log.Debugf("applying %d index events", len(events))
+			for _, event := range events {
+				if err := applyObjEvent(context.Background(), event); err != nil {
+					log.Errorf("failed apply index event %+v: %+v", event, err)
+				}
+			}
+		}
+	}
+}
+
+func applyObjEvent(ctx context.Context, event op.ObjEvent) error {
+	// the searcher may have been changed or the index is being rebuilt
+	if !canAutoUpdate() {
+		return nil
+	}
+	switch event.Type {
+	case op.ObjEventAdd:
+		return addIndex(ctx, event.Path)
+	case op.ObjEventDelete:
+		return instance.Del(ctx, event.Path)
+	case op.ObjEventMove:
+		if isIgnorePath(event.Path) {
+			return addIndex(ctx, event.DstPath)
+		}
+		if !indexable(event.DstPath) {
+			return instance.Del(ctx, event.Path)
+		}
+		if r, ok := instance.(searcher.Renamer); ok {
+			return r.Rename(ctx, event.Path, event.DstPath)
+		}
+		if err := instance.Del(ctx, event.Path); err != nil {
+			return err
+		}
+		return addIndex(ctx, event.DstPath)
+	}
+	return nil
+}
+
+// addIndex (re)indexes the object at path, with its subtree if it's a dir
+func addIndex(ctx context.Context, p string) error {
+	if !indexable(p) {
+		return nil
+	}
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, conf.UserKey, admin)
+	obj, err := fs.Get(ctx, p, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	// replace the old nodes, e.g. of an overwritten file
+	if err = instance.Del(ctx, p); err != nil {
+		return err
+	}
+	var objs []ObjWithParent
+	err = fs.WalkFS(ctx, -1, p, obj, func(reqPath string, info model.Obj) error {
+		if !indexable(reqPath) {
+			return filepath.SkipDir
+		}
+		objs = append(objs, ObjWithParent{
+			Parent: path.Dir(reqPath),
+			Obj:    info,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return BatchIndex(ctx, objs)
+}
+
+// indexable reports whether the path should be in the index,
+// the paths ignored by settings, of storages with index disabled, in recycle bins and in versions directories are not
+func indexable(p string) bool {
+	if p == "/" || isIgnorePath(p) {
+		return false
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(p)
+	if err != nil {
+		return true
+	}
+	s := storage.GetStorage()
+	if s.DisableIndex {
+		return false
+	}
+	if s.TrashEnabled && utils.IsSubPath(s.GetTrashPath(), actualPath) {
+		return false
+	}
+	return !fs.IsVersionsPath(storage, actualPath)
+}
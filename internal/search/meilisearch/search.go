@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +26,8 @@ type searchDocument struct {
 	// Can be used for filtering all descendants exactly.
 	// Storing path hashes instead of plaintext paths benefits disk usage and case-sensitive filter.
 	ParentPathHashes []string `json:"parent_path_hashes"`
+	// Modified in unix seconds, meilisearch can only filter and sort numbers.
+	ModifiedUnix int64 `json:"modified_unix"`
 	model.SearchNode
 }
 
@@ -33,6 +36,7 @@ type Meilisearch struct {
 	IndexUid             string
 	FilterableAttributes []string
 	SearchableAttributes []string
+	SortableAttributes   []string
 	taskQueue            *TaskQueueManager
 }
 
@@ -55,22 +59,48 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		parentHash := hashPath(req.Parent)
 		filters = append(filters, fmt.Sprintf("parent_path_hashes = '%s'", parentHash))
 	}
+	if req.MinSize > 0 {
+		filters = append(filters, fmt.Sprintf("size >= %d", req.MinSize))
+	}
+	if req.MaxSize > 0 {
+		filters = append(filters, fmt.Sprintf("size <= %d", req.MaxSize))
+	}
+	if req.ModifiedAfter != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix >= %d", req.ModifiedAfter.Unix()))
+	}
+	if req.ModifiedBefore != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix <= %d", req.ModifiedBefore.Unix()))
+	}
+	if len(req.Types) > 0 {
+		types := make([]string, 0, len(req.Types))
+		for _, t := range req.Types {
+			types = append(types, strconv.Itoa(t))
+		}
+		filters = append(filters, fmt.Sprintf("obj_type IN [%s]", strings.Join(types, ", ")))
+	}
+	if req.Storage != "" {
+		filters = append(filters, fmt.Sprintf("storage = '%s'", strings.ReplaceAll(utils.FixAndCleanPath(req.Storage), "'", "\\'")))
+	}
 	if len(filters) > 0 {
 		mReq.Filter = strings.Join(filters, " AND ")
 	}
+	if req.OrderBy != "" {
+		orderBy, direction := req.OrderBy, "asc"
+		if orderBy == "modified" {
+			orderBy = "modified_unix"
+		}
+		if req.OrderDirection == "desc" {
+			direction = "desc"
+		}
+		mReq.Sort = []string{orderBy + ":" + direction}
+	}
 
 	search, err := m.Client.Index(m.IndexUid).SearchWithContext(ctx, req.Keywords, mReq)
 	if err != nil {
 		return nil, 0, err
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
-		srcMap := src.(map[string]any)
-		return model.SearchNode{
-			Parent: srcMap["parent"].(string),
-			Name:   srcMap["name"].(string),
-			IsDir:  srcMap["is_dir"].(bool),
-			Size:   int64(srcMap["size"].(float64)),
-		}, nil
+		return buildSearchDocumentFromResults(src.(map[string]any)).SearchNode, nil
 	})
 	if err != nil {
 		return nil, 0, err
@@ -99,6 +129,7 @@ func (m *Meilisearch) BatchIndex(ctx context.Context, nodes []model.SearchNode)
 			ID:               nodePathHash,
 			ParentHash:       parentHash,
 			ParentPathHashes: parentPathHashes,
+			ModifiedUnix:     src.Modified.Unix(),
 			SearchNode:       src,
 		}, nil
 	})
@@ -261,6 +292,7 @@ func (m *Meilisearch) batchIndexWithTaskUID(ctx context.Context, nodes []model.S
 			ID:               nodePathHash,
 			ParentHash:       parentHash,
 			ParentPathHashes: parentPathHashes,
+			ModifiedUnix:     src.Modified.Unix(),
 			SearchNode:       src,
 		}, nil
 	})
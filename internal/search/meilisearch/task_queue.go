@@ -11,6 +11,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
 	mapset "github.com/deckarep/golang-set/v2"
 	log "github.com/sirupsen/logrus"
 )
@@ -235,12 +236,7 @@ func (tqm *TaskQueueManager) executeTask(ctx context.Context, task *QueuedTask)
 	for i := range currentObjs {
 		if toAdd.Contains(currentObjs[i].GetName()) {
 			log.Debugf("will add index: %s", path.Join(parent, currentObjs[i].GetName()))
-			nodesToAdd = append(nodesToAdd, model.SearchNode{
-				Parent: parent,
-				Name:   currentObjs[i].GetName(),
-				IsDir:  currentObjs[i].IsDir(),
-				Size:   currentObjs[i].GetSize(),
-			})
+			nodesToAdd = append(nodesToAdd, searcher.NewNode(parent, currentObjs[i]))
 		}
 	}
 
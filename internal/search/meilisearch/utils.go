@@ -1,6 +1,8 @@
 package meilisearch
 
 import (
+	"time"
+
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
 
@@ -22,6 +24,18 @@ func buildSearchDocumentFromResults(results map[string]any) *searchDocument {
 	if size, ok := results["size"].(float64); ok {
 		document.SearchNode.Size = int64(size)
 	}
+	if modified, ok := results["modified"].(string); ok {
+		document.SearchNode.Modified, _ = time.Parse(time.RFC3339, modified)
+	}
+	document.SearchNode.HashInfo, _ = results["hashinfo"].(string)
+	if objType, ok := results["obj_type"].(float64); ok {
+		document.SearchNode.ObjType = int(objType)
+	}
+	document.SearchNode.Storage, _ = results["storage"].(string)
+	document.SearchNode.Driver, _ = results["driver"].(string)
+	if modifiedUnix, ok := results["modified_unix"].(float64); ok {
+		document.ModifiedUnix = int64(modifiedUnix)
+	}
 
 	document.ID, _ = results["id"].(string)
 	document.ParentHash, _ = results["parent_hash"].(string)
@@ -2,8 +2,11 @@ package searcher
 
 import (
 	"context"
+	"path"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
 
 type Config struct {
@@ -29,3 +32,28 @@ type Searcher interface {
 	// Clear all index
 	Clear(ctx context.Context) error
 }
+
+// Renamer is implemented by the searchers that can move the nodes of a subtree in place,
+// the others are updated by deleting and indexing the subtree again
+type Renamer interface {
+	// Rename the node at src and its children to dst
+	Rename(ctx context.Context, src, dst string) error
+}
+
+// NewNode builds the index node of obj in the parent dir
+func NewNode(parent string, obj model.Obj) model.SearchNode {
+	node := model.SearchNode{
+		Parent:   parent,
+		Name:     obj.GetName(),
+		IsDir:    obj.IsDir(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		HashInfo: obj.GetHash().String(),
+		ObjType:  utils.GetObjType(obj.GetName(), obj.IsDir()),
+	}
+	if storage, _, err := op.GetStorageAndActualPath(path.Join(parent, obj.GetName())); err == nil {
+		node.Storage = storage.GetStorage().MountPath
+		node.Driver = storage.Config().Name
+	}
+	return node
+}
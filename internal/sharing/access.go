@@ -0,0 +1,117 @@
+package sharing
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// pendingDownloads are the downloads checked but not logged yet by the file and the client ip,
+// they are counted so that the concurrent requests can't all pass the limit
+var (
+	downloadsMu      sync.Mutex
+	pendingDownloads = make(map[string]map[string]int)
+)
+
+// CheckDownload checks the download limits of the sharing on the file at path requested by the client of ip.
+// A request with a range not from the start resumes a download, which is only limited by the bytes,
+// if the client has downloaded the file before, otherwise it counts as a download.
+// release must be called after the access is logged, it's not nil even if err isn't nil.
+func CheckDownload(sharing *model.Sharing, path, ip, rangeHeader string) (partial bool, release func(), err error) {
+	release = func() {}
+	partial = !fromStart(rangeHeader)
+	if sharing.FileMaxDownloads <= 0 && sharing.FileMaxBytes <= 0 {
+		return partial, release, nil
+	}
+	path = utils.FixAndCleanPath(path)
+	key := sharing.ID + path
+	downloadsMu.Lock()
+	defer downloadsMu.Unlock()
+	count, bytes, err := db.CountFileDownloads(sharing.ID, path)
+	if err != nil {
+		return false, release, err
+	}
+	if partial && pendingDownloads[key][ip] == 0 {
+		if partial, err = db.HasFileDownload(sharing.ID, path, ip); err != nil {
+			return false, release, err
+		}
+	}
+	if !partial {
+		for _, n := range pendingDownloads[key] {
+			count += int64(n)
+		}
+	}
+	if (!partial && sharing.FileMaxDownloads > 0 && count >= int64(sharing.FileMaxDownloads)) ||
+		(sharing.FileMaxBytes > 0 && bytes >= sharing.FileMaxBytes) {
+		return partial, release, errors.WithStack(errs.DownloadLimit)
+	}
+	if partial {
+		return partial, release, nil
+	}
+	if pendingDownloads[key] == nil {
+		pendingDownloads[key] = make(map[string]int)
+	}
+	pendingDownloads[key][ip]++
+	return partial, func() {
+		downloadsMu.Lock()
+		defer downloadsMu.Unlock()
+		if pendingDownloads[key][ip]--; pendingDownloads[key][ip] <= 0 {
+			delete(pendingDownloads[key], ip)
+		}
+		if len(pendingDownloads[key]) == 0 {
+			delete(pendingDownloads, key)
+		}
+	}, nil
+}
+
+// fromStart reports whether the Range header of a request asks for the file from the start,
+// a request without a range does, and so does a multi-range one with a range from the start
+func fromStart(rangeHeader string) bool {
+	rangeHeader = strings.TrimSpace(rangeHeader)
+	spec, ok := strings.CutPrefix(rangeHeader, "bytes=")
+	if !ok {
+		// an invalid range is ignored, the whole file is sent
+		return true
+	}
+	for _, r := range strings.Split(spec, ",") {
+		if strings.HasPrefix(strings.TrimSpace(r), "0-") {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordAccess appends the access to the access log of the sharing and tells the creator about it
+func RecordAccess(sharing *model.Sharing, a *model.SharingAccess) {
+	a.SharingId = sharing.ID
+	a.Path = utils.FixAndCleanPath(a.Path)
+	if err := db.CreateSharingAccess(a); err != nil {
+		log.Errorf("failed record access of sharing %s: %+v", a.SharingId, err)
+	}
+	notify.SharingAccessed(sharing, a)
+}
+
+func GetAccesses(sid string, pageIndex, pageSize int) ([]model.SharingAccess, int64, error) {
+	return db.GetSharingAccesses(sid, pageIndex, pageSize)
+}
+
+// GetStats returns the aggregates of the access logs of the sharings by their ids
+func GetStats(sids ...string) map[string]*model.SharingStats {
+	res := make(map[string]*model.SharingStats, len(sids))
+	stats, err := db.GetSharingStats(sids)
+	if err != nil {
+		log.Errorf("failed get sharing stats: %+v", err)
+		return res
+	}
+	for i := range stats {
+		res[stats[i].SharingId] = &stats[i]
+	}
+	return res
+}
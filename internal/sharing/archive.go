@@ -15,11 +15,11 @@ func archiveMeta(ctx context.Context, sid, path string, args model.SharingArchiv
 	if err != nil {
 		return nil, nil, errors.WithStack(errs.SharingNotFound)
 	}
-	if !sharing.Valid() {
-		return sharing, nil, errors.WithStack(errs.InvalidSharing)
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, nil, err
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if sharing.Upload {
+		return sharing, nil, errors.WithStack(errs.UploadOnly)
 	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
@@ -42,11 +42,11 @@ func archiveList(ctx context.Context, sid, path string, args model.SharingArchiv
 	if err != nil {
 		return nil, nil, errors.WithStack(errs.SharingNotFound)
 	}
-	if !sharing.Valid() {
-		return sharing, nil, errors.WithStack(errs.InvalidSharing)
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, nil, err
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if sharing.Upload {
+		return sharing, nil, errors.WithStack(errs.UploadOnly)
 	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
@@ -17,14 +17,14 @@ func get(ctx context.Context, sid, path string, args model.SharingListArgs) (*mo
 	if err != nil {
 		return nil, nil, errors.WithStack(errs.SharingNotFound)
 	}
-	if !sharing.Valid() {
-		return sharing, nil, errors.WithStack(errs.InvalidSharing)
-	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, nil, err
 	}
 	path = utils.FixAndCleanPath(path)
-	if len(sharing.Files) == 1 || path != "/" {
+	if sharing.Upload && path != "/" {
+		return sharing, nil, errors.WithStack(errs.UploadOnly)
+	}
+	if (len(sharing.Files) == 1 && !sharing.Upload) || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
 			return nil, nil, errors.WithMessage(err, "failed get sharing unwrap path")
@@ -17,11 +17,11 @@ func link(ctx context.Context, sid, path string, args *LinkArgs) (*model.Sharing
 	if err != nil {
 		return nil, nil, nil, errors.WithStack(errs.SharingNotFound)
 	}
-	if !sharing.Valid() {
-		return sharing, nil, nil, errors.WithStack(errs.InvalidSharing)
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, nil, nil, err
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, nil, errors.WithStack(errs.WrongShareCode)
+	if sharing.Upload {
+		return sharing, nil, nil, errors.WithStack(errs.UploadOnly)
 	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
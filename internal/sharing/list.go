@@ -16,13 +16,17 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 	if err != nil {
 		return nil, nil, errors.WithStack(errs.SharingNotFound)
 	}
-	if !sharing.Valid() {
-		return sharing, nil, errors.WithStack(errs.InvalidSharing)
-	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, nil, err
 	}
 	path = utils.FixAndCleanPath(path)
+	// the files in the dir of an upload sharing are not visible
+	if sharing.Upload {
+		if path != "/" {
+			return sharing, nil, errors.WithStack(errs.UploadOnly)
+		}
+		return sharing, []model.Obj{}, nil
+	}
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
@@ -3,10 +3,30 @@ package sharing
 import (
 	"context"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
+// verify checks the sharing is valid and can be accessed with pwd by the user from the ip in ctx
+func verify(ctx context.Context, sharing *model.Sharing, pwd string) error {
+	if !op.ValidSharing(sharing) {
+		return errors.WithStack(errs.InvalidSharing)
+	}
+	ip, _ := ctx.Value(conf.ClientIPKey).(string)
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if !sharing.AllowIP(ip) || !sharing.AllowUser(user) {
+		return errors.WithStack(errs.SharingNotAllowed)
+	}
+	if !sharing.Verify(pwd) {
+		return errors.WithStack(errs.WrongShareCode)
+	}
+	return nil
+}
+
 func List(ctx context.Context, sid, path string, args model.SharingListArgs) (*model.Sharing, []model.Obj, error) {
 	sharing, res, err := list(ctx, sid, path, args)
 	if err != nil {
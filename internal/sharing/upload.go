@@ -0,0 +1,131 @@
+package sharing
+
+import (
+	"context"
+	"io"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// UploadArgs describes a file to be uploaded to an upload sharing
+type UploadArgs struct {
+	Name string
+	// -1 means unknown
+	Size int64
+	Pwd  string
+}
+
+// UploadPath checks the file can be uploaded to the upload sharing,
+// and returns the mount path to put it to
+func UploadPath(ctx context.Context, sid string, args UploadArgs) (*model.Sharing, string, error) {
+	sharing, path, err := uploadPath(ctx, sid, args)
+	if err != nil {
+		log.Warnf("failed upload %s to sharing %s: %s", args.Name, sid, err)
+		return nil, "", err
+	}
+	return sharing, path, nil
+}
+
+func uploadPath(ctx context.Context, sid string, args UploadArgs) (*model.Sharing, string, error) {
+	sharing, err := op.GetSharingById(sid)
+	if err != nil {
+		return nil, "", errors.WithStack(errs.SharingNotFound)
+	}
+	if !sharing.Upload {
+		return sharing, "", errors.WithStack(errs.InvalidSharing)
+	}
+	if err = verify(ctx, sharing, args.Pwd); err != nil {
+		return sharing, "", err
+	}
+	// the files are put to the dir of the sharing directly
+	name := stdpath.Base(utils.FixAndCleanPath(args.Name))
+	if name == "/" {
+		return sharing, "", errors.New("empty file name")
+	}
+	if err = checkUpload(sharing, name, args.Size); err != nil {
+		return sharing, "", err
+	}
+	path := stdpath.Join(sharing.Files[0], sharing.UploadPrefix+name)
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return sharing, "", errors.WithMessage(err, "failed get storage")
+	}
+	// the visitors can't see the files, so they can't overwrite them either
+	if _, err = op.Get(ctx, storage, actualPath); err == nil {
+		return sharing, "", errors.WithStack(errs.ObjectAlreadyExists)
+	}
+	return sharing, path, nil
+}
+
+// checkUpload checks the file against the limits of the upload sharing
+func checkUpload(sharing *model.Sharing, name string, size int64) error {
+	if sharing.UploadMaxSize > 0 {
+		if size < 0 {
+			return errors.New("the size of the file is required")
+		}
+		if size > sharing.UploadMaxSize {
+			return errors.Errorf("the file is larger than %d bytes", sharing.UploadMaxSize)
+		}
+	}
+	if sharing.UploadExtensions != "" {
+		ext := utils.Ext(name)
+		for _, e := range strings.Split(sharing.UploadExtensions, ",") {
+			if strings.ToLower(strings.TrimPrefix(strings.TrimSpace(e), ".")) == ext {
+				return nil
+			}
+		}
+		return errors.Errorf("the file extension [%s] is not allowed", ext)
+	}
+	return nil
+}
+
+// sizeReader fails the upload whose body isn't of the declared size
+type sizeReader struct {
+	io.ReadCloser
+	size int64
+	read int64
+}
+
+// NewSizeReader wraps the body of an upload declared to be size bytes
+func NewSizeReader(body io.ReadCloser, size int64) io.ReadCloser {
+	return &sizeReader{ReadCloser: body, size: size}
+}
+
+func (r *sizeReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if r.read > r.size {
+		return n, errors.Errorf("received more than the declared %d bytes", r.size)
+	}
+	if errors.Is(err, io.EOF) && r.read < r.size {
+		return n, errors.Errorf("received %d bytes, less than the declared %d bytes", r.read, r.size)
+	}
+	return n, err
+}
+
+var uploadedMu sync.Mutex
+
+// Uploaded counts the file uploaded to the upload sharing and tells the creator about it
+func Uploaded(ctx context.Context, sharing *model.Sharing, path string, size int64) {
+	log.Infof("%s is uploaded to sharing %s", path, sharing.ID)
+	uploadedMu.Lock()
+	now := time.Now()
+	sharing.Uploaded++
+	sharing.LastUploaded = &now
+	err := op.UpdateSharing(sharing, true)
+	uploadedMu.Unlock()
+	if err != nil {
+		log.Errorf("failed update sharing %s: %+v", sharing.ID, err)
+	}
+	notify.SharingUploaded(sharing, path, size)
+}
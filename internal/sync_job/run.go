@@ -0,0 +1,233 @@
+package sync_job
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// at most maxErrors errors are kept in the report of a run
+const maxErrors = 100
+
+// modTimeTolerance absorbs the precision loss of modified time on some storages
+const modTimeTolerance = 2 * time.Second
+
+type copying struct {
+	task task.TaskExtensionInfo
+	path string
+	size int64
+}
+
+type runner struct {
+	ctx     context.Context
+	job     *model.SyncJob
+	run     *model.SyncRun
+	since   time.Time
+	copying []copying
+	errs    []string
+}
+
+func (r *runner) fail(path string, err error) {
+	r.run.Failed++
+	if len(r.errs) < maxErrors {
+		r.errs = append(r.errs, fmt.Sprintf("%s: %s", path, err))
+	}
+}
+
+// list returns the objects in the dir by name, a missing dir is empty if missingOk.
+// Only the dst side may be missing, a src dir that can't be listed would be mirrored as empty.
+func (r *runner) list(path string, missingOk bool) (map[string]model.Obj, error) {
+	objs, err := fs.List(r.ctx, path, &fs.ListArgs{Refresh: true, NoLog: true})
+	if err != nil && !(missingOk && errs.IsObjectNotFound(err)) {
+		return nil, err
+	}
+	m := make(map[string]model.Obj, len(objs))
+	for _, obj := range objs {
+		m[obj.GetName()] = obj
+	}
+	return m, nil
+}
+
+// sync synchronizes the dir srcPath to the dir dstPath
+func (r *runner) sync(srcPath, dstPath string) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	srcObjs, err := r.list(srcPath, false)
+	if err != nil {
+		return errors.WithMessagef(err, "failed list %s", srcPath)
+	}
+	dstObjs, err := r.list(dstPath, true)
+	if err != nil {
+		return errors.WithMessagef(err, "failed list %s", dstPath)
+	}
+	for name, src := range srcObjs {
+		if err = r.ctx.Err(); err != nil {
+			return err
+		}
+		srcObjPath, dstObjPath := stdpath.Join(srcPath, name), stdpath.Join(dstPath, name)
+		dst, exist := dstObjs[name]
+		switch {
+		case exist && src.IsDir() != dst.IsDir():
+			r.fail(srcObjPath, errors.New("a file and a directory have the same name"))
+		case src.IsDir():
+			if !exist {
+				if err = fs.MakeDir(r.ctx, dstObjPath); err != nil {
+					r.fail(dstObjPath, err)
+					continue
+				}
+			}
+			if err = r.sync(srcObjPath, dstObjPath); err != nil {
+				r.fail(srcObjPath, err)
+			}
+		case !exist:
+			r.copy(srcObjPath, dstPath, src)
+		case !r.differs(src, dst):
+			r.run.Skipped++
+		case r.job.Mode == model.SyncTwoWay && dst.ModTime().After(src.ModTime()):
+			r.copy(dstObjPath, srcPath, dst)
+		default:
+			r.copy(srcObjPath, dstPath, src)
+		}
+	}
+	for name, dst := range dstObjs {
+		if _, exist := srcObjs[name]; exist {
+			continue
+		}
+		dstObjPath := stdpath.Join(dstPath, name)
+		switch r.job.Mode {
+		case model.SyncMirror:
+			if err = fs.Remove(r.ctx, dstObjPath); err != nil {
+				r.fail(dstObjPath, err)
+			} else {
+				r.run.Removed++
+			}
+		case model.SyncTwoWay:
+			r.copy(dstObjPath, srcPath, dst)
+		}
+	}
+	return nil
+}
+
+// differs reports whether two files of the same name should be synchronized.
+// The hashes are compared if both sides have the same kind of hash, otherwise the modified times.
+func (r *runner) differs(src, dst model.Obj) bool {
+	if src.GetSize() != dst.GetSize() {
+		return true
+	}
+	if equal, ok := hashEqual(src, dst); ok {
+		return !equal
+	}
+	if r.job.Mode == model.SyncTwoWay {
+		// the files copied by the last run are newer than the other side,
+		// so only the changes after the last run count
+		return src.ModTime().After(r.since) || dst.ModTime().After(r.since)
+	}
+	return src.ModTime().After(dst.ModTime().Add(modTimeTolerance))
+}
+
+func hashEqual(a, b model.Obj) (equal, ok bool) {
+	bHash := b.GetHash()
+	for ht, ah := range a.GetHash().All() {
+		if ah == "" {
+			continue
+		}
+		if bh := bHash.GetHash(ht); bh != "" {
+			return strings.EqualFold(ah, bh), true
+		}
+	}
+	return false, false
+}
+
+func (r *runner) copy(srcObjPath, dstDirPath string, obj model.Obj) {
+	t, err := fs.Copy(r.ctx, srcObjPath, dstDirPath)
+	if err != nil {
+		r.fail(srcObjPath, err)
+		return
+	}
+	if t == nil {
+		// copied in the same storage
+		r.run.Copied++
+		r.run.Bytes += obj.GetSize()
+		return
+	}
+	r.copying = append(r.copying, copying{task: t, path: srcObjPath, size: obj.GetSize()})
+}
+
+// wait waits for the copy tasks submitted to fs.CopyTaskManager
+func (r *runner) wait() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for len(r.copying) > 0 {
+		pending := r.copying[:0]
+		for _, c := range r.copying {
+			switch c.task.GetState() {
+			case tache.StateSucceeded:
+				r.run.Copied++
+				r.run.Bytes += c.size
+			case tache.StateFailed, tache.StateCanceled:
+				err := c.task.GetErr()
+				if err == nil {
+					err = errors.New("canceled")
+				}
+				r.fail(c.path, err)
+			default:
+				pending = append(pending, c)
+			}
+		}
+		r.copying = pending
+		if len(pending) == 0 {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-r.ctx.Done():
+			for _, c := range pending {
+				r.fail(c.path, errors.New("stopped waiting for the copy task"))
+			}
+			r.copying = nil
+			return
+		}
+	}
+}
+
+func (r *runner) exec() error {
+	if err := r.sync(r.job.SrcPath, r.job.DstPath); err != nil {
+		return err
+	}
+	r.wait()
+	if r.run.Failed > 0 {
+		return errors.Errorf("%d objects failed to sync", r.run.Failed)
+	}
+	return nil
+}
+
+func (r *runner) finish(err error) {
+	end := time.Now()
+	r.run.End = &end
+	r.run.Status = model.SyncSuccess
+	if err != nil {
+		r.run.Status = model.SyncFailed
+		r.errs = append([]string{err.Error()}, r.errs...)
+	}
+	r.run.Errors = strings.Join(r.errs, "\n")
+	if e := db.UpdateSyncRun(r.run); e != nil {
+		log.Errorf("failed save sync run of job [%s]: %+v", r.job.Name, e)
+	}
+	if err == nil {
+		if e := db.SetSyncJobLastRun(r.job.ID, end); e != nil {
+			log.Errorf("failed save last run of sync job [%s]: %+v", r.job.Name, e)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package sync_job
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	mu      sync.Mutex
+	timers  = map[uint]*time.Timer{}
+	running = map[uint]context.CancelFunc{}
+)
+
+// Init fails the runs interrupted by the last shutdown and schedules all jobs
+func Init() {
+	if err := db.FailRunningSyncRuns("interrupted by restart"); err != nil {
+		log.Errorf("failed update interrupted sync runs: %+v", err)
+	}
+	jobs, err := db.GetAllSyncJobs()
+	if err != nil {
+		log.Errorf("failed get sync jobs: %+v", err)
+		return
+	}
+	for i := range jobs {
+		schedule(&jobs[i])
+	}
+}
+
+// schedule arms the timer of the next run of the job, replacing the old one
+func schedule(job *model.SyncJob) {
+	mu.Lock()
+	defer mu.Unlock()
+	if t, ok := timers[job.ID]; ok {
+		t.Stop()
+		delete(timers, job.ID)
+	}
+	if job.Disabled || job.Schedule == "" {
+		return
+	}
+	s, err := cron.ParseSchedule(job.Schedule)
+	if err != nil {
+		log.Errorf("invalid schedule of sync job [%s]: %s", job.Name, err)
+		return
+	}
+	next := s.Next(time.Now())
+	if next.IsZero() {
+		return
+	}
+	id := job.ID
+	timers[id] = time.AfterFunc(time.Until(next), func() {
+		job, err := db.GetSyncJobById(id)
+		if err != nil {
+			log.Errorf("failed get sync job %d: %+v", id, err)
+			return
+		}
+		if _, err = Run(job); err != nil {
+			log.Warnf("sync job [%s] not started: %s", job.Name, err)
+		}
+		schedule(job)
+	})
+}
+
+func unschedule(id uint) {
+	mu.Lock()
+	defer mu.Unlock()
+	if t, ok := timers[id]; ok {
+		t.Stop()
+		delete(timers, id)
+	}
+}
+
+func validate(job *model.SyncJob) error {
+	job.SrcPath = utils.FixAndCleanPath(job.SrcPath)
+	job.DstPath = utils.FixAndCleanPath(job.DstPath)
+	if job.Mode == "" {
+		job.Mode = model.SyncCopyNew
+	}
+	if !job.ValidMode() {
+		return errors.Errorf("invalid mode: %s", job.Mode)
+	}
+	if utils.IsSubPath(job.SrcPath, job.DstPath) || utils.IsSubPath(job.DstPath, job.SrcPath) {
+		return errors.New("the source and the destination can't contain each other")
+	}
+	if job.Schedule != "" {
+		if _, err := cron.ParseSchedule(job.Schedule); err != nil {
+			return errors.WithMessage(err, "invalid schedule")
+		}
+	}
+	return nil
+}
+
+func CreateJob(job *model.SyncJob) error {
+	if err := validate(job); err != nil {
+		return err
+	}
+	if err := db.CreateSyncJob(job); err != nil {
+		return err
+	}
+	schedule(job)
+	return nil
+}
+
+func UpdateJob(job *model.SyncJob) error {
+	old, err := db.GetSyncJobById(job.ID)
+	if err != nil {
+		return err
+	}
+	if err = validate(job); err != nil {
+		return err
+	}
+	job.LastRun = old.LastRun
+	if err = db.UpdateSyncJob(job); err != nil {
+		return err
+	}
+	schedule(job)
+	return nil
+}
+
+func DeleteJob(id uint) error {
+	unschedule(id)
+	Cancel(id)
+	return db.DeleteSyncJobById(id)
+}
+
+// Run starts a run of the job in the background, a job doesn't run concurrently with itself
+func Run(job *model.SyncJob) (*model.SyncRun, error) {
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get admin")
+	}
+	mu.Lock()
+	if _, ok := running[job.ID]; ok {
+		mu.Unlock()
+		return nil, errors.New("the job is running")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	running[job.ID] = cancel
+	mu.Unlock()
+
+	run := &model.SyncRun{
+		JobId:  job.ID,
+		Start:  time.Now(),
+		Status: model.SyncRunning,
+	}
+	if err = db.CreateSyncRun(run); err != nil {
+		finished(job.ID)
+		return nil, err
+	}
+	// the copy tasks are created by admin
+	ctx = context.WithValue(ctx, conf.UserKey, admin)
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.ProtocolSync)
+	r := &runner{ctx: ctx, job: job, run: run}
+	if job.LastRun != nil {
+		r.since = *job.LastRun
+	}
+	go func() {
+		defer finished(job.ID)
+		r.finish(r.exec())
+	}()
+	return run, nil
+}
+
+func finished(id uint) {
+	mu.Lock()
+	defer mu.Unlock()
+	if cancel, ok := running[id]; ok {
+		cancel()
+		delete(running, id)
+	}
+}
+
+// Cancel stops walking the job, the copy tasks already submitted are kept
+func Cancel(id uint) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	cancel, ok := running[id]
+	if ok {
+		cancel()
+	}
+	return ok
+}
@@ -0,0 +1,57 @@
+package task_group
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+)
+
+// BatchInfo is the first payload of a batch, added with the guard of the batch,
+// which is done after all the tasks are added, so the batch isn't completed early
+type BatchInfo struct {
+	// the creator of the batch, the notification goes to it
+	UserId uint
+	// what the tasks are, e.g. offline_download
+	Type string
+}
+
+// BatchResult is appended to the payloads of the batch when a task of it is done
+type BatchResult struct {
+	Name      string
+	Succeeded bool
+	Error     string
+}
+
+// NotifyBatch emits the event of the batch once all the tasks of it are done,
+// the tasks created by the tasks of the batch, e.g. the transfers of the downloads, are counted too
+func NotifyBatch(ctx context.Context, batchID string, hasSuccess bool, payloads ...any) {
+	// the guard isn't done successfully if no task is added
+	if !hasSuccess {
+		return
+	}
+	var (
+		info      *BatchInfo
+		succeeded int
+		failed    []string
+	)
+	for _, payload := range payloads {
+		switch p := payload.(type) {
+		case BatchInfo:
+			info = &p
+		case BatchResult:
+			if p.Succeeded {
+				succeeded++
+			} else {
+				failed = append(failed, fmt.Sprintf("%s: %s", p.Name, p.Error))
+			}
+		}
+	}
+	// the batches aren't restored after restart, the tasks retried after that make up a batch without the info
+	if info == nil {
+		return
+	}
+	notify.BatchDone(info.UserId, info.Type, batchID, succeeded, failed)
+}
+
+var BatchCoordinator = NewTaskGroupCoordinator("NotifyBatch", NotifyBatch)
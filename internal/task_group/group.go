@@ -7,7 +7,8 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-type OnCompletionFunc func(ctx context.Context, groupID string, payloads ...any)
+// OnCompletionFunc is called once all the tasks of the group are done, hasSuccess tells whether any of them succeeded
+type OnCompletionFunc func(ctx context.Context, groupID string, hasSuccess bool, payloads ...any)
 type TaskGroupCoordinator struct {
 	name string
 	mu   sync.Mutex
@@ -45,12 +46,16 @@ func (tgc *TaskGroupCoordinator) AddTask(groupID string, payload any) {
 	tgc.groupPayloads[groupID] = append(tgc.groupPayloads[groupID], payload)
 }
 
+// AppendPayload appends the payload to the group, it's dropped if the group is completed or not added
 func (tgc *TaskGroupCoordinator) AppendPayload(groupID string, payload any) {
 	if payload == nil {
 		return
 	}
 	tgc.mu.Lock()
 	defer tgc.mu.Unlock()
+	if _, ok := tgc.groupStates[groupID]; !ok {
+		return
+	}
 	tgc.groupPayloads[groupID] = append(tgc.groupPayloads[groupID], payload)
 }
 
@@ -69,10 +74,10 @@ func (tgc *TaskGroupCoordinator) Done(ctx context.Context, groupID string, succe
 		payloads := tgc.groupPayloads[groupID]
 		delete(tgc.groupStates, groupID)
 		delete(tgc.groupPayloads, groupID)
-		if tgc.onCompletion != nil && state.hasSuccess {
+		if tgc.onCompletion != nil {
 			logrus.Debugf("OnCompletion:%s", groupID)
 			tgc.mu.Unlock()
-			tgc.onCompletion(ctx, groupID, payloads...)
+			tgc.onCompletion(ctx, groupID, state.hasSuccess, payloads...)
 			tgc.mu.Lock()
 		}
 		return
@@ -8,8 +8,10 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -21,7 +23,69 @@ type SrcPathToRemove string
 // ActualPath
 type DstPathToHook string
 
-func HookAndRemove(ctx context.Context, dstPath string, payloads ...any) {
+// TaskResult is appended to the payloads of the group when a task of it is done,
+// the group is notified once for all the tasks rather than once for each file
+type TaskResult struct {
+	Task      task.TaskExtensionInfo
+	Type      string
+	Succeeded bool
+}
+
+// AppendResult appends the result of the task to the group, the canceled tasks are ignored
+func (tgc *TaskGroupCoordinator) AppendResult(groupID string, t task.TaskExtensionInfo, typ string, succeeded bool) {
+	if !succeeded && t.Ctx() != nil && t.Ctx().Err() != nil {
+		return
+	}
+	tgc.AppendPayload(groupID, TaskResult{Task: t, Type: typ, Succeeded: succeeded})
+}
+
+// notifyResults emits the event of a single task, or the event of the batch if the group has more tasks,
+// for each creator and type of the tasks
+func notifyResults(groupID string, payloads []any) {
+	type key struct {
+		userId uint
+		typ    string
+	}
+	var keys []key
+	results := make(map[key][]TaskResult)
+	for _, payload := range payloads {
+		r, ok := payload.(TaskResult)
+		if !ok {
+			continue
+		}
+		k := key{typ: r.Type}
+		if creator := r.Task.GetCreator(); creator != nil {
+			k.userId = creator.ID
+		}
+		if _, ok = results[k]; !ok {
+			keys = append(keys, k)
+		}
+		results[k] = append(results[k], r)
+	}
+	for _, k := range keys {
+		rs := results[k]
+		if len(rs) == 1 {
+			notify.TaskDone(rs[0].Task, k.typ, rs[0].Succeeded)
+			continue
+		}
+		succeeded := 0
+		var failed []string
+		for _, r := range rs {
+			if r.Succeeded {
+				succeeded++
+			} else {
+				failed = append(failed, fmt.Sprintf("%s: %v", r.Task.GetName(), r.Task.GetErr()))
+			}
+		}
+		notify.BatchDone(k.userId, k.typ, groupID, succeeded, failed)
+	}
+}
+
+func HookAndRemove(ctx context.Context, dstPath string, hasSuccess bool, payloads ...any) {
+	defer notifyResults(dstPath, payloads)
+	if !hasSuccess {
+		return
+	}
 	dstStorage, dstActualPath, err := op.GetStorageAndActualPath(dstPath)
 	if err != nil {
 		log.Error(errors.WithMessage(err, "failed get dst storage"))
@@ -85,6 +149,7 @@ func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver,
 	}
 
 	if !dstObj.IsDir() {
+		moveDavProps(srcStorage, dstStorage, srcPath, dstObjPath)
 		err = op.Remove(ctx, srcStorage, srcPath)
 		if err != nil {
 			return fmt.Errorf("failed remove %s: %+v", path.Join(srcStorage.GetStorage().MountPath, srcPath), err)
@@ -110,6 +175,7 @@ func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver,
 	if hasErr {
 		return errors.Errorf("some subitems of [%s] failed to verify and remove", path.Join(srcStorage.GetStorage().MountPath, srcPath))
 	}
+	moveDavProps(srcStorage, dstStorage, srcPath, dstObjPath)
 	err = op.Remove(ctx, srcStorage, srcPath)
 	if err != nil {
 		return fmt.Errorf("failed remove %s: %+v", path.Join(srcStorage.GetStorage().MountPath, srcPath), err)
@@ -117,4 +183,13 @@ func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver,
 	return nil
 }
 
+// moveDavProps moves the WebDAV dead properties of the verified src to dst before src is removed
+func moveDavProps(srcStorage, dstStorage driver.Driver, srcPath, dstPath string) {
+	src := utils.GetFullPath(srcStorage.GetStorage().MountPath, srcPath)
+	dst := utils.GetFullPath(dstStorage.GetStorage().MountPath, dstPath)
+	if err := op.MoveDavProps(src, dst); err != nil {
+		log.Errorf("failed move dav props of %s: %+v", src, err)
+	}
+}
+
 var TransferCoordinator *TaskGroupCoordinator = NewTaskGroupCoordinator("HookAndRemove", HookAndRemove)
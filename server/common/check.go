@@ -31,9 +31,20 @@ func IsApply(metaPath, reqPath string, applySub bool) bool {
 	return utils.IsSubPath(metaPath, reqPath) && applySub
 }
 
+// HasPermission checks the effective permission of the user on reqPath,
+// the groups of the user and the path rules are taken into account
+func HasPermission(user *model.User, reqPath string, check func(permission int32) bool) bool {
+	return check(op.GetUserPermission(user, reqPath))
+}
+
 func CanAccess(user *model.User, meta *model.Meta, reqPath string, password string) bool {
+	perm := op.GetUserPermission(user, reqPath)
+	// if a path rule denies to read the reqPath, can't access
+	if !model.CanRead(perm) {
+		return false
+	}
 	// if the reqPath is in hide (only can check the nearest meta) and user can't see hides, can't access
-	if meta != nil && !user.CanSeeHides() && meta.Hide != "" &&
+	if meta != nil && !model.CanSeeHides(perm) && meta.Hide != "" &&
 		IsApply(meta.Path, path.Dir(reqPath), meta.HSub) { // the meta should apply to the parent of current path
 		for _, hide := range strings.Split(meta.Hide, "\n") {
 			re := regexp2.MustCompile(hide, regexp2.None)
@@ -43,7 +54,7 @@ func CanAccess(user *model.User, meta *model.Meta, reqPath string, password stri
 		}
 	}
 	// if is not guest and can access without password
-	if user.CanAccessWithoutPassword() {
+	if model.CanAccessWithoutPassword(perm) {
 		return true
 	}
 	// if meta is nil or password is empty, can access
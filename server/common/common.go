@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 )
@@ -77,6 +79,10 @@ func ErrorPage(c *gin.Context, err error, code int, l ...bool) {
 }
 
 func ErrorWithDataResp(c *gin.Context, err error, code int, data interface{}, l ...bool) {
+	// paths locked by WebDAV are refused rather than failed, whatever the handler passes
+	if errors.Is(err, errs.ObjectLocked) {
+		code, l = http.StatusLocked, nil
+	}
 	if len(l) > 0 && l[0] {
 		if flags.Debug || flags.Dev {
 			log.Errorf("%+v", err)
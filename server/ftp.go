@@ -141,7 +141,7 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 			return nil, err
 		}
 	}
-	if userObj.Disabled || !userObj.CanFTPAccess() {
+	if userObj.Disabled || !common.HasPermission(userObj, userObj.BasePath, model.CanFTPAccess) {
 		model.LoginCache.Set(ip, count+1)
 		return nil, errors.New("user is not allowed to access via FTP")
 	}
@@ -155,6 +155,7 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 		ctx = context.WithValue(ctx, conf.MetaPassKey, "")
 	}
 	ctx = context.WithValue(ctx, conf.ClientIPKey, ip)
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.ProtocolFTP)
 	ctx = context.WithValue(ctx, conf.ProxyHeaderKey, d.proxyHeader)
 	return ftp.NewAferoAdapter(ctx), nil
 }
@@ -19,7 +19,8 @@ func Mkdir(ctx context.Context, path string) error {
 	if err != nil {
 		return err
 	}
-	if !user.CanWrite() || !user.CanFTPManage() {
+	perm := op.GetUserPermission(user, reqPath)
+	if !model.CanWrite(perm) || !model.CanFTPManage(perm) {
 		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
 		if err != nil {
 			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
@@ -35,13 +36,14 @@ func Mkdir(ctx context.Context, path string) error {
 
 func Remove(ctx context.Context, path string) error {
 	user := ctx.Value(conf.UserKey).(*model.User)
-	if !user.CanRemove() || !user.CanFTPManage() {
-		return errs.PermissionDenied
-	}
 	reqPath, err := user.JoinPath(path)
 	if err != nil {
 		return err
 	}
+	perm := op.GetUserPermission(user, reqPath)
+	if !model.CanRemove(perm) || !model.CanFTPManage(perm) {
+		return errs.PermissionDenied
+	}
 	if err = RemoveStage(reqPath); !errors.Is(err, errs.ObjectNotFound) {
 		return err
 	}
@@ -60,8 +62,9 @@ func Rename(ctx context.Context, oldPath, newPath string) error {
 	}
 	srcDir, srcBase := stdpath.Split(srcPath)
 	dstDir, dstBase := stdpath.Split(dstPath)
+	srcPerm, dstPerm := op.GetUserPermission(user, srcPath), op.GetUserPermission(user, dstDir)
 	if srcDir == dstDir {
-		if !user.CanRename() || !user.CanFTPManage() {
+		if !model.CanRename(srcPerm) || !model.CanFTPManage(srcPerm) {
 			return errs.PermissionDenied
 		}
 		if err = MoveStage(srcPath, dstPath); !errors.Is(err, errs.ObjectNotFound) {
@@ -69,7 +72,8 @@ func Rename(ctx context.Context, oldPath, newPath string) error {
 		}
 		return fs.Rename(ctx, srcPath, dstBase)
 	} else {
-		if !user.CanFTPManage() || !user.CanMove() || (srcBase != dstBase && !user.CanRename()) {
+		if !model.CanFTPManage(srcPerm) || !model.CanMove(srcPerm) || !model.CanMove(dstPerm) ||
+			(srcBase != dstBase && !model.CanRename(srcPerm)) {
 			return errs.PermissionDenied
 		}
 		if err = MoveStage(srcPath, dstPath); !errors.Is(err, errs.ObjectNotFound) {
@@ -33,7 +33,8 @@ func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownl
 		}
 	}
 	ctx = context.WithValue(ctx, conf.MetaKey, meta)
-	if !common.CanAccess(user, meta, reqPath, ctx.Value(conf.MetaPassKey).(string)) {
+	if !common.CanAccess(user, meta, reqPath, ctx.Value(conf.MetaPassKey).(string)) ||
+		!common.HasPermission(user, reqPath, model.CanFTPAccess) {
 		return nil, errs.PermissionDenied
 	}
 
@@ -127,7 +128,8 @@ func Stat(ctx context.Context, path string) (os.FileInfo, error) {
 		}
 	}
 	ctx = context.WithValue(ctx, conf.MetaKey, meta)
-	if !common.CanAccess(user, meta, reqPath, ctx.Value(conf.MetaPassKey).(string)) {
+	if !common.CanAccess(user, meta, reqPath, ctx.Value(conf.MetaPassKey).(string)) ||
+		!common.HasPermission(user, reqPath, model.CanFTPAccess) {
 		return nil, errs.PermissionDenied
 	}
 	if ret, err := StatStage(reqPath); !errors.Is(err, errs.ObjectNotFound) {
@@ -153,7 +155,8 @@ func List(ctx context.Context, path string) ([]os.FileInfo, error) {
 		}
 	}
 	ctx = context.WithValue(ctx, conf.MetaKey, meta)
-	if !common.CanAccess(user, meta, reqPath, ctx.Value(conf.MetaPassKey).(string)) {
+	if !common.CanAccess(user, meta, reqPath, ctx.Value(conf.MetaPassKey).(string)) ||
+		!common.HasPermission(user, reqPath, model.CanFTPAccess) {
 		return nil, errs.PermissionDenied
 	}
 	objs, err := fs.List(ctx, reqPath, &fs.ListArgs{})
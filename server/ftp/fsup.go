@@ -40,7 +40,8 @@ func uploadAuth(ctx context.Context, path string) error {
 		}
 	}
 	if !(common.CanAccess(user, meta, path, ctx.Value(conf.MetaPassKey).(string)) &&
-		((user.CanFTPManage() && user.CanWrite()) || common.CanWrite(meta, stdpath.Dir(path)))) {
+		((common.HasPermission(user, path, model.CanFTPManage) && common.HasPermission(user, path, model.CanWrite)) ||
+			common.CanWrite(meta, stdpath.Dir(path)))) {
 		return errs.PermissionDenied
 	}
 	return nil
@@ -133,7 +134,7 @@ func (f *FileUploadProxy) Close() error {
 	task, err := fs.PutAsTask(f.ctx, dir, s)
 	if err != nil {
 		_ = s.Close()
-		return err
+		return uploadErr(err)
 	}
 	sf.SetRemoveCallback(func() {
 		fs.UploadTaskManager.Cancel(task.GetID())
@@ -239,7 +240,7 @@ func (f *FileUploadWithLengthProxy) Close() error {
 			return err
 		}
 		err = <-f.errChan
-		return err
+		return uploadErr(err)
 	} else {
 		data := f.first512Bytes[:f.pFirst]
 		contentType := http.DetectContentType(data)
@@ -254,6 +255,14 @@ func (f *FileUploadWithLengthProxy) Close() error {
 			WebPutAsTask: false,
 			Reader:       bytes.NewReader(data),
 		}
-		return fs.PutDirectly(f.ctx, dir, s)
+		return uploadErr(fs.PutDirectly(f.ctx, dir, s))
+	}
+}
+
+// uploadErr makes ftpserver reply 552 when the quota is exceeded
+func uploadErr(err error) error {
+	if errors.Is(err, errs.QuotaExceeded) {
+		return ftpserver.ErrStorageExceeded
 	}
+	return err
 }
@@ -91,15 +91,15 @@ func FsArchiveMetaSplit(c *gin.Context) {
 }
 
 func FsArchiveMeta(c *gin.Context, req *ArchiveMetaReq, user *model.User) {
-	if !user.CanReadArchives() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqPath, err := user.JoinPath(req.Path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, reqPath, model.CanReadArchives) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
 		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
@@ -176,15 +176,15 @@ func FsArchiveListSplit(c *gin.Context) {
 }
 
 func FsArchiveList(c *gin.Context, req *ArchiveListReq, user *model.User) {
-	if !user.CanReadArchives() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqPath, err := user.JoinPath(req.Path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, reqPath, model.CanReadArchives) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
 		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
@@ -246,10 +246,6 @@ func FsArchiveDecompress(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanDecompress() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	srcPaths := make([]string, 0, len(req.Names))
 	for _, name := range req.Names {
 		srcPath, err := user.JoinPath(stdpath.Join(req.SrcDir, name))
@@ -264,6 +260,10 @@ func FsArchiveDecompress(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, dstDir, model.CanDecompress) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	tasks := make([]task.TaskExtensionInfo, 0, len(srcPaths))
 	for _, srcPath := range srcPaths {
 		t, e := fs.ArchiveDecompress(c.Request.Context(), srcPath, dstDir, model.ArchiveDecompressArgs{
@@ -298,6 +298,78 @@ func FsArchiveDecompress(c *gin.Context) {
 	})
 }
 
+type ArchiveCompressReq struct {
+	SrcDir string   `json:"src_dir" form:"src_dir"`
+	Names  []string `json:"name" form:"name"`
+	// the path of the archive
+	DstPath string `json:"dst_path" form:"dst_path"`
+	// zip or tar.gz, it's got from the extension of the archive if it's empty
+	Format      string `json:"format" form:"format"`
+	ArchivePass string `json:"archive_pass" form:"archive_pass"`
+	Overwrite   bool   `json:"overwrite" form:"overwrite"`
+	// the meta password of the src objects
+	Password string `json:"password" form:"password"`
+}
+
+func FsArchiveCompress(c *gin.Context) {
+	var req ArchiveCompressReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	srcPaths := make([]string, 0, len(req.Names))
+	for _, name := range req.Names {
+		// the names may be the paths relative to the src dir, so that the objects can be in different storages
+		srcPath, err := user.JoinPath(stdpath.Join(req.SrcDir, name))
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		meta, err := op.GetNearestMeta(srcPath)
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		if !common.CanAccess(user, meta, srcPath, req.Password) {
+			common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+			return
+		}
+		srcPaths = append(srcPaths, srcPath)
+	}
+	dstPath, err := user.JoinPath(req.DstPath)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.HasPermission(user, stdpath.Dir(dstPath), model.CanWrite) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	format := req.Format
+	if format == "" {
+		switch ext := strings.ToLower(dstPath); {
+		case strings.HasSuffix(ext, ".zip"):
+			format = model.ArchiveZip
+		case strings.HasSuffix(ext, ".tar.gz"), strings.HasSuffix(ext, ".tgz"):
+			format = model.ArchiveTarGz
+		}
+	}
+	t, err := fs.ArchiveCompress(c.Request.Context(), srcPaths, dstPath, model.ArchiveCompressArgs{
+		Format:    format,
+		Password:  req.ArchivePass,
+		Overwrite: req.Overwrite,
+		MetaPass:  req.Password,
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfos([]task.TaskExtensionInfo{t}),
+	})
+}
+
 func ArchiveDown(c *gin.Context) {
 	archiveRawPath := c.Request.Context().Value(conf.PathKey).(string)
 	innerPath := utils.FixAndCleanPath(c.Query("inner"))
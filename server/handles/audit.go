@@ -0,0 +1,78 @@
+package handles
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ListAuditLogsReq struct {
+	model.PageReq
+	model.AuditLogFilter
+}
+
+func ListAuditLogs(c *gin.Context) {
+	var req ListAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	log.Debugf("%+v", req)
+	logs, total, err := db.GetAuditLogs(&req.AuditLogFilter, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+// ExportAuditLogs writes all the logs matching the filter as a csv file
+func ExportAuditLogs(c *gin.Context) {
+	var req model.AuditLogFilter
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	filename := fmt.Sprintf("audit_%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"id", "time", "user_id", "username", "ip", "protocol", "action", "src", "dst", "size", "result", "message"})
+	err := db.WalkAuditLogs(&req, func(logs []model.AuditLog) error {
+		for _, l := range logs {
+			if err := w.Write([]string{
+				strconv.FormatUint(uint64(l.ID), 10),
+				l.Time.Format(time.RFC3339),
+				strconv.FormatUint(uint64(l.UserId), 10),
+				l.Username,
+				l.IP,
+				l.Protocol,
+				l.Action,
+				l.Src,
+				l.Dst,
+				strconv.FormatInt(l.Size, 10),
+				l.Result,
+				l.Message,
+			}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+	w.Flush()
+	if err != nil {
+		log.Errorf("failed export audit logs: %+v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package handles
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/dedup"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type DedupScanReq struct {
+	Paths []string `json:"paths" binding:"required"`
+}
+
+func StartDedupScan(c *gin.Context) {
+	var req DedupScanReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := dedup.Scan(req.Paths); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func StopDedupScan(c *gin.Context) {
+	dedup.Stop()
+	common.SuccessResp(c)
+}
+
+func GetDedupStatus(c *gin.Context) {
+	common.SuccessResp(c, dedup.GetStatus())
+}
+
+func ListDedupGroups(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	log.Debugf("%+v", req)
+	groups, total, err := db.GetDedupGroups(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: groups,
+		Total:   total,
+	})
+}
+
+type DedupRemoveReq struct {
+	// the group the paths belong to
+	HashKey string   `json:"hash_key" binding:"required"`
+	Paths   []string `json:"paths" binding:"required"`
+	// required for the groups of the size and name only
+	Confirm bool `json:"confirm"`
+}
+
+func RemoveDuplicates(c *gin.Context) {
+	var req DedupRemoveReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	paths := cleanPaths(req.Paths)
+	if err := dedup.CheckGroup(req.HashKey, paths, "", req.Confirm); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	for _, path := range paths {
+		if err := dedup.Remove(c.Request.Context(), path); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
+
+type DedupLinkReq struct {
+	// the group the paths belong to
+	HashKey string `json:"hash_key" binding:"required"`
+	// the file kept, the links point to it
+	Keep    string   `json:"keep" binding:"required"`
+	Paths   []string `json:"paths" binding:"required"`
+	LinkDir string   `json:"link_dir" binding:"required"`
+	// required for the groups of the size and name only
+	Confirm bool `json:"confirm"`
+}
+
+func LinkDuplicates(c *gin.Context) {
+	var req DedupLinkReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	keep := utils.FixAndCleanPath(req.Keep)
+	paths := cleanPaths(req.Paths)
+	if err := dedup.CheckGroup(req.HashKey, paths, keep, req.Confirm); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	url := fmt.Sprintf("%s/d%s?sign=%s",
+		common.GetApiUrl(c),
+		utils.EncodePath(keep, true),
+		sign.NotExpired(keep))
+	linkDir := utils.FixAndCleanPath(req.LinkDir)
+	for _, path := range paths {
+		if path == keep {
+			continue
+		}
+		if err := dedup.ReplaceWithLink(c.Request.Context(), path, linkDir, url); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
+
+func cleanPaths(paths []string) []string {
+	res := make([]string, len(paths))
+	for i, path := range paths {
+		res[i] = utils.FixAndCleanPath(path)
+	}
+	return res
+}
@@ -36,7 +36,7 @@ func FsMkdir(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	if !user.CanWrite() {
+	if !common.HasPermission(user, reqPath, model.CanWrite) {
 		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
 		if err != nil {
 			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
@@ -76,15 +76,15 @@ func FsMove(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanMove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	dstDir, err := user.JoinPath(req.DstDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, dstDir, model.CanMove) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	validPaths := make([]string, 0, len(req.Names))
 	for _, name := range req.Names {
@@ -95,6 +95,10 @@ func FsMove(c *gin.Context) {
 			common.ErrorResp(c, err, 403)
 			return
 		}
+		if !common.HasPermission(user, srcPath, model.CanMove) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
 		if !req.Overwrite {
 			base := stdpath.Base(srcPath)
 			if base == "." || base == "/" {
@@ -151,15 +155,15 @@ func FsCopy(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanCopy() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	dstDir, err := user.JoinPath(req.DstDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, dstDir, model.CanCopy) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	validPaths := make([]string, 0, len(req.Names))
 	for _, name := range req.Names {
@@ -170,6 +174,10 @@ func FsCopy(c *gin.Context) {
 			common.ErrorResp(c, err, 403)
 			return
 		}
+		if !common.HasPermission(user, srcPath, model.CanCopy) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
 		if !req.Overwrite {
 			base := stdpath.Base(srcPath)
 			if base == "." || base == "/" {
@@ -233,10 +241,6 @@ func FsRename(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanRename() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqPath, err := user.JoinPath(req.Path)
 	if err == nil {
 		err = checkRelativePath(req.Name)
@@ -245,6 +249,10 @@ func FsRename(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, reqPath, model.CanRename) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	if !req.Overwrite {
 		dstPath := stdpath.Join(stdpath.Dir(reqPath), req.Name)
 		if dstPath != reqPath {
@@ -284,10 +292,6 @@ func FsRemove(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanRemove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	for i, name := range req.Names {
 		if strings.TrimSpace(utils.FixAndCleanPath(name)) == "/" {
 			log.Warnf("FsRemove: invalid item skipped: %s (parent directory: %s)\n", name, req.Dir)
@@ -301,6 +305,10 @@ func FsRemove(c *gin.Context) {
 			common.ErrorResp(c, err, 403)
 			return
 		}
+		if !common.HasPermission(user, req.Names[i], model.CanRemove) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
 	}
 	for _, path := range req.Names {
 		if path == "" {
@@ -328,15 +336,15 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 	}
 
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanRemove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, srcDir, model.CanRemove) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(srcDir)
 	if err != nil {
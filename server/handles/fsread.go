@@ -94,7 +94,8 @@ func FsList(c *gin.Context, req *ListReq, user *model.User) {
 		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
 		return
 	}
-	if !user.CanWrite() && !common.CanWrite(meta, reqPath) && req.Refresh {
+	canWrite := common.HasPermission(user, reqPath, model.CanWrite)
+	if !canWrite && !common.CanWrite(meta, reqPath) && req.Refresh {
 		common.ErrorStrResp(c, "Refresh without permission", 403)
 		return
 	}
@@ -109,7 +110,7 @@ func FsList(c *gin.Context, req *ListReq, user *model.User) {
 	total, objs := pagination(objs, &req.PageReq)
 	provider := "unknown"
 	var directUploadTools []string
-	if user.CanWrite() {
+	if canWrite {
 		if storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{}); err == nil {
 			directUploadTools = op.GetDirectUploadTools(storage)
 		}
@@ -119,7 +120,7 @@ func FsList(c *gin.Context, req *ListReq, user *model.User) {
 		Total:             int64(total),
 		Readme:            getReadme(meta, reqPath),
 		Header:            getHeader(meta, reqPath),
-		Write:             user.CanWrite() || common.CanWrite(meta, reqPath),
+		Write:             canWrite || common.CanWrite(meta, reqPath),
 		Provider:          provider,
 		DirectUploadTools: directUploadTools,
 	})
@@ -1,7 +1,9 @@
 package handles
 
 import (
+	"errors"
 	"io"
+	"net/http"
 	"net/url"
 	stdpath "path"
 	"strconv"
@@ -12,6 +14,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -19,6 +22,17 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+func putErrCode(err error) int {
+	if errors.Is(err, errs.QuotaExceeded) {
+		return 507
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return 413
+	}
+	return 500
+}
+
 func getLastModified(c *gin.Context) time.Time {
 	now := time.Now()
 	lastModifiedStr := c.GetHeader("Last-Modified")
@@ -38,6 +52,39 @@ func shouldIgnoreSystemFile(filename string) bool {
 	return false
 }
 
+// getUploadPath returns the path to put the uploaded file to,
+// the path of a file uploaded to a sharing is resolved by middlewares.SharingUp
+func getUploadPath(c *gin.Context) (string, int, error) {
+	if _, ok := c.Request.Context().Value(conf.SharingKey).(*model.Sharing); ok {
+		return c.Request.Context().Value(conf.PathKey).(string), 0, nil
+	}
+	path, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		return "", 400, err
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	path, err = user.JoinPath(path)
+	if err != nil {
+		return "", 403, err
+	}
+	return path, 0, nil
+}
+
+// uploaded tells the creator of the sharing the file is uploaded to
+func uploaded(c *gin.Context, path string, size int64) {
+	if s, ok := c.Request.Context().Value(conf.SharingKey).(*model.Sharing); ok {
+		sharing.Uploaded(c.Request.Context(), s, path, size)
+	}
+}
+
+// putFailed removes what's left of the file failed to be uploaded to a sharing,
+// the file didn't exist before as it's checked by middlewares.SharingUp
+func putFailed(c *gin.Context, path string) {
+	if _, ok := c.Request.Context().Value(conf.SharingKey).(*model.Sharing); ok {
+		_ = fs.Remove(c.Request.Context(), path)
+	}
+}
+
 func FsStream(c *gin.Context) {
 	defer func() {
 		if n, _ := io.ReadFull(c.Request.Body, []byte{0}); n == 1 {
@@ -45,20 +92,13 @@ func FsStream(c *gin.Context) {
 		}
 		_ = c.Request.Body.Close()
 	}()
-	path := c.GetHeader("File-Path")
-	path, err := url.PathUnescape(path)
+	path, code, err := getUploadPath(c)
 	if err != nil {
-		common.ErrorResp(c, err, 400)
+		common.ErrorResp(c, err, code)
 		return
 	}
 	asTask := c.GetHeader("As-Task") == "true"
 	overwrite := c.GetHeader("Overwrite") != "false"
-	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	path, err = user.JoinPath(path)
-	if err != nil {
-		common.ErrorResp(c, err, 403)
-		return
-	}
 	if !overwrite {
 		if res, _ := fs.Get(c.Request.Context(), path, &fs.GetArgs{NoLog: true}); res != nil {
 			common.ErrorStrResp(c, "file exists", 403)
@@ -115,9 +155,13 @@ func FsStream(c *gin.Context) {
 		err = fs.PutDirectly(c.Request.Context(), dir, s)
 	}
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		if t == nil {
+			putFailed(c, path)
+		}
+		common.ErrorResp(c, err, putErrCode(err))
 		return
 	}
+	uploaded(c, path, s.GetSize())
 	if t == nil {
 		common.SuccessResp(c)
 		return
@@ -134,20 +178,13 @@ func FsForm(c *gin.Context) {
 		}
 		_ = c.Request.Body.Close()
 	}()
-	path := c.GetHeader("File-Path")
-	path, err := url.PathUnescape(path)
+	path, code, err := getUploadPath(c)
 	if err != nil {
-		common.ErrorResp(c, err, 400)
+		common.ErrorResp(c, err, code)
 		return
 	}
 	asTask := c.GetHeader("As-Task") == "true"
 	overwrite := c.GetHeader("Overwrite") != "false"
-	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	path, err = user.JoinPath(path)
-	if err != nil {
-		common.ErrorResp(c, err, 403)
-		return
-	}
 	if !overwrite {
 		if res, _ := fs.Get(c.Request.Context(), path, &fs.GetArgs{NoLog: true}); res != nil {
 			common.ErrorStrResp(c, "file exists", 403)
@@ -215,9 +252,13 @@ func FsForm(c *gin.Context) {
 		err = fs.PutDirectly(c.Request.Context(), dir, s)
 	}
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		if t == nil {
+			putFailed(c, path)
+		}
+		common.ErrorResp(c, err, putErrCode(err))
 		return
 	}
+	uploaded(c, path, s.GetSize())
 	if t == nil {
 		common.SuccessResp(c)
 		return
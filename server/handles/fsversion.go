@@ -0,0 +1,129 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func FsVersionsList(c *gin.Context) {
+	var req FsGetReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.HasPermission(user, reqPath, model.CanRead) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	versions, err := fs.GetVersions(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, versions)
+}
+
+type FsVersionsReq struct {
+	Path string `json:"path"`
+	Ids  []uint `json:"ids" binding:"required"`
+}
+
+// checkVersions makes sure the versions belong to the file at path,
+// so that the permission checked on path covers them
+func checkVersions(c *gin.Context, path string, ids []uint) bool {
+	for _, id := range ids {
+		v, err := db.GetFileVersionById(id)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return false
+		}
+		if v.Path != path {
+			common.ErrorStrResp(c, "the version doesn't belong to the file", 400)
+			return false
+		}
+	}
+	return true
+}
+
+type FsVersionRestoreReq struct {
+	Path string `json:"path"`
+	Id   uint   `json:"id" binding:"required"`
+}
+
+func FsVersionRestore(c *gin.Context) {
+	var req FsVersionRestoreReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.HasPermission(user, reqPath, model.CanWrite) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !checkVersions(c, reqPath, []uint{req.Id}) {
+		return
+	}
+	if err = fs.RestoreVersion(c.Request.Context(), req.Id); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func FsVersionsDelete(c *gin.Context) {
+	var req FsVersionsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.HasPermission(user, reqPath, model.CanRemove) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !checkVersions(c, reqPath, req.Ids) {
+		return
+	}
+	for _, id := range req.Ids {
+		if err = fs.DeleteVersion(c.Request.Context(), id); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
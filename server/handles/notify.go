@@ -0,0 +1,255 @@
+package handles
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListNotifyChannels(c *gin.Context) {
+	channels, err := db.GetNotifyChannels()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, channels)
+}
+
+func CreateNotifyChannel(c *gin.Context) {
+	var req model.NotifyChannel
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	if err := notify.ValidateChannel(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := db.CreateNotifyChannel(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateNotifyChannel(c *gin.Context) {
+	var req model.NotifyChannel
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if _, err := db.GetNotifyChannelById(req.ID); err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if err := notify.ValidateChannel(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := db.UpdateNotifyChannel(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteNotifyChannel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = db.DeleteNotifyChannelById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type TestNotifyChannelReq struct {
+	ID     uint   `json:"id" binding:"required"`
+	Target string `json:"target"`
+}
+
+// TestNotifyChannel sends a test event to the target through the channel, the error of sending is responded
+func TestNotifyChannel(c *gin.Context) {
+	var req TestNotifyChannelReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	ch, err := db.GetNotifyChannelById(req.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if err = notify.Test(ch, req.Target); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type NotifyChannelInfo struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type MyNotifyChannelsResp struct {
+	Channels []NotifyChannelInfo `json:"channels"`
+	Events   []string            `json:"events"`
+}
+
+// ListMyNotifyChannels lists the enabled channels and the events the users can subscribe to,
+// the configs of the channels are only visible to the admins
+func ListMyNotifyChannels(c *gin.Context) {
+	channels, err := db.GetNotifyChannels()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := MyNotifyChannelsResp{Channels: []NotifyChannelInfo{}, Events: model.NotifyEvents}
+	for _, ch := range channels {
+		if !ch.Disabled {
+			resp.Channels = append(resp.Channels, NotifyChannelInfo{ID: ch.ID, Name: ch.Name, Type: ch.Type})
+		}
+	}
+	common.SuccessResp(c, resp)
+}
+
+func ListMyNotifySubscriptions(c *gin.Context) {
+	user, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || user.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	subs, err := db.GetNotifySubscriptionsByUserId(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, subs)
+}
+
+// checkNotifySubscription checks the subscription is to an enabled channel with a valid target
+func checkNotifySubscription(c *gin.Context, sub *model.NotifySubscription) bool {
+	ch, err := db.GetNotifyChannelById(sub.ChannelId)
+	if err != nil || ch.Disabled {
+		common.ErrorStrResp(c, "notify channel not found", 400)
+		return false
+	}
+	if err = notify.ValidateTarget(ch, sub.Target); err != nil {
+		common.ErrorResp(c, err, 400)
+		return false
+	}
+	for _, e := range sub.Events {
+		if !slices.Contains(model.NotifyEvents, e) {
+			common.ErrorStrResp(c, "unknown event: "+e, 400)
+			return false
+		}
+	}
+	return true
+}
+
+func CreateMyNotifySubscription(c *gin.Context) {
+	user, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || user.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req model.NotifySubscription
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	req.UserId = user.ID
+	if !checkNotifySubscription(c, &req) {
+		return
+	}
+	if err := db.CreateNotifySubscription(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// getMyNotifySubscription returns the subscription of id if it's of the user
+func getMyNotifySubscription(c *gin.Context, id uint) (*model.NotifySubscription, bool) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	sub, err := db.GetNotifySubscriptionById(id)
+	if err != nil || sub.UserId != user.ID {
+		common.ErrorStrResp(c, "notify subscription not found", 404)
+		return nil, false
+	}
+	return sub, true
+}
+
+func UpdateMyNotifySubscription(c *gin.Context) {
+	var req model.NotifySubscription
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	sub, ok := getMyNotifySubscription(c, req.ID)
+	if !ok {
+		return
+	}
+	req.UserId = sub.UserId
+	if !checkNotifySubscription(c, &req) {
+		return
+	}
+	if err := db.UpdateNotifySubscription(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteMyNotifySubscription(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	sub, ok := getMyNotifySubscription(c, uint(id))
+	if !ok {
+		return
+	}
+	if err = db.DeleteNotifySubscriptionById(sub.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// TestMyNotifySubscription sends a test event through the subscription
+func TestMyNotifySubscription(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	sub, ok := getMyNotifySubscription(c, uint(id))
+	if !ok {
+		return
+	}
+	ch, err := db.GetNotifyChannelById(sub.ChannelId)
+	if err != nil || ch.Disabled {
+		common.ErrorStrResp(c, "notify channel not found", 400)
+		return
+	}
+	if err = notify.Test(ch, sub.Target); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -1,6 +1,8 @@
 package handles
 
 import (
+	"fmt"
+	stdpath "path"
 	"strings"
 
 	_115 "github.com/OpenListTeam/OpenList/v4/drivers/115"
@@ -13,6 +15,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/drivers/thunderx"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/bittorrent"
 	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
@@ -114,6 +117,40 @@ func SetTransmission(c *gin.Context) {
 	common.SuccessResp(c, "ok")
 }
 
+type SetBitTorrentReq struct {
+	ListenPort string `json:"listen_port" form:"listen_port"`
+	SeedRatio  string `json:"seed_ratio" form:"seed_ratio"`
+	Seedtime   string `json:"seedtime" form:"seedtime"`
+}
+
+func SetBitTorrent(c *gin.Context) {
+	var req SetBitTorrentReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	items := []model.SettingItem{
+		{Key: conf.TorrentListenPort, Value: req.ListenPort, Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.TorrentSeedRatio, Value: req.SeedRatio, Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.TorrentSeedtime, Value: req.Seedtime, Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+	}
+	if err := op.SaveSettingItems(items); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	_tool, err := tool.Tools.Get("BitTorrent")
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	res, err := _tool.Init()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, res)
+}
+
 type Set115Req struct {
 	TempDir string `json:"temp_dir" form:"temp_dir"`
 }
@@ -475,20 +512,52 @@ func OfflineDownloadTools(c *gin.Context) {
 	common.SuccessResp(c, tools)
 }
 
+// ParseTorrent parses the .torrent file uploaded to download to the path, the files in it can be selected
+// to download by adding the returned magnet with the BitTorrent tool
+func ParseTorrent(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(c.PostForm("path"))
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.HasPermission(user, reqPath, model.CanAddOfflineDownloadTasks) {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	defer f.Close()
+	t, err := bittorrent.ParseTorrent(f)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, t)
+}
+
 type AddOfflineDownloadReq struct {
 	Urls         []string `json:"urls"`
 	Path         string   `json:"path"`
 	Tool         string   `json:"tool"`
 	DeletePolicy string   `json:"delete_policy"`
+	// the request headers and the expected checksum like sha256:<hex>, for SimpleHttp
+	Headers  map[string]string `json:"headers"`
+	Checksum string            `json:"checksum"`
+	// the paths of the files to download in a torrent, for BitTorrent
+	Files []string `json:"files"`
 }
 
 func AddOfflineDownload(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanAddOfflineDownloadTasks() {
-		common.ErrorStrResp(c, "permission denied", 403)
-		return
-	}
-
 	var req AddOfflineDownloadReq
 	if err := c.ShouldBind(&req); err != nil {
 		common.ErrorResp(c, err, 400)
@@ -499,6 +568,15 @@ func AddOfflineDownload(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, reqPath, model.CanAddOfflineDownloadTasks) {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+	// the other tools would ignore them silently
+	if (len(req.Headers) > 0 || req.Checksum != "") && req.Tool != "SimpleHttp" {
+		common.ErrorStrResp(c, "the headers and the checksum are only supported by SimpleHttp", 400)
+		return
+	}
 	var tasks []task.TaskExtensionInfo
 	for _, url := range req.Urls {
 		// Filter out empty lines and whitespace-only strings
@@ -512,6 +590,9 @@ func AddOfflineDownload(c *gin.Context) {
 			DstDirPath:   reqPath,
 			Tool:         req.Tool,
 			DeletePolicy: tool.DeletePolicy(req.DeletePolicy),
+			Headers:      req.Headers,
+			Checksum:     req.Checksum,
+			Files:        req.Files,
 		})
 		if err != nil {
 			common.ErrorResp(c, err, 500)
@@ -525,3 +606,97 @@ func AddOfflineDownload(c *gin.Context) {
 		"tasks": getTaskInfos(tasks),
 	})
 }
+
+type ImportOfflineDownloadReq struct {
+	// the default destination dir of the lines
+	Path         string `json:"path"`
+	Tool         string `json:"tool"`
+	DeletePolicy string `json:"delete_policy"`
+	// json, csv or aria2, see tool.ParseManifest
+	Format   string `json:"format"`
+	Manifest string `json:"manifest"`
+}
+
+type ImportOfflineDownloadError struct {
+	// the index of the entry in the manifest, from 0
+	Index int    `json:"index"`
+	Url   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// ImportOfflineDownload adds a task for each line of the manifest, with its own destination,
+// name, headers and checksum, the completion of them is notified once as a batch
+func ImportOfflineDownload(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	var req ImportOfflineDownloadReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	entries, err := tool.ParseManifest(req.Format, strings.NewReader(req.Manifest))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	args := make([]*tool.AddURLArgs, 0, len(entries))
+	permitted := make(map[string]bool)
+	for i, e := range entries {
+		if strings.TrimSpace(e.URL) == "" {
+			common.ErrorStrResp(c, fmt.Sprintf("the url of entry %d is empty", i), 400)
+			return
+		}
+		dir := req.Path
+		if strings.HasPrefix(e.Dir, "/") {
+			dir = e.Dir
+		} else if e.Dir != "" {
+			dir = stdpath.Join(req.Path, e.Dir)
+		}
+		reqPath, err := user.JoinPath(dir)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		if _, ok := permitted[reqPath]; !ok {
+			if !common.HasPermission(user, reqPath, model.CanAddOfflineDownloadTasks) {
+				common.ErrorStrResp(c, "permission denied", 403)
+				return
+			}
+			permitted[reqPath] = true
+		}
+		args = append(args, &tool.AddURLArgs{
+			URL:          strings.TrimSpace(e.URL),
+			DstDirPath:   reqPath,
+			Tool:         req.Tool,
+			DeletePolicy: tool.DeletePolicy(req.DeletePolicy),
+			Headers:      e.Headers,
+			Checksum:     e.Checksum,
+			Name:         e.Name,
+		})
+	}
+	if len(args) == 0 {
+		common.ErrorStrResp(c, "no url in the manifest", 400)
+		return
+	}
+	batchID, results, errs := tool.ImportURLs(c, args)
+	var tasks []task.TaskExtensionInfo
+	for _, t := range results {
+		if t != nil {
+			tasks = append(tasks, t)
+		}
+	}
+	var failed []ImportOfflineDownloadError
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, ImportOfflineDownloadError{
+				Index: i,
+				Url:   args[i].URL,
+				Error: err.Error(),
+			})
+		}
+	}
+	common.SuccessResp(c, gin.H{
+		"batch_id": batchID,
+		"tasks":    getTaskInfos(tasks),
+		"errors":   failed,
+	})
+}
@@ -0,0 +1,103 @@
+package handles
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func ListQuotas(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	log.Debugf("%+v", req)
+	quotas, total, err := op.GetQuotas(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: quotas,
+		Total:   total,
+	})
+}
+
+func GetQuota(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	quota, err := op.GetQuotaById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, quota)
+}
+
+func CreateQuota(c *gin.Context) {
+	var req model.Quota
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err := op.CreateQuota(&req)
+	audit.Record(c.Request.Context(), model.AuditQuotaCreate, req.Path, "", 0, err)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func UpdateQuota(c *gin.Context) {
+	var req model.Quota
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err := op.UpdateQuota(&req)
+	audit.Record(c.Request.Context(), model.AuditQuotaUpdate, req.Path, "", 0, err)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func DeleteQuota(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err = op.DeleteQuotaById(uint(id))
+	audit.Record(c.Request.Context(), model.AuditQuotaDelete, idStr, "", 0, err)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// ReconcileQuotas recounts the usage of all quotas in the background
+func ReconcileQuotas(c *gin.Context) {
+	go func() {
+		if err := op.ReconcileQuotas(context.Background()); err != nil {
+			log.Errorf("failed reconcile quotas: %+v", err)
+		}
+	}()
+	common.SuccessResp(c)
+}
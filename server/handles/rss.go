@@ -0,0 +1,140 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/rss"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func ListRssSubscriptions(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	log.Debugf("%+v", req)
+	subs, total, err := db.GetRssSubscriptions(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: subs,
+		Total:   total,
+	})
+}
+
+func GetRssSubscription(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	sub, err := db.GetRssSubscriptionById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, sub)
+}
+
+func CreateRssSubscription(c *gin.Context) {
+	var req model.RssSubscription
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err := rss.CreateSubscription(c.Request.Context(), &req)
+	audit.Record(c.Request.Context(), model.AuditRssCreate, req.Url, req.DstPath, 0, err)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func UpdateRssSubscription(c *gin.Context) {
+	var req model.RssSubscription
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err := rss.UpdateSubscription(c.Request.Context(), &req)
+	audit.Record(c.Request.Context(), model.AuditRssUpdate, req.Url, req.DstPath, 0, err)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func DeleteRssSubscription(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err = rss.DeleteSubscription(uint(id))
+	audit.Record(c.Request.Context(), model.AuditRssDelete, idStr, "", 0, err)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// CheckRssSubscription checks the feed now, without waiting for the interval
+func CheckRssSubscription(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	sub, err := db.GetRssSubscriptionById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	added, err := rss.Check(c.Request.Context(), sub)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"added": added,
+	})
+}
+
+type ListRssItemsReq struct {
+	model.PageReq
+	SubscriptionId uint `json:"subscription_id" form:"subscription_id" binding:"required"`
+}
+
+func ListRssItems(c *gin.Context) {
+	var req ListRssItemsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	log.Debugf("%+v", req)
+	items, total, err := db.GetRssItems(req.SubscriptionId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
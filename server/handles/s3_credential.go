@@ -0,0 +1,155 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type S3CredentialReq struct {
+	ID       uint                   `json:"id"`
+	Name     string                 `json:"name" binding:"required"`
+	Buckets  []model.S3BucketPolicy `json:"buckets"`
+	Disabled bool                   `json:"disabled"`
+}
+
+type S3CredentialCreateResp struct {
+	AccessKeyId     string              `json:"access_key_id"`
+	SecretAccessKey string              `json:"secret_access_key"`
+	Info            *model.S3Credential `json:"info"`
+}
+
+func ListMyS3Credentials(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	listS3Credentials(c, userObj)
+}
+
+func CreateMyS3Credential(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req S3CredentialReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorStrResp(c, "request invalid", 400)
+		return
+	}
+	cred := &model.S3Credential{
+		UserId:   userObj.ID,
+		Name:     req.Name,
+		Buckets:  req.Buckets,
+		Disabled: req.Disabled,
+	}
+	if err := op.CreateS3Credential(cred); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, S3CredentialCreateResp{
+		AccessKeyId:     cred.AccessKeyId,
+		SecretAccessKey: cred.SecretAccessKey,
+		Info:            cred,
+	})
+}
+
+func UpdateMyS3Credential(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req S3CredentialReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorStrResp(c, "request invalid", 400)
+		return
+	}
+	cred, err := op.GetS3CredentialByIdAndUserId(req.ID, userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get s3 credential", 404)
+		return
+	}
+	cred.Name = req.Name
+	cred.Buckets = req.Buckets
+	cred.Disabled = req.Disabled
+	if err = op.UpdateS3Credential(cred); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, cred)
+}
+
+func DeleteMyS3Credential(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	credId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	cred, err := op.GetS3CredentialByIdAndUserId(uint(credId), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get s3 credential", 404)
+		return
+	}
+	if err = op.DeleteS3CredentialById(cred.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListS3Credentials(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	userObj, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	listS3Credentials(c, userObj)
+}
+
+func DeleteS3Credential(c *gin.Context) {
+	credId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteS3CredentialById(uint(credId)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func listS3Credentials(c *gin.Context, userObj *model.User) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	creds, total, err := op.GetS3CredentialsByUserId(userObj.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: creds,
+		Total:   total,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/data"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -19,7 +20,9 @@ import (
 func ResetToken(c *gin.Context) {
 	token := random.Token()
 	item := model.SettingItem{Key: "token", Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE}
-	if err := op.SaveSettingItem(&item); err != nil {
+	err := op.SaveSettingItem(&item)
+	audit.Record(c.Request.Context(), model.AuditTokenReset, "", "", 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
@@ -53,7 +56,13 @@ func SaveSettings(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.SaveSettingItems(req); err != nil {
+	keys := make([]string, 0, len(req))
+	for _, item := range req {
+		keys = append(keys, item.Key)
+	}
+	err := op.SaveSettingItems(req)
+	audit.Record(c.Request.Context(), model.AuditSettingSave, strings.Join(keys, ","), "", 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -135,7 +144,9 @@ func DefaultSettings(c *gin.Context) {
 
 func DeleteSetting(c *gin.Context) {
 	key := c.Query("key")
-	if err := op.DeleteSettingItemByKey(key); err != nil {
+	err := op.DeleteSettingItemByKey(key)
+	audit.Record(c.Request.Context(), model.AuditSettingDelete, key, "", 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
@@ -2,17 +2,24 @@ package handles
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	stdpath "path"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/go-cache"
@@ -33,14 +40,12 @@ func SharingGet(c *gin.Context, req *FsGetReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s)
 	url := ""
 	if !obj.IsDir() {
 		fakePath := fmt.Sprintf("/%s/%s", sid, path)
 		url = fmt.Sprintf("%s/sd%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true))
-		if s.Pwd != "" {
-			url += "?pwd=" + s.Pwd
-		}
+		url += sharingDownQuery(c, s, fakePath, req.Password)
 	}
 	thumb, _ := model.GetThumb(obj)
 	common.SuccessResp(c, FsGetResp{
@@ -77,7 +82,7 @@ func SharingList(c *gin.Context, req *ListReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s)
 	total, objs := pagination(objs, &req.PageReq)
 	common.SuccessResp(c, FsListResp{
 		Content: utils.MustSliceConvert(objs, func(obj model.Obj) ObjResp {
@@ -98,7 +103,7 @@ func SharingList(c *gin.Context, req *ListReq) {
 		Total:    int64(total),
 		Readme:   s.Readme,
 		Header:   s.Header,
-		Write:    false,
+		Write:    s.Upload,
 		Provider: "unknown",
 	})
 }
@@ -130,12 +135,10 @@ func SharingArchiveMeta(c *gin.Context, req *ArchiveMetaReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s)
 	fakePath := fmt.Sprintf("/%s/%s", sid, path)
 	url := fmt.Sprintf("%s/sad%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true))
-	if s.Pwd != "" {
-		url += "?pwd=" + s.Pwd
-	}
+	url += sharingDownQuery(c, s, fakePath, req.Password)
 	common.SuccessResp(c, ArchiveMetaResp{
 		Comment:     ret.GetComment(),
 		IsEncrypted: ret.IsEncrypted(),
@@ -176,7 +179,7 @@ func SharingArchiveList(c *gin.Context, req *ArchiveListReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s)
 	total, objs := pagination(objs, &req.PageReq)
 	ret, _ := utils.SliceConvert(objs, func(src model.Obj) (ObjResp, error) {
 		return toObjsRespWithoutSignAndThumb(src), nil
@@ -194,14 +197,23 @@ func SharingDown(c *gin.Context) {
 	pwd := c.Query("pwd")
 	s, err := op.GetSharingById(sid)
 	if err == nil {
-		if !s.Valid() {
+		if !op.ValidSharing(s) {
 			err = errs.InvalidSharing
+		} else if !allowSharingDown(c, s, sid, path) {
+			err = errs.SharingNotAllowed
 		} else if !s.Verify(pwd) {
 			err = errs.WrongShareCode
+		} else if s.Upload {
+			err = errs.UploadOnly
 		} else if len(s.Files) != 1 && path == "/" {
 			err = errors.New("cannot get sharing root link")
 		}
 	}
+	partial, release := false, func() {}
+	if err == nil && c.Request.Method != http.MethodHead {
+		partial, release, err = sharing.CheckDownload(s, path, c.ClientIP(), c.GetHeader("Range"))
+	}
+	defer release()
 	if dealErrorPage(c, err) {
 		return
 	}
@@ -218,7 +230,8 @@ func SharingDown(c *gin.Context) {
 		if _, ok := c.GetQuery("d"); !ok {
 			if url := common.GenerateDownProxyURL(storage.GetStorage(), unwrapPath); url != "" {
 				c.Redirect(302, url)
-				_ = countAccess(c.ClientIP(), s)
+				_ = countAccess(c, s)
+				logAccess(c, s, model.SharingAccessDown, path, "", 0, true, partial)
 				return
 			}
 		}
@@ -230,10 +243,11 @@ func SharingDown(c *gin.Context) {
 			common.ErrorPage(c, errors.WithMessage(err, "failed get sharing link"), 500)
 			return
 		}
-		_ = countAccess(c.ClientIP(), s)
+		_ = countAccess(c, s)
 		proxy(c, link, obj, storage.GetStorage().ProxyRange)
+		logAccess(c, s, model.SharingAccessDown, path, "", int64(max(c.Writer.Size(), 0)), false, partial)
 	} else {
-		link, _, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
+		link, obj, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
 			Type:     c.Query("type"),
@@ -243,8 +257,9 @@ func SharingDown(c *gin.Context) {
 			common.ErrorPage(c, errors.WithMessage(err, "failed get sharing link"), 500)
 			return
 		}
-		_ = countAccess(c.ClientIP(), s)
+		_ = countAccess(c, s)
 		redirect(c, link)
+		logAccess(c, s, model.SharingAccessDown, path, "", obj.GetSize(), true, partial)
 	}
 }
 
@@ -261,14 +276,23 @@ func SharingArchiveExtract(c *gin.Context) {
 	archivePass := c.Query("pass")
 	s, err := op.GetSharingById(sid)
 	if err == nil {
-		if !s.Valid() {
+		if !op.ValidSharing(s) {
 			err = errs.InvalidSharing
+		} else if !allowSharingDown(c, s, sid, path) {
+			err = errs.SharingNotAllowed
 		} else if !s.Verify(pwd) {
 			err = errs.WrongShareCode
+		} else if s.Upload {
+			err = errs.UploadOnly
 		} else if len(s.Files) != 1 && path == "/" {
 			err = errors.New("cannot extract sharing root")
 		}
 	}
+	partial, release := false, func() {}
+	if err == nil && c.Request.Method != http.MethodHead {
+		partial, release, err = sharing.CheckDownload(s, path, c.ClientIP(), c.GetHeader("Range"))
+	}
+	defer release()
 	if dealErrorPage(c, err) {
 		return
 	}
@@ -298,13 +322,15 @@ func SharingArchiveExtract(c *gin.Context) {
 				return
 			}
 			proxy(c, link, obj, storage.GetStorage().ProxyRange)
+			logAccess(c, s, model.SharingAccessExtract, path, innerPath, int64(max(c.Writer.Size(), 0)), false, partial)
 		} else {
 			args.Redirect = true
-			link, _, err := op.DriverExtract(c.Request.Context(), storage, actualPath, args)
+			link, obj, err := op.DriverExtract(c.Request.Context(), storage, actualPath, args)
 			if dealErrorPage(c, err) {
 				return
 			}
 			redirect(c, link)
+			logAccess(c, s, model.SharingAccessExtract, path, innerPath, obj.GetSize(), true, partial)
 		}
 	} else {
 		rc, size, err := op.InternalExtract(c.Request.Context(), storage, actualPath, args)
@@ -313,7 +339,56 @@ func SharingArchiveExtract(c *gin.Context) {
 		}
 		fileName := stdpath.Base(innerPath)
 		proxyInternalExtract(c, rc, size, fileName)
+		logAccess(c, s, model.SharingAccessExtract, path, innerPath, int64(max(c.Writer.Size(), 0)), false, partial)
+	}
+}
+
+// sharingDownSignExpiration is how long the download url of a sharing needing login is valid
+const sharingDownSignExpiration = time.Hour
+
+// sharingDownQuery returns the query of the download url of the file at fakePath in the sharing,
+// the password verified is put in it, and a sign binding the url to the user if the sharing needs login
+func sharingDownQuery(c *gin.Context, s *model.Sharing, fakePath, pwd string) string {
+	query := url.Values{}
+	if s.HasPwd() {
+		query.Set("pwd", pwd)
+	}
+	if s.NeedLogin() {
+		user := c.Request.Context().Value(conf.UserKey).(*model.User)
+		uid := strconv.FormatUint(uint64(user.ID), 10)
+		query.Set("uid", uid)
+		query.Set("sign", sign.WithDuration(sharingDownSignData(fakePath, uid), sharingDownSignExpiration))
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
+
+func sharingDownSignData(fakePath, uid string) string {
+	return utils.FixAndCleanPath(fakePath) + ":" + uid
+}
+
+// allowSharingDown checks the visitor of a download url of the sharing,
+// the download urls are opened without the token, so the users are checked by the sign in them,
+// and the user is checked again as it may have been disabled or removed from the sharing since
+func allowSharingDown(c *gin.Context, s *model.Sharing, sid, path string) bool {
+	if !s.AllowIP(c.ClientIP()) {
+		return false
+	}
+	if !s.NeedLogin() {
+		return true
+	}
+	uid := c.Query("uid")
+	if sign.Verify(sharingDownSignData(stdpath.Join("/", sid, path), uid), c.Query("sign")) != nil {
+		return false
+	}
+	id, err := strconv.ParseUint(uid, 10, 64)
+	if err != nil {
+		return false
 	}
+	user, err := op.GetUserById(uint(id))
+	return err == nil && !user.Disabled && s.AllowUser(user)
 }
 
 func dealError(c *gin.Context, err error) bool {
@@ -323,7 +398,8 @@ func dealError(c *gin.Context, err error) bool {
 		common.ErrorStrResp(c, "the share does not exist", 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorStrResp(c, "the share has expired or is no longer valid", 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.UploadOnly) || errors.Is(err, errs.DownloadLimit) ||
+		errors.Is(err, errs.SharingNotAllowed) {
 		common.ErrorResp(c, err, 403)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorResp(c, err, 202)
@@ -340,7 +416,8 @@ func dealErrorPage(c *gin.Context, err error) bool {
 		common.ErrorPage(c, errors.New("the share does not exist"), 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorPage(c, errors.New("the share has expired or is no longer valid"), 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.UploadOnly) || errors.Is(err, errs.DownloadLimit) ||
+		errors.Is(err, errs.SharingNotAllowed) {
 		common.ErrorPage(c, err, 403)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorPage(c, err, 202)
@@ -352,8 +429,10 @@ func dealErrorPage(c *gin.Context, err error) bool {
 
 type SharingResp struct {
 	*model.Sharing
-	CreatorName string `json:"creator"`
-	CreatorRole int    `json:"creator_role"`
+	CreatorName string              `json:"creator"`
+	CreatorRole int                 `json:"creator_role"`
+	HasPwd      bool                `json:"has_pwd"`
+	Stats       *model.SharingStats `json:"stats"`
 }
 
 func GetSharing(c *gin.Context) {
@@ -368,6 +447,8 @@ func GetSharing(c *gin.Context) {
 		Sharing:     s,
 		CreatorName: s.Creator.Username,
 		CreatorRole: s.Creator.Role,
+		HasPwd:      s.HasPwd(),
+		Stats:       sharing.GetStats(s.ID)[s.ID],
 	})
 }
 
@@ -391,18 +472,52 @@ func ListSharings(c *gin.Context) {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
+	stats := sharing.GetStats(utils.MustSliceConvert(sharings, func(s model.Sharing) string {
+		return s.ID
+	})...)
 	common.SuccessResp(c, common.PageResp{
 		Content: utils.MustSliceConvert(sharings, func(s model.Sharing) SharingResp {
 			return SharingResp{
 				Sharing:     &s,
 				CreatorName: s.Creator.Username,
 				CreatorRole: s.Creator.Role,
+				HasPwd:      s.HasPwd(),
+				Stats:       stats[s.ID],
 			}
 		}),
 		Total: total,
 	})
 }
 
+type ListSharingAccessesReq struct {
+	model.PageReq
+	ID string `json:"id" form:"id"`
+}
+
+func ListSharingAccesses(c *gin.Context) {
+	var req ListSharingAccessesReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, err := op.GetSharingById(req.ID)
+	if err != nil || (!user.IsAdmin() && s.CreatorId != user.ID) {
+		common.ErrorStrResp(c, "sharing not found", 404)
+		return
+	}
+	accesses, total, err := sharing.GetAccesses(s.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: accesses,
+		Total:   total,
+	})
+}
+
 type UpdateSharingReq struct {
 	Files       []string   `json:"files"`
 	Expires     *time.Time `json:"expires"`
@@ -413,6 +528,19 @@ type UpdateSharingReq struct {
 	Readme      string     `json:"readme"`
 	Header      string     `json:"header"`
 	model.Sort
+	FileMaxDownloads int    `json:"file_max_downloads"`
+	FileMaxBytes     int64  `json:"file_max_bytes"`
+	Upload           bool   `json:"upload"`
+	UploadMaxSize    int64  `json:"upload_max_size"`
+	UploadExtensions string `json:"upload_extensions"`
+	UploadPrefix     string `json:"upload_prefix"`
+	AllowedCIDRs     string `json:"allowed_cidrs"`
+	RequireLogin     bool   `json:"require_login"`
+	AllowedUserIds   []uint `json:"allowed_user_ids"`
+	AllowedGroupIds  []uint `json:"allowed_group_ids"`
+	// only the hash of the password is kept, so an empty Pwd keeps the password when updating
+	RemovePwd bool `json:"remove_pwd"`
+
 	CreatorName string `json:"creator"`
 	Accessed    int    `json:"accessed"`
 	ID          string `json:"id"`
@@ -439,19 +567,22 @@ func UpdateSharing(c *gin.Context) {
 		}
 	} else {
 		user = reqUser
-		if !user.CanShare() {
-			common.ErrorStrResp(c, "permission denied", 403)
-			return
-		}
 	}
 	for i, s := range req.Files {
 		s = utils.FixAndCleanPath(s)
 		req.Files[i] = s
-		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.BasePath) {
+		if !reqUser.IsAdmin() && (!strings.HasPrefix(s, user.BasePath) || !common.HasPermission(user, s, model.CanShare)) {
 			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 500)
 			return
 		}
 	}
+	if !checkUploadSharing(c, reqUser, user, &req) {
+		return
+	}
+	if _, err := (&model.SharingDB{AllowedCIDRs: req.AllowedCIDRs}).GetAllowedPrefixes(); err != nil {
+		common.ErrorStrResp(c, fmt.Sprintf("invalid allowed cidrs: %s", err), 400)
+		return
+	}
 	s, err := op.GetSharingById(req.ID)
 	if err != nil || (!reqUser.IsAdmin() && s.CreatorId != user.ID) {
 		common.ErrorStrResp(c, "sharing not found", 404)
@@ -462,7 +593,9 @@ func UpdateSharing(c *gin.Context) {
 	}
 	s.Files = req.Files
 	s.Expires = req.Expires
-	s.Pwd = req.Pwd
+	if req.Pwd != "" || req.RemovePwd {
+		s.SetPwd(req.Pwd)
+	}
 	s.Accessed = req.Accessed
 	s.MaxAccessed = req.MaxAccessed
 	s.Disabled = req.Disabled
@@ -470,18 +603,56 @@ func UpdateSharing(c *gin.Context) {
 	s.Header = req.Header
 	s.Readme = req.Readme
 	s.Remark = req.Remark
+	s.FileMaxDownloads = req.FileMaxDownloads
+	s.FileMaxBytes = req.FileMaxBytes
+	s.Upload = req.Upload
+	s.UploadMaxSize = req.UploadMaxSize
+	s.UploadExtensions = req.UploadExtensions
+	s.UploadPrefix = req.UploadPrefix
+	s.AllowedCIDRs = req.AllowedCIDRs
+	s.RequireLogin = req.RequireLogin
+	s.AllowedUserIds = req.AllowedUserIds
+	s.AllowedGroupIds = req.AllowedGroupIds
 	s.Creator = user
-	if err = op.UpdateSharing(s); err != nil {
+	err = op.UpdateSharing(s)
+	audit.Record(c.Request.Context(), model.AuditShareUpdate, s.ID, "", 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c, SharingResp{
 			Sharing:     s,
 			CreatorName: s.Creator.Username,
 			CreatorRole: s.Creator.Role,
+			HasPwd:      s.HasPwd(),
 		})
 	}
 }
 
+// checkUploadSharing checks the only dir of an upload sharing can be written by the creator
+func checkUploadSharing(c *gin.Context, reqUser, user *model.User, req *UpdateSharingReq) bool {
+	if !req.Upload {
+		return true
+	}
+	if len(req.Files) != 1 {
+		common.ErrorStrResp(c, "an upload sharing must have exactly 1 dir", 400)
+		return false
+	}
+	if !reqUser.IsAdmin() && !common.HasPermission(user, req.Files[0], model.CanWrite) {
+		common.ErrorStrResp(c, fmt.Sprintf("permission denied to upload to path [%s]", req.Files[0]), 403)
+		return false
+	}
+	obj, err := fs.Get(c.Request.Context(), req.Files[0], &fs.GetArgs{NoLog: true})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return false
+	}
+	if !obj.IsDir() {
+		common.ErrorStrResp(c, "an upload sharing must have exactly 1 dir", 400)
+		return false
+	}
+	return true
+}
+
 func CreateSharing(c *gin.Context) {
 	var req UpdateSharingReq
 	var err error
@@ -503,7 +674,7 @@ func CreateSharing(c *gin.Context) {
 		}
 	} else {
 		user = reqUser
-		if !user.CanShare() || (!user.IsAdmin() && req.ID != "") {
+		if !user.IsAdmin() && req.ID != "" {
 			common.ErrorStrResp(c, "permission denied", 403)
 			return
 		}
@@ -511,16 +682,22 @@ func CreateSharing(c *gin.Context) {
 	for i, s := range req.Files {
 		s = utils.FixAndCleanPath(s)
 		req.Files[i] = s
-		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.BasePath) {
+		if !reqUser.IsAdmin() && (!strings.HasPrefix(s, user.BasePath) || !common.HasPermission(user, s, model.CanShare)) {
 			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 500)
 			return
 		}
 	}
+	if !checkUploadSharing(c, reqUser, user, &req) {
+		return
+	}
+	if _, err := (&model.SharingDB{AllowedCIDRs: req.AllowedCIDRs}).GetAllowedPrefixes(); err != nil {
+		common.ErrorStrResp(c, fmt.Sprintf("invalid allowed cidrs: %s", err), 400)
+		return
+	}
 	s := &model.Sharing{
 		SharingDB: &model.SharingDB{
 			ID:          req.ID,
 			Expires:     req.Expires,
-			Pwd:         req.Pwd,
 			Accessed:    req.Accessed,
 			MaxAccessed: req.MaxAccessed,
 			Disabled:    req.Disabled,
@@ -528,19 +705,36 @@ func CreateSharing(c *gin.Context) {
 			Remark:      req.Remark,
 			Readme:      req.Readme,
 			Header:      req.Header,
+
+			FileMaxDownloads: req.FileMaxDownloads,
+			FileMaxBytes:     req.FileMaxBytes,
+			Upload:           req.Upload,
+			UploadMaxSize:    req.UploadMaxSize,
+			UploadExtensions: req.UploadExtensions,
+			UploadPrefix:     req.UploadPrefix,
+			AllowedCIDRs:     req.AllowedCIDRs,
+			RequireLogin:     req.RequireLogin,
+			AllowedUserIds:   req.AllowedUserIds,
+			AllowedGroupIds:  req.AllowedGroupIds,
 		},
 		Files:   req.Files,
 		Creator: user,
 	}
+	s.SetPwd(req.Pwd)
 	var id string
-	if id, err = op.CreateSharing(s); err != nil {
+	id, err = op.CreateSharing(s)
+	audit.Record(c.Request.Context(), model.AuditShareCreate, id, strings.Join(s.Files, ","), 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		s.ID = id
+		// the password is shown to the creator once, only its hash is kept
+		s.Pwd = req.Pwd
 		common.SuccessResp(c, SharingResp{
 			Sharing:     s,
 			CreatorName: s.Creator.Username,
 			CreatorRole: s.Creator.Role,
+			HasPwd:      s.HasPwd(),
 		})
 	}
 }
@@ -553,7 +747,9 @@ func DeleteSharing(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if err = op.DeleteSharing(sid); err != nil {
+	err = op.DeleteSharing(sid)
+	audit.Record(c.Request.Context(), model.AuditShareDelete, sid, "", 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -583,13 +779,50 @@ var (
 	AccessCountDelay = 30 * time.Minute
 )
 
-func countAccess(ip string, s *model.Sharing) error {
-	key := fmt.Sprintf("%s:%s", s.ID, ip)
+func countAccess(c *gin.Context, s *model.Sharing) error {
+	key := fmt.Sprintf("%s:%s", s.ID, c.ClientIP())
 	_, ok := AccessCache.Get(key)
 	if !ok {
 		AccessCache.Set(key, struct{}{}, cache.WithEx[interface{}](AccessCountDelay))
+		logAccess(c, s, model.SharingAccessView, "/", "", 0, false, false)
 		s.Accessed += 1
 		return op.UpdateSharing(s, true)
 	}
 	return nil
 }
+
+// rangeBytes returns the bytes of the file of size requested by r
+func rangeBytes(r *http.Request, size int64) int64 {
+	ranges, err := http_range.ParseRange(r.Header.Get("Range"), size)
+	if err != nil || len(ranges) == 0 {
+		return size
+	}
+	var n int64
+	for _, rg := range ranges {
+		n += rg.Length
+	}
+	return n
+}
+
+// logAccess appends the access to the access log of the sharing, the HEAD requests are not logged.
+// The bytes of a proxied download are what it has written, so it's logged after the transfer.
+// partial is decided by sharing.CheckDownload.
+func logAccess(c *gin.Context, s *model.Sharing, typ, path, innerPath string, bytes int64, redirected, partial bool) {
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	if typ != model.SharingAccessView && redirected {
+		bytes = rangeBytes(c.Request, bytes)
+	}
+	sharing.RecordAccess(s, &model.SharingAccess{
+		Time:       time.Now(),
+		Type:       typ,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		Path:       path,
+		InnerPath:  innerPath,
+		Bytes:      bytes,
+		Redirected: redirected,
+		Partial:    partial,
+	})
+}
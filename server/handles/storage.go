@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
@@ -96,7 +97,9 @@ func CreateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if id, err := op.CreateStorage(c.Request.Context(), req); err != nil {
+	id, err := op.CreateStorage(c.Request.Context(), req)
+	audit.Record(c.Request.Context(), model.AuditStorageCreate, req.MountPath, "", 0, err)
+	if err != nil {
 		common.ErrorWithDataResp(c, err, 500, gin.H{
 			"id": id,
 		}, true)
@@ -113,7 +116,9 @@ func UpdateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.UpdateStorage(c.Request.Context(), req); err != nil {
+	err := op.UpdateStorage(c.Request.Context(), req)
+	audit.Record(c.Request.Context(), model.AuditStorageUpdate, req.MountPath, "", 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -127,7 +132,9 @@ func DeleteStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DeleteStorageById(c.Request.Context(), uint(id)); err != nil {
+	err = op.DeleteStorageById(c.Request.Context(), uint(id))
+	audit.Record(c.Request.Context(), model.AuditStorageDelete, idStr, "", 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -141,7 +148,9 @@ func DisableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.DisableStorage(c.Request.Context(), uint(id)); err != nil {
+	err = op.DisableStorage(c.Request.Context(), uint(id))
+	audit.Record(c.Request.Context(), model.AuditStorageDisable, idStr, "", 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
@@ -155,7 +164,9 @@ func EnableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.EnableStorage(c.Request.Context(), uint(id)); err != nil {
+	err = op.EnableStorage(c.Request.Context(), uint(id))
+	audit.Record(c.Request.Context(), model.AuditStorageEnable, idStr, "", 0, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
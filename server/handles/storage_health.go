@@ -0,0 +1,60 @@
+package handles
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/health"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListStorageHealth(c *gin.Context) {
+	common.SuccessResp(c, health.GetStatuses())
+}
+
+type StorageHealthHistoryReq struct {
+	model.PageReq
+	ID uint `json:"id" form:"id" binding:"required"`
+}
+
+func ListStorageHealthHistory(c *gin.Context) {
+	var req StorageHealthHistoryReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	healths, total, err := health.GetHistory(req.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: healths,
+		Total:   total,
+	})
+}
+
+// CheckStorageHealth checks the storage right now, it's reinitialized at once if failing
+func CheckStorageHealth(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	s, err := db.GetStorageById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	storage, err := op.GetStorageByMountPath(s.MountPath)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, health.Check(context.WithoutCancel(c.Request.Context()), storage, true))
+}
@@ -0,0 +1,169 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ApiTokenReq struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name" binding:"required"`
+	Permission int32      `json:"permission"`
+	BasePath   string     `json:"base_path"`
+	Admin      bool       `json:"admin"`
+	Expires    *time.Time `json:"expires"`
+}
+
+type ApiTokenCreateResp struct {
+	Token string          `json:"token"`
+	Info  *model.ApiToken `json:"info"`
+}
+
+func ListMyApiTokens(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	listApiTokens(c, userObj)
+}
+
+func CreateMyApiToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req ApiTokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorStrResp(c, "request invalid", 400)
+		return
+	}
+	if req.Admin && !userObj.IsAdmin() {
+		common.ErrorStrResp(c, "only admin can create admin token", 403)
+		return
+	}
+	t := &model.ApiToken{
+		UserId:     userObj.ID,
+		Name:       req.Name,
+		Permission: req.Permission,
+		BasePath:   req.BasePath,
+		Admin:      req.Admin,
+		Expires:    req.Expires,
+	}
+	token, err := op.CreateApiToken(t)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, ApiTokenCreateResp{
+		Token: token,
+		Info:  t,
+	})
+}
+
+func UpdateMyApiToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req ApiTokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorStrResp(c, "request invalid", 400)
+		return
+	}
+	if req.Admin && !userObj.IsAdmin() {
+		common.ErrorStrResp(c, "only admin can create admin token", 403)
+		return
+	}
+	t, err := op.GetApiTokenByIdAndUserId(req.ID, userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get api token", 404)
+		return
+	}
+	t.Name = req.Name
+	t.Permission = req.Permission
+	t.BasePath = req.BasePath
+	t.Admin = req.Admin
+	t.Expires = req.Expires
+	if err = op.UpdateApiToken(t); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, t)
+}
+
+func DeleteMyApiToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	t, err := op.GetApiTokenByIdAndUserId(uint(tokenId), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get api token", 404)
+		return
+	}
+	if err = op.DeleteApiTokenById(t.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListApiTokens(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	userObj, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	listApiTokens(c, userObj)
+}
+
+func DeleteApiToken(c *gin.Context) {
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteApiTokenById(uint(tokenId)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func listApiTokens(c *gin.Context, userObj *model.User) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	tokens, total, err := op.GetApiTokensByUserId(userObj.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: tokens,
+		Total:   total,
+	})
+}
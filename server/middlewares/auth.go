@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -46,6 +47,23 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 			c.Next()
 			return
 		}
+		if strings.HasPrefix(token, model.ApiTokenPrefix) {
+			user, apiToken, err := op.GetUserByApiToken(token)
+			if err != nil {
+				common.ErrorResp(c, err, 401)
+				c.Abort()
+				return
+			}
+			if user.Disabled {
+				common.ErrorStrResp(c, "Current user is disabled, replace please", 401)
+				c.Abort()
+				return
+			}
+			common.GinWithValue(c, conf.UserKey, user, conf.ApiTokenKey, apiToken)
+			log.Debugf("use api token [%s] of user: %s", apiToken.Name, user.Username)
+			c.Next()
+			return
+		}
 		userClaims, err := common.ParseToken(token)
 		if err != nil {
 			common.ErrorResp(c, err, 401)
@@ -139,6 +157,17 @@ func AuthNotGuest(c *gin.Context) {
 	}
 }
 
+// AuthNotApiToken rejects requests authenticated by a personal access token,
+// so that a token can't be used to manage the account or to mint broader tokens
+func AuthNotApiToken(c *gin.Context) {
+	if _, ok := c.Request.Context().Value(conf.ApiTokenKey).(*model.ApiToken); ok {
+		common.ErrorStrResp(c, "Not allowed with an api token", 403)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+}
+
 func AuthAdmin(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if !user.IsAdmin() {
@@ -0,0 +1,16 @@
+package middlewares
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Protocol records the protocol and the client ip of the request in the context,
+// they are used by the audit log
+func Protocol(protocol string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		common.GinWithValue(c, conf.ProtocolKey, protocol, conf.ClientIPKey, c.ClientIP())
+		c.Next()
+	}
+}
@@ -1,9 +1,18 @@
 package middlewares
 
 import (
+	"net/http"
+	"net/url"
+	"strconv"
+
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
 )
 
 func SharingIdParse(c *gin.Context) {
@@ -16,3 +25,79 @@ func EmptyPathParse(c *gin.Context) {
 	common.GinWithValue(c, conf.PathKey, "/")
 	c.Next()
 }
+
+// formOverhead is the room for the boundaries and the headers of the parts in the body of a form
+const formOverhead = 1 << 20
+
+// SharingUp checks the file uploaded to an upload sharing,
+// and resolves the path to put it to for handles.FsStream and handles.FsForm.
+// The file is put by the creator of the sharing, so that the quotas, the locks and the audit apply to the creator.
+func SharingUp(c *gin.Context) {
+	sid := c.Request.Context().Value(conf.SharingIDKey).(string)
+	// the upload is accounted when it's done, so it can't be a task
+	if c.GetHeader("As-Task") == "true" {
+		common.ErrorStrResp(c, "uploading to a sharing can't be a task", 400)
+		c.Abort()
+		return
+	}
+	name, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		c.Abort()
+		return
+	}
+	isForm := c.ContentType() == binding.MIMEMultipartPOSTForm
+	// the declared size is checked by sharing.UploadPath, the body is limited in case it's larger
+	if s, err := op.GetSharingById(sid); err == nil && s.UploadMaxSize > 0 {
+		limit := s.UploadMaxSize
+		if isForm {
+			limit += formOverhead
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	}
+	size := c.Request.ContentLength
+	if isForm {
+		// the parsed form is kept for handles.FsForm
+		file, err := c.FormFile("file")
+		if err != nil {
+			code := 400
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				code = 413
+			}
+			common.ErrorResp(c, err, code)
+			c.Abort()
+			return
+		}
+		size = file.Size
+	} else if size < 0 {
+		if sizeStr := c.GetHeader("X-File-Size"); sizeStr != "" {
+			if size, err = strconv.ParseInt(sizeStr, 10, 64); err != nil {
+				common.ErrorResp(c, err, 400)
+				c.Abort()
+				return
+			}
+		}
+	}
+	s, path, err := sharing.UploadPath(c.Request.Context(), sid, sharing.UploadArgs{
+		Name: name,
+		Size: size,
+		Pwd:  c.GetHeader("Password"),
+	})
+	if err != nil {
+		code := 400
+		if errors.Is(err, errs.SharingNotFound) {
+			code = 404
+		} else if errors.Is(err, errs.InvalidSharing) || errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.ObjectAlreadyExists) {
+			code = 403
+		}
+		common.ErrorResp(c, err, code)
+		c.Abort()
+		return
+	}
+	if !isForm && size >= 0 {
+		c.Request.Body = sharing.NewSizeReader(c.Request.Body, size)
+	}
+	common.GinWithValue(c, conf.SharingKey, s, conf.PathKey, path, conf.UserKey, s.Creator)
+	c.Next()
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/message"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -39,7 +40,7 @@ func Init(e *gin.Engine) {
 	if conf.Conf.MaxConnections > 0 {
 		g.Use(middlewares.MaxAllowed(conf.Conf.MaxConnections))
 	}
-	WebDav(g.Group("/dav"))
+	WebDav(g.Group("/dav", middlewares.Protocol(model.ProtocolWebDAV)))
 	S3(g.Group("/s3"))
 
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
@@ -65,20 +66,36 @@ func Init(e *gin.Engine) {
 	g.HEAD("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, handles.SharingArchiveExtract)
 	g.HEAD("/sad/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, handles.SharingArchiveExtract)
 
-	api := g.Group("/api")
+	api := g.Group("/api", middlewares.Protocol(model.ProtocolWeb))
 	auth := api.Group("", middlewares.Auth(false))
 	webauthn := api.Group("/authn", middlewares.Authn)
 
 	api.POST("/auth/login", handles.Login)
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
+	// account management is not allowed with api tokens
+	account := auth.Group("", middlewares.AuthNotApiToken)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
-	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
-	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
-	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	account.POST("/me/update", handles.UpdateCurrent)
+	account.GET("/me/sshkey/list", handles.ListMyPublicKey)
+	account.POST("/me/sshkey/add", handles.AddMyPublicKey)
+	account.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
+	account.GET("/me/tokens/list", handles.ListMyApiTokens)
+	account.POST("/me/tokens/create", handles.CreateMyApiToken)
+	account.POST("/me/tokens/update", handles.UpdateMyApiToken)
+	account.POST("/me/tokens/delete", handles.DeleteMyApiToken)
+	account.GET("/me/s3/list", handles.ListMyS3Credentials)
+	account.POST("/me/s3/create", handles.CreateMyS3Credential)
+	account.POST("/me/s3/update", handles.UpdateMyS3Credential)
+	account.POST("/me/s3/delete", handles.DeleteMyS3Credential)
+	account.GET("/me/notify/channels", handles.ListMyNotifyChannels)
+	account.GET("/me/notify/list", handles.ListMyNotifySubscriptions)
+	account.POST("/me/notify/create", handles.CreateMyNotifySubscription)
+	account.POST("/me/notify/update", handles.UpdateMyNotifySubscription)
+	account.POST("/me/notify/delete", handles.DeleteMyNotifySubscription)
+	account.POST("/me/notify/test", handles.TestMyNotifySubscription)
+	account.POST("/auth/2fa/generate", handles.Generate2FA)
+	account.POST("/auth/2fa/verify", handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
 
 	// auth
@@ -132,10 +149,78 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/del_cache", handles.DelUserCache)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
+	user.GET("/token/list", handles.ListApiTokens)
+	user.POST("/token/delete", handles.DeleteApiToken)
+	user.GET("/s3/list", handles.ListS3Credentials)
+	user.POST("/s3/delete", handles.DeleteS3Credential)
+
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.GET("/get", handles.GetGroup)
+	group.POST("/create", handles.CreateGroup)
+	group.POST("/update", handles.UpdateGroup)
+	group.POST("/delete", handles.DeleteGroup)
+
+	rule := g.Group("/path_rule")
+	rule.GET("/list", handles.ListPathRules)
+	rule.GET("/get", handles.GetPathRule)
+	rule.POST("/create", handles.CreatePathRule)
+	rule.POST("/update", handles.UpdatePathRule)
+	rule.POST("/delete", handles.DeletePathRule)
+
+	quota := g.Group("/quota")
+	quota.GET("/list", handles.ListQuotas)
+	quota.GET("/get", handles.GetQuota)
+	quota.POST("/create", handles.CreateQuota)
+	quota.POST("/update", handles.UpdateQuota)
+	quota.POST("/delete", handles.DeleteQuota)
+	quota.POST("/reconcile", handles.ReconcileQuotas)
+
+	trash := g.Group("/trash")
+	trash.GET("/list", handles.ListTrash)
+	trash.POST("/restore", handles.RestoreTrash)
+	trash.POST("/purge", handles.PurgeTrash)
+
+	syncJob := g.Group("/sync")
+	syncJob.GET("/list", handles.ListSyncJobs)
+	syncJob.GET("/get", handles.GetSyncJob)
+	syncJob.POST("/create", handles.CreateSyncJob)
+	syncJob.POST("/update", handles.UpdateSyncJob)
+	syncJob.POST("/delete", handles.DeleteSyncJob)
+	syncJob.POST("/run", handles.RunSyncJob)
+	syncJob.POST("/cancel", handles.CancelSyncJob)
+	syncJob.GET("/runs", handles.ListSyncRuns)
+
+	rss := g.Group("/rss")
+	rss.GET("/list", handles.ListRssSubscriptions)
+	rss.GET("/get", handles.GetRssSubscription)
+	rss.POST("/create", handles.CreateRssSubscription)
+	rss.POST("/update", handles.UpdateRssSubscription)
+	rss.POST("/delete", handles.DeleteRssSubscription)
+	rss.POST("/check", handles.CheckRssSubscription)
+	rss.GET("/items", handles.ListRssItems)
+
+	dedup := g.Group("/dedup")
+	dedup.POST("/scan", handles.StartDedupScan)
+	dedup.POST("/stop", handles.StopDedupScan)
+	dedup.GET("/status", handles.GetDedupStatus)
+	dedup.GET("/groups", handles.ListDedupGroups)
+	dedup.POST("/remove", handles.RemoveDuplicates)
+	dedup.POST("/link", handles.LinkDuplicates)
+
+	notifyChannel := g.Group("/notify")
+	notifyChannel.GET("/list", handles.ListNotifyChannels)
+	notifyChannel.POST("/create", handles.CreateNotifyChannel)
+	notifyChannel.POST("/update", handles.UpdateNotifyChannel)
+	notifyChannel.POST("/delete", handles.DeleteNotifyChannel)
+	notifyChannel.POST("/test", handles.TestNotifyChannel)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
 	storage.GET("/get", handles.GetStorage)
+	storage.GET("/health", handles.ListStorageHealth)
+	storage.GET("/health/history", handles.ListStorageHealthHistory)
+	storage.POST("/health/check", handles.CheckStorageHealth)
 	storage.POST("/create", handles.CreateStorage)
 	storage.POST("/update", handles.UpdateStorage)
 	storage.POST("/delete", handles.DeleteStorage)
@@ -158,6 +243,7 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/set_aria2", handles.SetAria2)
 	setting.POST("/set_qbit", handles.SetQbittorrent)
 	setting.POST("/set_transmission", handles.SetTransmission)
+	setting.POST("/set_bittorrent", handles.SetBitTorrent)
 	setting.POST("/set_115", handles.Set115)
 	setting.POST("/set_115_open", handles.Set115Open)
 	setting.POST("/set_123_pan", handles.Set123Pan)
@@ -181,6 +267,10 @@ func admin(g *gin.RouterGroup) {
 	index.POST("/clear", middlewares.SearchIndex, handles.ClearIndex)
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
 
+	audit := g.Group("/audit")
+	audit.GET("/list", handles.ListAuditLogs)
+	audit.GET("/export", handles.ExportAuditLogs)
+
 	scan := g.Group("/scan")
 	scan.POST("/start", handles.StartManualScan)
 	scan.POST("/stop", handles.StopManualScan)
@@ -193,6 +283,10 @@ func fsAndShare(g *gin.RouterGroup) {
 	a := g.Group("/archive")
 	a.Any("/meta", handles.FsArchiveMetaSplit)
 	a.Any("/list", handles.FsArchiveListSplit)
+	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
+	s := g.Group("/share/:sid", middlewares.SharingIdParse, middlewares.SharingUp)
+	s.PUT("/put", uploadLimiter, handles.FsStream)
+	s.PUT("/form", uploadLimiter, handles.FsForm)
 }
 
 func _fs(g *gin.RouterGroup) {
@@ -208,6 +302,9 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/copy", handles.FsCopy)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	g.Any("/versions/list", handles.FsVersionsList)
+	g.POST("/versions/restore", handles.FsVersionRestore)
+	g.POST("/versions/delete", handles.FsVersionsDelete)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
@@ -216,7 +313,10 @@ func _fs(g *gin.RouterGroup) {
 	// g.POST("/add_qbit", handles.AddQbittorrent)
 	// g.POST("/add_transmission", handles.SetTransmission)
 	g.POST("/add_offline_download", handles.AddOfflineDownload)
+	g.POST("/import_offline_download", handles.ImportOfflineDownload)
+	g.POST("/parse_torrent", handles.ParseTorrent)
 	g.POST("/archive/decompress", handles.FsArchiveDecompress)
+	g.POST("/archive/compress", handles.FsArchiveCompress)
 	// Direct upload (client-side upload to storage)
 	g.POST("/get_direct_upload_info", middlewares.FsUp, handles.FsGetDirectUploadInfo)
 }
@@ -233,6 +333,7 @@ func _sharing(g *gin.RouterGroup) {
 	g.POST("/delete", handles.DeleteSharing)
 	g.POST("/enable", handles.SetEnableSharing(false))
 	g.POST("/disable", handles.SetEnableSharing(true))
+	g.GET("/access/list", handles.ListSharingAccesses)
 }
 
 func Cors(r *gin.Engine) {
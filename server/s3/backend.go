@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"net/http"
 	"path"
 	"strings"
 	"sync"
@@ -23,6 +24,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/itsHenry35/gofakes3"
 	"github.com/ncw/swift/v2"
 	log "github.com/sirupsen/logrus"
@@ -39,8 +41,18 @@ type s3Backend struct {
 	meta *sync.Map
 }
 
+// checkPermission checks the permission of the user bound to the request on fp,
+// requests without a user are allowed as the buckets are configured by the admin
+func checkPermission(ctx context.Context, fp string, check func(permission int32) bool) error {
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok || common.HasPermission(user, fp, check) {
+		return nil
+	}
+	return errorWithStatus(ctx, "AccessDenied", "Access Denied", http.StatusForbidden)
+}
+
 // newBackend creates a new SimpleBucketBackend.
-func newBackend() gofakes3.Backend {
+func newBackend() *s3Backend {
 	return &s3Backend{
 		meta: new(sync.Map),
 	}
@@ -54,11 +66,19 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 	}
 	var response []gofakes3.BucketInfo
 	for _, b := range buckets {
-		node, _ := fs.Get(ctx, b.Path, &fs.GetArgs{})
+		// only the buckets the key can list are shown
+		bucket, err := getBucketByName(ctx, b.Name, model.S3BucketList)
+		if err != nil {
+			continue
+		}
+		creationDate := time.Now()
+		if node, err := fs.Get(ctx, bucket.Path, &fs.GetArgs{}); err == nil {
+			creationDate = node.ModTime()
+		}
 		response = append(response, gofakes3.BucketInfo{
 			// Name:         gofakes3.URLEncode(b.Name),
 			Name:         b.Name,
-			CreationDate: gofakes3.NewContentTime(node.ModTime()),
+			CreationDate: gofakes3.NewContentTime(creationDate),
 		})
 	}
 	return response, nil
@@ -66,11 +86,14 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 
 // ListBucket lists the objects in the given bucket.
 func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *gofakes3.Prefix, page gofakes3.ListBucketPage) (*gofakes3.ObjectList, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName, model.S3BucketList)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
+	if err = checkPermission(ctx, bucketPath, model.CanRead); err != nil {
+		return nil, err
+	}
 
 	if prefix == nil {
 		prefix = emptyPrefix
@@ -102,13 +125,16 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 //
 // Note that the metadata is not supported yet.
 func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName string) (*gofakes3.Object, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName, model.S3BucketRead)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if err = checkPermission(ctx, fp, model.CanRead); err != nil {
+		return nil, err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -145,13 +171,16 @@ func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName strin
 
 // GetObject fetchs the object from the filesystem.
 func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string, rangeRequest *gofakes3.ObjectRangeRequest) (s3Obj *gofakes3.Object, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName, model.S3BucketRead)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if err = checkPermission(ctx, fp, model.CanRead); err != nil {
+		return nil, err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -232,7 +261,7 @@ func (b *s3Backend) PutObject(
 	meta map[string]string,
 	input io.Reader, size int64,
 ) (result gofakes3.PutObjectResult, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName, model.S3BucketWrite)
 	if err != nil {
 		return result, err
 	}
@@ -251,6 +280,9 @@ func (b *s3Backend) PutObject(
 		reqPath = path.Dir(fp)
 	}
 	log.Debugf("reqPath: %s", reqPath)
+	if err = checkPermission(ctx, fp, model.CanWrite); err != nil {
+		return result, err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	ctx = context.WithValue(ctx, conf.MetaKey, fmeta)
 
@@ -303,6 +335,12 @@ func (b *s3Backend) PutObject(
 	}
 
 	err = fs.PutDirectly(ctx, reqPath, stream)
+	if errors.Is(err, errs.QuotaExceeded) {
+		return result, errorWithStatus(ctx, "QuotaExceeded", err.Error(), http.StatusForbidden)
+	}
+	if errors.Is(err, errs.ObjectLocked) {
+		return result, errLocked(ctx, err)
+	}
 	if err != nil {
 		return result, err
 	}
@@ -345,13 +383,16 @@ func (b *s3Backend) DeleteObject(ctx context.Context, bucketName, objectName str
 
 // deleteObject deletes the object from the filesystem.
 func (b *s3Backend) deleteObject(ctx context.Context, bucketName, objectName string) error {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName, model.S3BucketWrite)
 	if err != nil {
 		return err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if err = checkPermission(ctx, fp, model.CanRemove); err != nil {
+		return err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	// S3 does not report an error when attemping to delete a key that does not exist, so
 	// we need to skip IsNotExist errors.
@@ -359,7 +400,9 @@ func (b *s3Backend) deleteObject(ctx context.Context, bucketName, objectName str
 		return err
 	}
 
-	fs.Remove(ctx, fp)
+	if err = fs.Remove(ctx, fp); errors.Is(err, errs.ObjectLocked) {
+		return errLocked(ctx, err)
+	}
 	return nil
 }
 
@@ -394,7 +437,7 @@ func (b *s3Backend) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket
 		return result, nil
 	}
 
-	srcB, err := getBucketByName(srcBucket)
+	srcB, err := getBucketByName(ctx, srcBucket, model.S3BucketRead)
 	if err != nil {
 		return result, err
 	}
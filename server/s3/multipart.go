@@ -0,0 +1,603 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/itsHenry35/gofakes3"
+	"github.com/itsHenry35/gofakes3/signature"
+	xml "github.com/minio/xxml"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// gofakes3 keeps the parts of multipart uploads in memory and joins them into one buffer on completion,
+// so the multipart requests are handled here: the parts are staged in files under the temp dir
+// and streamed to the storage through the backend on completion.
+
+const (
+	multipartDirName = "s3-multipart"
+	uploadInfoName   = "upload.json"
+	// uploads without activity for this long are removed
+	multipartExpiry   = 24 * time.Hour
+	maxPartNumber     = 10000
+	defaultMaxUploads = 1000
+	defaultMaxParts   = 1000
+)
+
+type multipartUpload struct {
+	ID        string                 `json:"id"`
+	Bucket    string                 `json:"bucket"`
+	Key       string                 `json:"key"`
+	Meta      map[string]string      `json:"meta"`
+	UserId    uint                   `json:"user_id"` // 0 for the global key
+	Initiated time.Time              `json:"initiated"`
+	Parts     map[int]*multipartPart `json:"parts"`
+}
+
+type multipartPart struct {
+	ETag         string    `json:"etag"` // hex md5 of the part
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name          `xml:"InitiateMultipartUploadResult"`
+	Bucket   string            `xml:"Bucket"`
+	Key      string            `xml:"Key"`
+	UploadID gofakes3.UploadID `xml:"UploadId"`
+}
+
+// multipartMu protects the info files of the uploads
+var multipartMu sync.Mutex
+
+func multipartDir() string {
+	return filepath.Join(conf.Conf.TempDir, multipartDirName)
+}
+
+func (u *multipartUpload) dir() string {
+	return filepath.Join(multipartDir(), u.ID)
+}
+
+func (u *multipartUpload) partPath(number int) string {
+	return filepath.Join(u.dir(), "part-"+strconv.Itoa(number))
+}
+
+func (u *multipartUpload) lastActivity() time.Time {
+	t := u.Initiated
+	for _, p := range u.Parts {
+		if p.LastModified.After(t) {
+			t = p.LastModified
+		}
+	}
+	return t
+}
+
+func (u *multipartUpload) save() error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(u.dir(), uploadInfoName), data, 0o600)
+}
+
+func loadUpload(id string) (*multipartUpload, error) {
+	// the id becomes a directory name, so it must not be a path
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	data, err := os.ReadFile(filepath.Join(multipartDir(), id, uploadInfoName))
+	if err != nil {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	var u multipartUpload
+	if err = json.Unmarshal(data, &u); err != nil {
+		return nil, errors.WithMessagef(err, "failed parse info of upload [%s]", id)
+	}
+	if u.Parts == nil {
+		u.Parts = make(map[int]*multipartPart)
+	}
+	return &u, nil
+}
+
+// getUpload returns the upload if it belongs to the object and the user in ctx
+func getUpload(ctx context.Context, id, bucket, object string) (*multipartUpload, error) {
+	u, err := loadUpload(id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Bucket != bucket || u.Key != object || u.UserId != requestUserId(ctx) {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	return u, nil
+}
+
+func requestUserId(ctx context.Context) uint {
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		return user.ID
+	}
+	return 0
+}
+
+// withMultipart handles the multipart requests and passes the others to gofakes3
+func (s *server) withMultipart(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		uploadID := query.Get("uploadId")
+		_, uploads := query["uploads"]
+		if uploadID == "" && !uploads {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		// the same as the auth middleware of gofakes3
+		if s.authEnabled.Load() {
+			result := signature.V4SignVerify(r)
+			if result == signature.ErrUnsupportAlgorithm {
+				result = signature.V2SignVerify(r)
+			}
+			if result != signature.ErrNone {
+				resp := signature.GetAPIError(result)
+				w.Header().Add("content-type", "application/xml")
+				w.WriteHeader(resp.HTTPStatusCode)
+				_, _ = w.Write(signature.EncodeAPIErrorToResponse(resp))
+				return
+			}
+		}
+		bucket, object, _ := strings.Cut(strings.Trim(r.URL.Path, "/"), "/")
+		var err error
+		if uploadID != "" {
+			switch r.Method {
+			case http.MethodGet:
+				err = s.listParts(w, r, bucket, object, uploadID)
+			case http.MethodPut:
+				err = s.uploadPart(w, r, bucket, object, uploadID)
+			case http.MethodPost:
+				err = s.completeUpload(w, r, bucket, object, uploadID)
+			case http.MethodDelete:
+				err = s.abortUpload(w, r, bucket, object, uploadID)
+			default:
+				err = gofakes3.ErrMethodNotAllowed
+			}
+		} else {
+			switch r.Method {
+			case http.MethodGet:
+				err = s.listUploads(w, r, bucket)
+			case http.MethodPost:
+				err = s.createUpload(w, r, bucket, object)
+			default:
+				err = gofakes3.ErrMethodNotAllowed
+			}
+		}
+		if err != nil {
+			writeError(w, r, err)
+		}
+	})
+}
+
+func (s *server) createUpload(w http.ResponseWriter, r *http.Request, bucketName, object string) error {
+	if object == "" {
+		return gofakes3.ErrInvalidURI
+	}
+	ctx := r.Context()
+	bucket, err := getBucketByName(ctx, bucketName, model.S3BucketWrite)
+	if err != nil {
+		return err
+	}
+	if err = checkPermission(ctx, path.Join(bucket.Path, object), model.CanWrite); err != nil {
+		return err
+	}
+	u := &multipartUpload{
+		ID:        random.String(32),
+		Bucket:    bucketName,
+		Key:       object,
+		Meta:      metadataHeaders(r.Header),
+		UserId:    requestUserId(ctx),
+		Initiated: time.Now(),
+		Parts:     make(map[int]*multipartPart),
+	}
+	if err = os.MkdirAll(u.dir(), 0o700); err != nil {
+		return errors.WithStack(err)
+	}
+	if err = u.save(); err != nil {
+		_ = os.RemoveAll(u.dir())
+		return errors.WithStack(err)
+	}
+	log.Debugf("s3 multipart upload [%s] created for %s/%s", u.ID, bucketName, object)
+	return writeXML(w, initiateMultipartUploadResult{
+		Bucket:   bucketName,
+		Key:      object,
+		UploadID: gofakes3.UploadID(u.ID),
+	})
+}
+
+func (s *server) uploadPart(w http.ResponseWriter, r *http.Request, bucket, object, uploadID string) error {
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number < 1 || number > maxPartNumber {
+		return gofakes3.ErrInvalidPart
+	}
+	u, err := getUpload(r.Context(), uploadID, bucket, object)
+	if err != nil {
+		return err
+	}
+	var (
+		body io.Reader = r.Body
+		size           = r.ContentLength
+	)
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		body = newChunkedReader(r.Body)
+		size, err = strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+		if err != nil {
+			return gofakes3.ErrMissingContentLength
+		}
+	}
+	tmp, err := os.CreateTemp(u.dir(), "part-*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), body)
+	if err != nil {
+		return errors.WithMessage(err, "failed to receive part")
+	}
+	if size >= 0 && n != size {
+		return gofakes3.ErrIncompleteBody
+	}
+	sum := h.Sum(nil)
+	if contentMD5 := r.Header.Get("Content-MD5"); contentMD5 != "" && contentMD5 != base64.StdEncoding.EncodeToString(sum) {
+		return gofakes3.ErrBadDigest
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	multipartMu.Lock()
+	defer multipartMu.Unlock()
+	// reload, the upload may have been changed by other parts or aborted
+	if u, err = getUpload(r.Context(), uploadID, bucket, object); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), u.partPath(number)); err != nil {
+		return errors.WithStack(err)
+	}
+	etag := hex.EncodeToString(sum)
+	u.Parts[number] = &multipartPart{
+		ETag:         etag,
+		Size:         n,
+		LastModified: time.Now(),
+	}
+	if err = u.save(); err != nil {
+		return errors.WithStack(err)
+	}
+	w.Header().Set("ETag", `"`+etag+`"`)
+	return nil
+}
+
+func (s *server) completeUpload(w http.ResponseWriter, r *http.Request, bucket, object, uploadID string) (err error) {
+	var req gofakes3.CompleteMultipartUploadRequest
+	if err = xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		return gofakes3.ErrMalformedXML
+	}
+	if len(req.Parts) == 0 {
+		return gofakes3.ErrMalformedXML
+	}
+	// take the upload, so that it can't be completed or aborted twice
+	multipartMu.Lock()
+	u, err := getUpload(r.Context(), uploadID, bucket, object)
+	if err == nil {
+		dir, committing := u.dir(), u.dir()+".committing"
+		if err = os.Rename(dir, committing); err == nil {
+			committed := false
+			defer func() {
+				if committed {
+					_ = os.RemoveAll(committing)
+					return
+				}
+				// the completion can be retried
+				multipartMu.Lock()
+				defer multipartMu.Unlock()
+				if e := os.Rename(committing, dir); e != nil {
+					log.Errorf("failed restore s3 multipart upload [%s]: %+v", uploadID, e)
+				}
+			}()
+			u.ID += ".committing"
+			defer func() {
+				committed = err == nil
+			}()
+		}
+	}
+	multipartMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var (
+		readers []io.Reader
+		sums    []byte
+		size    int64
+	)
+	for i, p := range req.Parts {
+		if i > 0 && p.PartNumber <= req.Parts[i-1].PartNumber {
+			return gofakes3.ErrInvalidPartOrder
+		}
+		part, ok := u.Parts[p.PartNumber]
+		if !ok || strings.Trim(p.ETag, `"`) != part.ETag {
+			return gofakes3.ErrorMessagef(gofakes3.ErrInvalidPart, "part %d is not uploaded or its etag doesn't match", p.PartNumber)
+		}
+		f, err := os.Open(u.partPath(p.PartNumber))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer f.Close()
+		readers = append(readers, f)
+		sum, _ := hex.DecodeString(part.ETag)
+		sums = append(sums, sum...)
+		size += part.Size
+	}
+	if _, err = s.backend.PutObject(r.Context(), bucket, object, u.Meta, io.MultiReader(readers...), size); err != nil {
+		return err
+	}
+	etagSum := md5.Sum(sums)
+	log.Debugf("s3 multipart upload [%s] of %s/%s completed with %d parts", uploadID, bucket, object, len(req.Parts))
+	return writeXML(w, gofakes3.CompleteMultipartUploadResult{
+		Bucket: bucket,
+		Key:    object,
+		ETag:   `"` + hex.EncodeToString(etagSum[:]) + "-" + strconv.Itoa(len(req.Parts)) + `"`,
+	})
+}
+
+func (s *server) abortUpload(w http.ResponseWriter, r *http.Request, bucket, object, uploadID string) error {
+	multipartMu.Lock()
+	defer multipartMu.Unlock()
+	u, err := getUpload(r.Context(), uploadID, bucket, object)
+	if err != nil {
+		return err
+	}
+	if err = os.RemoveAll(u.dir()); err != nil {
+		return errors.WithStack(err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *server) listUploads(w http.ResponseWriter, r *http.Request, bucketName string) error {
+	ctx := r.Context()
+	if _, err := getBucketByName(ctx, bucketName, model.S3BucketList); err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	keyMarker, idMarker := query.Get("key-marker"), query.Get("upload-id-marker")
+	maxUploads := defaultMaxUploads
+	if v, err := strconv.Atoi(query.Get("max-uploads")); err == nil && v > 0 && v < maxUploads {
+		maxUploads = v
+	}
+	entries, err := os.ReadDir(multipartDir())
+	if err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	userId := requestUserId(ctx)
+	var uploads []*multipartUpload
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		u, err := loadUpload(e.Name())
+		// the uploads being committed have a suffix in their names
+		if err != nil || u.ID != e.Name() || u.Bucket != bucketName || u.UserId != userId || !strings.HasPrefix(u.Key, prefix) {
+			continue
+		}
+		if keyMarker != "" && (u.Key < keyMarker || u.Key == keyMarker && (idMarker == "" || u.ID <= idMarker)) {
+			continue
+		}
+		uploads = append(uploads, u)
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].Key != uploads[j].Key {
+			return uploads[i].Key < uploads[j].Key
+		}
+		return uploads[i].ID < uploads[j].ID
+	})
+	result := gofakes3.ListMultipartUploadsResult{
+		Bucket:         bucketName,
+		KeyMarker:      keyMarker,
+		UploadIDMarker: gofakes3.UploadID(idMarker),
+		MaxUploads:     int64(maxUploads),
+		Prefix:         prefix,
+	}
+	if len(uploads) > maxUploads {
+		uploads = uploads[:maxUploads]
+		result.IsTruncated = true
+		result.NextKeyMarker = uploads[maxUploads-1].Key
+		result.NextUploadIDMarker = gofakes3.UploadID(uploads[maxUploads-1].ID)
+	}
+	for _, u := range uploads {
+		result.Uploads = append(result.Uploads, gofakes3.ListMultipartUploadItem{
+			Key:       u.Key,
+			UploadID:  gofakes3.UploadID(u.ID),
+			Initiated: gofakes3.NewContentTime(u.Initiated),
+		})
+	}
+	return writeXML(w, result)
+}
+
+func (s *server) listParts(w http.ResponseWriter, r *http.Request, bucket, object, uploadID string) error {
+	u, err := getUpload(r.Context(), uploadID, bucket, object)
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+	maxParts := defaultMaxParts
+	if v, err := strconv.Atoi(query.Get("max-parts")); err == nil && v > 0 && v < maxParts {
+		maxParts = v
+	}
+	numbers := make([]int, 0, len(u.Parts))
+	for number := range u.Parts {
+		if number > marker {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	result := gofakes3.ListMultipartUploadPartsResult{
+		Bucket:           bucket,
+		Key:              object,
+		UploadID:         gofakes3.UploadID(uploadID),
+		PartNumberMarker: marker,
+		MaxParts:         int64(maxParts),
+	}
+	if len(numbers) > maxParts {
+		numbers = numbers[:maxParts]
+		result.IsTruncated = true
+		result.NextPartNumberMarker = numbers[maxParts-1]
+	}
+	for _, number := range numbers {
+		p := u.Parts[number]
+		result.Parts = append(result.Parts, gofakes3.ListMultipartUploadPartItem{
+			PartNumber:   number,
+			LastModified: gofakes3.NewContentTime(p.LastModified),
+			ETag:         `"` + p.ETag + `"`,
+			Size:         p.Size,
+		})
+	}
+	return writeXML(w, result)
+}
+
+// cleanupMultipartUploads removes the uploads that are neither completed nor aborted in time
+func cleanupMultipartUploads() {
+	entries, err := os.ReadDir(multipartDir())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("failed read s3 multipart dir: %+v", err)
+		}
+		return
+	}
+	multipartMu.Lock()
+	defer multipartMu.Unlock()
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		var last time.Time
+		if u, err := loadUpload(e.Name()); err == nil {
+			last = u.lastActivity()
+		} else if info, err := e.Info(); err == nil {
+			// a broken upload or one left committing by a crash
+			last = info.ModTime()
+		}
+		if time.Since(last) < multipartExpiry {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(multipartDir(), e.Name())); err != nil {
+			log.Errorf("failed remove stale s3 multipart upload [%s]: %+v", e.Name(), err)
+			continue
+		}
+		log.Infof("removed stale s3 multipart upload [%s]", e.Name())
+	}
+}
+
+// metadataHeaders keeps the headers that gofakes3 keeps as the metadata of an object
+func metadataHeaders(header http.Header) map[string]string {
+	meta := make(map[string]string)
+	for k, v := range header {
+		if strings.HasPrefix(k, "X-Amz-") || strings.HasPrefix(k, "Content-") || k == "Cache-Control" {
+			meta[k] = v[0]
+		}
+	}
+	return meta
+}
+
+func writeXML(w http.ResponseWriter, v any) error {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var resp *gofakes3.ErrorResponse
+	var s3Err gofakes3.Error
+	if !errors.As(err, &resp) {
+		if errors.As(err, &s3Err) {
+			resp = &gofakes3.ErrorResponse{Code: s3Err.ErrorCode(), Message: s3Err.ErrorCode().Message()}
+		} else {
+			resp = &gofakes3.ErrorResponse{Code: gofakes3.ErrInternal}
+		}
+	}
+	if resp.Code == gofakes3.ErrInternal {
+		log.Errorf("s3 multipart request %s %s failed: %+v", r.Method, r.URL.Path, err)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(resp.Code.Status())
+	if r.Method != http.MethodHead {
+		_, _ = w.Write([]byte(xml.Header))
+		_ = xml.NewEncoder(w).Encode(resp)
+	}
+}
+
+// chunkedReader decodes the aws-chunked body of streaming uploads,
+// the chunk signatures are not verified and the trailers are skipped
+type chunkedReader struct {
+	r      *bufio.Reader
+	remain int64
+	done   bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r)}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.remain == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		line = strings.TrimSpace(line)
+		// the line break after the data of a chunk
+		if line == "" {
+			continue
+		}
+		sizeStr, _, _ := strings.Cut(line, ";")
+		size, err := strconv.ParseInt(sizeStr, 16, 64)
+		if err != nil || size < 0 {
+			return 0, errors.Errorf("invalid chunk header: %q", line)
+		}
+		if size == 0 {
+			c.done = true
+			return 0, io.EOF
+		}
+		c.remain = size
+	}
+	if int64(len(p)) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.r.Read(p)
+	c.remain -= int64(n)
+	if err == io.EOF && c.remain > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
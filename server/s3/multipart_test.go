@@ -0,0 +1,29 @@
+package s3
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestChunkedReader(t *testing.T) {
+	sig := ";chunk-signature=" + strings.Repeat("0", 64)
+	tests := map[string]string{
+		"signed":   "5" + sig + "\r\nhello\r\n6" + sig + "\r\n world\r\n0" + sig + "\r\n\r\n",
+		"trailer":  "5\r\nhello\r\n6\r\n world\r\n0\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n",
+		"one read": "b" + sig + "\r\nhello world\r\n0" + sig + "\r\n\r\n",
+	}
+	for name, body := range tests {
+		got, err := io.ReadAll(newChunkedReader(strings.NewReader(body)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(got) != "hello world" {
+			t.Errorf("%s: got %q", name, got)
+		}
+	}
+	if _, err := io.ReadAll(newChunkedReader(strings.NewReader("a\r\nhello"))); err == nil {
+		t.Errorf("a truncated body should fail")
+	}
+}
@@ -6,22 +6,47 @@ import (
 	"context"
 	"math/rand"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
 	"github.com/itsHenry35/gofakes3"
 )
 
+var cleanupOnce sync.Once
+
+// server wraps gofakes3 with the parts it doesn't handle: the keys of users and multipart uploads
+type server struct {
+	faker   *gofakes3.GoFakeS3
+	backend *s3Backend
+	// whether the requests must be signed, gofakes3 checks it by the size of its key list
+	authEnabled atomic.Bool
+}
+
 // Make a new S3 Server to serve the remote
 func NewServer(ctx context.Context) (h http.Handler, err error) {
 	var newLogger logger
+	backend := newBackend()
+	authList := authlistResolver()
 	faker := gofakes3.New(
-		newBackend(),
+		backend,
 		// gofakes3.WithHostBucket(!opt.pathBucketMode),
 		gofakes3.WithLogger(newLogger),
 		gofakes3.WithRequestID(rand.Uint64()),
 		gofakes3.WithoutVersioning(),
-		gofakes3.WithV4Auth(authlistResolver()),
+		gofakes3.WithV4Auth(authList),
 		gofakes3.WithIntegrityCheck(true), // Check Content-MD5 if supplied
 	)
+	s := &server{
+		faker:   faker,
+		backend: backend,
+	}
+	s.authEnabled.Store(len(authList) > 0)
+	cleanupOnce.Do(func() {
+		cleanupMultipartUploads()
+		cron.NewCron(time.Hour).Do(cleanupMultipartUploads)
+	})
 
-	return faker.Server(), nil
+	return s.withCredential(withErrorStatus(s.withMultipart(faker.Server()))), nil
 }
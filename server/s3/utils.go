@@ -5,6 +5,7 @@ package s3
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -14,6 +15,8 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/itsHenry35/gofakes3"
+	"github.com/itsHenry35/gofakes3/signature"
+	log "github.com/sirupsen/logrus"
 )
 
 type Bucket struct {
@@ -29,15 +32,26 @@ func getAndParseBuckets() ([]Bucket, error) {
 	return res, err
 }
 
-func getBucketByName(name string) (Bucket, error) {
+// getBucketByName returns the bucket for doing action in it,
+// for the key of a user the access is checked and the path is resolved under the base path of the user
+func getBucketByName(ctx context.Context, name, action string) (Bucket, error) {
 	buckets, err := getAndParseBuckets()
 	if err != nil {
 		return Bucket{}, err
 	}
 	for _, b := range buckets {
-		if b.Name == name {
-			return b, nil
+		if b.Name != name {
+			continue
 		}
+		if cred, ok := ctx.Value(conf.S3CredentialKey).(*model.S3Credential); ok && !cred.Allowed(name, action) {
+			return Bucket{}, gofakes3.ErrorMessage("AccessDenied", "Access Denied")
+		}
+		if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+			if b.Path, err = user.JoinPath(b.Path); err != nil {
+				return Bucket{}, gofakes3.ErrorMessage("AccessDenied", "Access Denied")
+			}
+		}
+		return b, nil
 	}
 	return Bucket{}, gofakes3.BucketNotFound(name)
 }
@@ -150,13 +164,118 @@ func prefixParser(p *gofakes3.Prefix) (path, remaining string) {
 // 	}
 // }
 
+// authlistResolver returns the global key pair in the settings and the enabled key pairs of users.
+// The map is never nil, so that the keys of users created later can be added to it.
 func authlistResolver() map[string]string {
+	authList := make(map[string]string)
 	s3accesskeyid := setting.GetStr(conf.S3AccessKeyId)
 	s3secretaccesskey := setting.GetStr(conf.S3SecretAccessKey)
-	if s3accesskeyid == "" && s3secretaccesskey == "" {
-		return nil
+	if s3accesskeyid != "" || s3secretaccesskey != "" {
+		authList[s3accesskeyid] = s3secretaccesskey
+	}
+	creds, err := op.GetEnabledS3Credentials()
+	if err != nil {
+		log.Errorf("failed load s3 credentials: %+v", err)
+	}
+	for _, c := range creds {
+		authList[c.AccessKeyId] = c.SecretAccessKey
 	}
-	authList := make(map[string]string)
-	authList[s3accesskeyid] = s3secretaccesskey
 	return authList
 }
+
+// getAccessKey extracts the access key id from the signature of the request
+func getAccessKey(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		query := r.URL.Query()
+		if cred := query.Get("X-Amz-Credential"); cred != "" {
+			accessKey, _, _ := strings.Cut(cred, "/")
+			return accessKey
+		}
+		return query.Get("AWSAccessKeyId")
+	}
+	if strings.HasPrefix(auth, "AWS4-") {
+		_, cred, ok := strings.Cut(auth, "Credential=")
+		if !ok {
+			return ""
+		}
+		accessKey, _, _ := strings.Cut(cred, "/")
+		return accessKey
+	}
+	if v2, ok := strings.CutPrefix(auth, "AWS "); ok {
+		accessKey, _, _ := strings.Cut(v2, ":")
+		return accessKey
+	}
+	return ""
+}
+
+// withCredential binds the requests signed with the key of a user to the user,
+// the signature itself is verified afterwards
+func (s *server) withCredential(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessKey := getAccessKey(r)
+		if accessKey == "" || accessKey == setting.GetStr(conf.S3AccessKeyId) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		user, cred, err := op.GetUserByS3AccessKey(accessKey)
+		if err != nil {
+			log.Warnf("s3 access denied for key [%s]: %v", accessKey, err)
+			w.Header().Add("content-type", "application/xml")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write(signature.EncodeAPIErrorToResponse(signature.APIError{
+				Code:           "InvalidAccessKeyId",
+				Description:    "The access key ID you provided does not exist in our records.",
+				HTTPStatusCode: http.StatusForbidden,
+			}))
+			return
+		}
+		// the key may have been created after the server started
+		s.faker.AddAuthKeys(map[string]string{cred.AccessKeyId: cred.SecretAccessKey})
+		s.authEnabled.Store(true)
+		ctx := context.WithValue(r.Context(), conf.UserKey, user)
+		ctx = context.WithValue(ctx, conf.S3CredentialKey, cred)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type errorStatusKey struct{}
+
+// withErrorStatus responds the errors of the codes gofakes3 doesn't know with the status set by errorWithStatus,
+// gofakes3 responds them with 500
+func withErrorStatus(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := new(int)
+		ctx := context.WithValue(r.Context(), errorStatusKey{}, status)
+		handler.ServeHTTP(&errorStatusWriter{ResponseWriter: w, status: status}, r.WithContext(ctx))
+	})
+}
+
+type errorStatusWriter struct {
+	http.ResponseWriter
+	status *int
+}
+
+func (w *errorStatusWriter) WriteHeader(statusCode int) {
+	if statusCode == http.StatusInternalServerError && *w.status != 0 {
+		statusCode = *w.status
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *errorStatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// errorWithStatus returns the error of the code unknown to gofakes3, which is responded with the status
+func errorWithStatus(ctx context.Context, code gofakes3.ErrorCode, message string, status int) error {
+	if s, ok := ctx.Value(errorStatusKey{}).(*int); ok {
+		*s = status
+	}
+	return gofakes3.ErrorMessage(code, message)
+}
+
+// errLocked returns the error of an object locked by WebDAV, which S3 reports as a conflicting operation
+func errLocked(ctx context.Context, err error) error {
+	return errorWithStatus(ctx, "OperationAborted", err.Error(), http.StatusConflict)
+}
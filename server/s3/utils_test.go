@@ -0,0 +1,58 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+)
+
+func TestGetAccessKey(t *testing.T) {
+	tests := []struct {
+		url, auth string
+		want      string
+	}{
+		{"/bucket/key", "AWS4-HMAC-SHA256 Credential=OLKEY/20250101/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=abc", "OLKEY"},
+		{"/bucket/key", "AWS OLKEY:c2lnbmF0dXJl", "OLKEY"},
+		{"/bucket/key?X-Amz-Credential=OLKEY%2F20250101%2Fus-east-1%2Fs3%2Faws4_request&X-Amz-Signature=abc", "", "OLKEY"},
+		{"/bucket/key?AWSAccessKeyId=OLKEY&Signature=abc", "", "OLKEY"},
+		{"/bucket/key", "", ""},
+		{"/bucket/key", "Bearer token", ""},
+	}
+	for _, tt := range tests {
+		r, err := http.NewRequest(http.MethodGet, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.auth != "" {
+			r.Header.Set("Authorization", tt.auth)
+		}
+		if got := getAccessKey(r); got != tt.want {
+			t.Errorf("getAccessKey(%s, %q) = %q, want %q", tt.url, tt.auth, got, tt.want)
+		}
+	}
+}
+
+func TestWithErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  func(r *http.Request) error
+		want int
+	}{
+		{"quota", func(r *http.Request) error {
+			return errorWithStatus(r.Context(), "QuotaExceeded", errs.QuotaExceeded.Error(), http.StatusForbidden)
+		}, http.StatusForbidden},
+		{"internal", func(r *http.Request) error { return errs.NotImplement }, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		h := withErrorStatus(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, r, tt.err(r))
+		}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/bucket/key", nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
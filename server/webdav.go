@@ -23,7 +23,7 @@ var handler *webdav.Handler
 func WebDav(dav *gin.RouterGroup) {
 	handler = &webdav.Handler{
 		Prefix:     path.Join(conf.URL.Path, "/dav"),
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: webdav.NewDBLS(),
 		Logger: func(request *http.Request, err error) {
 			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
 		},
@@ -107,7 +107,15 @@ func WebDAVAuth(c *gin.Context) {
 	}
 	// at least auth is successful till here
 	model.LoginCache.Del(ip)
-	if user.Disabled || !user.CanWebdavRead() {
+	// the permission is resolved on the requested path, so that path rules apply
+	reqPath, err := user.JoinPath(strings.TrimPrefix(c.Request.URL.Path, handler.Prefix))
+	if err != nil {
+		c.Status(http.StatusForbidden)
+		c.Abort()
+		return
+	}
+	perm := op.GetUserPermission(user, reqPath)
+	if user.Disabled || !model.CanWebdavRead(perm) || !model.CanRead(perm) {
 		if c.Request.Method == "OPTIONS" {
 			common.GinWithValue(c, conf.UserKey, guest)
 			c.Next()
@@ -117,27 +125,27 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	if (c.Request.Method == "PUT" || c.Request.Method == "MKCOL") && (!user.CanWebdavManage() || !user.CanWrite()) {
+	if (c.Request.Method == "PUT" || c.Request.Method == "MKCOL") && (!model.CanWebdavManage(perm) || !model.CanWrite(perm)) {
 		c.Status(http.StatusForbidden)
 		c.Abort()
 		return
 	}
-	if c.Request.Method == "MOVE" && (!user.CanWebdavManage() || (!user.CanMove() && !user.CanRename())) {
+	if c.Request.Method == "MOVE" && (!model.CanWebdavManage(perm) || (!model.CanMove(perm) && !model.CanRename(perm))) {
 		c.Status(http.StatusForbidden)
 		c.Abort()
 		return
 	}
-	if c.Request.Method == "COPY" && (!user.CanWebdavManage() || !user.CanCopy()) {
+	if c.Request.Method == "COPY" && (!model.CanWebdavManage(perm) || !model.CanCopy(perm)) {
 		c.Status(http.StatusForbidden)
 		c.Abort()
 		return
 	}
-	if c.Request.Method == "DELETE" && (!user.CanWebdavManage() || !user.CanRemove()) {
+	if c.Request.Method == "DELETE" && (!model.CanWebdavManage(perm) || !model.CanRemove(perm)) {
 		c.Status(http.StatusForbidden)
 		c.Abort()
 		return
 	}
-	if c.Request.Method == "PROPPATCH" && !user.CanWebdavManage() {
+	if c.Request.Method == "PROPPATCH" && !model.CanWebdavManage(perm) {
 		c.Status(http.StatusForbidden)
 		c.Abort()
 		return
@@ -0,0 +1,105 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// the dead properties are stored in the database by the full virtual path of the resource,
+// patches of the same resource are serialized as they read and rewrite all its properties
+var patchMu sync.Mutex
+
+func loadDeadProps(name string) (map[xml.Name]Property, error) {
+	props, err := op.GetDavProps(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(props) == 0 {
+		return nil, nil
+	}
+	deadProps := make(map[xml.Name]Property, len(props))
+	for _, p := range props {
+		pn := xml.Name{Space: p.Space, Local: p.Local}
+		deadProps[pn] = Property{
+			XMLName:  pn,
+			Lang:     p.Lang,
+			InnerXML: []byte(p.InnerXML),
+		}
+	}
+	return deadProps, nil
+}
+
+// patchDeadProps applies the patches to the dead properties of resource name,
+// it's constrained in the same manner as DeadPropsHolder.Patch
+func patchDeadProps(name string, patches []Proppatch) ([]Propstat, error) {
+	patchMu.Lock()
+	defer patchMu.Unlock()
+	old, err := op.GetDavProps(name)
+	if err != nil {
+		return nil, err
+	}
+	// keep the order the properties were set in
+	var order []xml.Name
+	deadProps := make(map[xml.Name]model.DavProp, len(old))
+	for _, p := range old {
+		pn := xml.Name{Space: p.Space, Local: p.Local}
+		order = append(order, pn)
+		deadProps[pn] = p
+	}
+	pstat := Propstat{Status: http.StatusOK}
+	for _, patch := range patches {
+		for _, p := range patch.Props {
+			pstat.Props = append(pstat.Props, Property{XMLName: p.XMLName})
+			if patch.Remove {
+				delete(deadProps, p.XMLName)
+				continue
+			}
+			if _, ok := deadProps[p.XMLName]; !ok {
+				order = append(order, p.XMLName)
+			}
+			deadProps[p.XMLName] = model.DavProp{
+				Space:    p.XMLName.Space,
+				Local:    p.XMLName.Local,
+				Lang:     p.Lang,
+				InnerXML: string(p.InnerXML),
+			}
+		}
+	}
+	props := make([]model.DavProp, 0, len(deadProps))
+	for _, pn := range order {
+		if p, ok := deadProps[pn]; ok {
+			props = append(props, p)
+			delete(deadProps, pn)
+		}
+	}
+	if err = op.SetDavProps(name, props); err != nil {
+		return nil, err
+	}
+	return []Propstat{pstat}, nil
+}
+
+// handleObjEvent keeps the dead properties following their resources when they are
+// moved or removed through the fs layer, an overwritten file keeps its properties
+// as op.Put doesn't emit the events of the temp changes replacing it
+func handleObjEvent(ctx context.Context, event op.ObjEvent) {
+	var err error
+	switch event.Type {
+	case op.ObjEventDelete:
+		err = op.DeleteDavProps(event.Path)
+	case op.ObjEventMove:
+		err = op.MoveDavProps(event.Path, event.DstPath)
+	}
+	if err != nil {
+		log.Errorf("failed update dav props of %s: %+v", event.Path, err)
+	}
+}
+
+func init() {
+	op.RegisterObjEventHook(handleObjEvent)
+}
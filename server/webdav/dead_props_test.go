@@ -0,0 +1,82 @@
+package webdav
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPutKeepsDeadProps(t *testing.T) {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	conf.Conf = conf.DefaultConfig(t.TempDir())
+	db.Init(dB)
+	root := t.TempDir()
+	ctx := context.Background()
+	_, err = op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/dav",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/dav")
+	if err != nil {
+		t.Fatalf("failed to get storage: %+v", err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		// Finder and Office create an empty file, PROPPATCH it, then PUT the content
+		{name: "empty.txt", content: ""},
+		{name: "full.txt", content: "old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(filepath.Join(root, tt.name), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			path := "/dav/" + tt.name
+			props := []model.DavProp{{Space: "urn:test", Local: "color", InnerXML: "red"}}
+			if err := op.SetDavProps(path, props); err != nil {
+				t.Fatal(err)
+			}
+			body := "new content"
+			err := op.Put(ctx, storage, "/", &stream.FileStream{
+				Obj: &model.Object{
+					Name:     tt.name,
+					Size:     int64(len(body)),
+					Modified: time.Now(),
+				},
+				Reader: strings.NewReader(body),
+			}, nil)
+			if err != nil {
+				t.Fatalf("failed to put: %+v", err)
+			}
+			got, err := op.GetDavProps(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || got[0].Local != "color" || got[0].InnerXML != "red" {
+				t.Errorf("expected the props to be kept, got %+v", got)
+			}
+		})
+	}
+}
@@ -6,14 +6,17 @@ package webdav
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"path"
 	"path/filepath"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	log "github.com/sirupsen/logrus"
 )
 
 // slashClean is equivalent to but slightly more efficient than
@@ -25,6 +28,15 @@ func slashClean(name string) string {
 	return path.Clean(name)
 }
 
+// fsErrStatus returns the status of a failed move or copy, which is
+// StatusLocked if the src or the dst is locked by another lock.
+func fsErrStatus(err error) int {
+	if errors.Is(err, errs.ObjectLocked) {
+		return StatusLocked
+	}
+	return http.StatusInternalServerError
+}
+
 // moveFiles moves files and/or directories from src to dst.
 //
 // See section 9.9.4 for when various HTTP status codes apply.
@@ -34,10 +46,14 @@ func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	srcName := path.Base(src)
 	dstName := path.Base(dst)
 	user := ctx.Value(conf.UserKey).(*model.User)
-	if srcDir != dstDir && !user.CanMove() {
-		return http.StatusForbidden, nil
+	srcPerm := op.GetUserPermission(user, src)
+	if srcDir != dstDir {
+		dstPerm := op.GetUserPermission(user, dstDir)
+		if !model.CanMove(srcPerm) || !model.CanMove(dstPerm) || !model.CanWebdavManage(dstPerm) {
+			return http.StatusForbidden, nil
+		}
 	}
-	if srcName != dstName && !user.CanRename() {
+	if srcName != dstName && !model.CanRename(srcPerm) {
 		return http.StatusForbidden, nil
 	}
 	if srcDir == dstDir {
@@ -45,14 +61,14 @@ func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	} else {
 		_, err = fs.Move(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir)
 		if err != nil {
-			return http.StatusInternalServerError, err
+			return fsErrStatus(err), err
 		}
 		if srcName != dstName {
 			err = fs.Rename(ctx, path.Join(dstDir, srcName), dstName)
 		}
 	}
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return fsErrStatus(err), err
 	}
 	// TODO if there are no files copy, should return 204
 	return http.StatusCreated, nil
@@ -65,7 +81,11 @@ func copyFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	dstDir := path.Dir(dst)
 	_, err = fs.Copy(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir)
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return fsErrStatus(err), err
+	}
+	// the dead properties are copied as well, see section 9.8.2
+	if err = op.CopyDavProps(src, path.Join(dstDir, path.Base(src))); err != nil {
+		log.Errorf("failed copy dav props of %s: %+v", src, err)
 	}
 	// TODO if there are no files copy, should return 204
 	return http.StatusCreated, nil
@@ -109,6 +109,9 @@ type LockDetails struct {
 	// ZeroDepth is whether the lock has zero depth. If it does not have zero
 	// depth, it has infinite depth.
 	ZeroDepth bool
+	// UserId is the id of the user creating the lock, the lock doesn't
+	// refuse the write operations of the user through other protocols.
+	UserId uint
 }
 
 // NewMemLS returns a new in-memory LockSystem.
@@ -416,6 +419,15 @@ func (b *byExpiry) Pop() interface{} {
 
 const infiniteTimeout = -1
 
+const (
+	// requestLockTimeout is the timeout of the temporary locks taken by a request
+	// without the If header, so that the locks left by a crash don't last forever.
+	requestLockTimeout = time.Hour
+	// maxLockTimeout caps the timeout requested by clients, the locks are persisted
+	// and an infinite one would outlive its client.
+	maxLockTimeout = 24 * time.Hour
+)
+
 // parseTimeout parses the Timeout HTTP header, as per section 10.7. If s is
 // empty, an infiniteTimeout is returned.
 func parseTimeout(s string) (time.Duration, error) {
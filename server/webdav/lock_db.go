@@ -0,0 +1,161 @@
+package webdav
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/google/uuid"
+)
+
+// NewDBLS returns a new LockSystem keeping the locks in the database,
+// so that they survive restarts and are shared by the instances using
+// the same database. The locks are also checked by the fs layer for the
+// write operations of the other protocols.
+func NewDBLS() LockSystem {
+	return &dbLS{held: make(map[string]bool)}
+}
+
+type dbLS struct {
+	mu sync.Mutex
+	// held contains the tokens confirmed by the requests in progress,
+	// a request only holds the locks in this instance
+	held map[string]bool
+}
+
+func (m *dbLS) Confirm(now time.Time, name0, name1 string, conditions ...Condition) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var t0, t1 string
+	var err error
+	if name0 != "" {
+		if t0, err = m.lookup(now, slashClean(name0), conditions...); err != nil {
+			return nil, err
+		}
+		if t0 == "" {
+			return nil, ErrConfirmationFailed
+		}
+	}
+	if name1 != "" {
+		if t1, err = m.lookup(now, slashClean(name1), conditions...); err != nil {
+			return nil, err
+		}
+		if t1 == "" {
+			return nil, ErrConfirmationFailed
+		}
+	}
+
+	// Don't hold the same lock twice.
+	if t1 == t0 {
+		t1 = ""
+	}
+
+	if t0 != "" {
+		m.held[t0] = true
+	}
+	if t1 != "" {
+		m.held[t1] = true
+	}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.held, t0)
+		delete(m.held, t1)
+	}, nil
+}
+
+// lookup returns the token of the lock that locks the named resource, provided
+// that the lock matches at least one of the given conditions and that it isn't
+// held by another request. Otherwise, it returns an empty token.
+func (m *dbLS) lookup(now time.Time, name string, conditions ...Condition) (string, error) {
+	// TODO: support Condition.Not and Condition.ETag.
+	for _, c := range conditions {
+		if c.Token == "" || m.held[c.Token] {
+			continue
+		}
+		l, err := op.GetDavLockByToken(c.Token, now)
+		if err != nil {
+			return "", err
+		}
+		if l == nil {
+			continue
+		}
+		if name == l.Root {
+			return l.Token, nil
+		}
+		if l.ZeroDepth {
+			continue
+		}
+		if l.Root == "/" || strings.HasPrefix(name, l.Root+"/") {
+			return l.Token, nil
+		}
+	}
+	return "", nil
+}
+
+func (m *dbLS) Create(now time.Time, details LockDetails) (string, error) {
+	l := &model.DavLock{
+		Token:     "opaquelocktoken:" + uuid.NewString(),
+		Root:      slashClean(details.Root),
+		ZeroDepth: details.ZeroDepth,
+		OwnerXML:  details.OwnerXML,
+		UserId:    details.UserId,
+	}
+	l.SetDuration(now, details.Duration)
+	if err := op.CreateDavLock(l, now); err != nil {
+		if errors.Is(err, errs.ObjectLocked) {
+			return "", ErrLocked
+		}
+		return "", err
+	}
+	return l.Token, nil
+}
+
+func (m *dbLS) Refresh(now time.Time, token string, duration time.Duration) (LockDetails, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, err := op.GetDavLockByToken(token, now)
+	if err != nil {
+		return LockDetails{}, err
+	}
+	if l == nil {
+		return LockDetails{}, ErrNoSuchLock
+	}
+	if m.held[token] {
+		return LockDetails{}, ErrLocked
+	}
+	l.SetDuration(now, duration)
+	if err = op.UpdateDavLock(l); err != nil {
+		return LockDetails{}, err
+	}
+	return LockDetails{
+		Root:      l.Root,
+		Duration:  l.Duration,
+		OwnerXML:  l.OwnerXML,
+		ZeroDepth: l.ZeroDepth,
+		UserId:    l.UserId,
+	}, nil
+}
+
+func (m *dbLS) Unlock(now time.Time, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, err := op.GetDavLockByToken(token, now)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return ErrNoSuchLock
+	}
+	if m.held[token] {
+		return ErrLocked
+	}
+	return op.DeleteDavLockByToken(token)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 )
@@ -103,11 +104,12 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Handler) lock(now time.Time, root string) (token string, status int, err error) {
+func (h *Handler) lock(now time.Time, root string, userId uint) (token string, status int, err error) {
 	token, err = h.LockSystem.Create(now, LockDetails{
 		Root:      root,
-		Duration:  infiniteTimeout,
+		Duration:  requestLockTimeout,
 		ZeroDepth: true,
+		UserId:    userId,
 	})
 	if err != nil {
 		if err == ErrLocked {
@@ -118,7 +120,9 @@ func (h *Handler) lock(now time.Time, root string) (token string, status int, er
 	return token, 0, nil
 }
 
+// confirmLocks confirms the locks of src and dst, which are the full virtual paths
 func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func(), status int, err error) {
+	user := r.Context().Value(conf.UserKey).(*model.User)
 	hdr := r.Header.Get("If")
 	if hdr == "" {
 		// An empty If header means that the client hasn't previously created locks.
@@ -128,13 +132,13 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 		// locks are unlocked at the end of the HTTP request.
 		now, srcToken, dstToken := time.Now(), "", ""
 		if src != "" {
-			srcToken, status, err = h.lock(now, src)
+			srcToken, status, err = h.lock(now, src, user.ID)
 			if err != nil {
 				return nil, status, err
 			}
 		}
 		if dst != "" {
-			dstToken, status, err = h.lock(now, dst)
+			dstToken, status, err = h.lock(now, dst, user.ID)
 			if err != nil {
 				if srcToken != "" {
 					h.LockSystem.Unlock(now, srcToken)
@@ -174,6 +178,9 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 			if err != nil {
 				return nil, status, err
 			}
+			if lsrc, err = user.JoinPath(lsrc); err != nil {
+				return nil, http.StatusForbidden, err
+			}
 		}
 		release, err = h.LockSystem.Confirm(time.Now(), lsrc, dst, l.conditions...)
 		if err == ErrConfirmationFailed {
@@ -286,18 +293,17 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
 	reqPath, err = user.JoinPath(reqPath)
 	if err != nil {
 		return 403, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 	// TODO: return MultiStatus where appropriate.
 
 	// "godoc os RemoveAll" says that "If the path does not exist, RemoveAll
@@ -310,6 +316,9 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 		return http.StatusMethodNotAllowed, err
 	}
 	if err := fs.Remove(ctx, reqPath); err != nil {
+		if errors.Is(err, errs.ObjectLocked) {
+			return StatusLocked, err
+		}
 		return http.StatusMethodNotAllowed, err
 	}
 	//fs.ClearCache(path.Dir(reqPath))
@@ -330,11 +339,6 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if reqPath == "" {
 		return http.StatusMethodNotAllowed, nil
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
 	// TODO(rost): Support the If-Match, If-None-Match headers? See bradfitz'
 	// comments in http.checkEtag.
 	ctx := r.Context()
@@ -343,6 +347,11 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if err != nil {
 		return http.StatusForbidden, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 	size := r.ContentLength
 	if size < 0 {
 		sizeStr := r.Header.Get("X-File-Size")
@@ -375,6 +384,12 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if errs.IsNotFoundError(err) {
 		return http.StatusNotFound, err
 	}
+	if errors.Is(err, errs.QuotaExceeded) {
+		return http.StatusInsufficientStorage, err
+	}
+	if errors.Is(err, errs.ObjectLocked) {
+		return StatusLocked, err
+	}
 
 	// TODO(rost): Returning 405 Method Not Allowed might not be appropriate.
 	if err != nil {
@@ -397,18 +412,17 @@ func (h *Handler) handleMkcol(w http.ResponseWriter, r *http.Request) (status in
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
 	reqPath, err = user.JoinPath(reqPath)
 	if err != nil {
 		return 403, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 
 	if r.ContentLength > 0 {
 		return http.StatusUnsupportedMediaType, nil
@@ -479,6 +493,11 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 	}
 
 	if r.Method == "COPY" {
+		// the source is checked by the auth middleware, the destination is checked here
+		dstPerm := op.GetUserPermission(user, path.Dir(dst))
+		if !model.CanWebdavManage(dstPerm) || !model.CanCopy(dstPerm) {
+			return http.StatusForbidden, nil
+		}
 		// Section 7.5.1 says that a COPY only needs to lock the destination,
 		// not both destination and source. Strictly speaking, this is racy,
 		// even though a COPY doesn't modify the source, if a concurrent
@@ -526,6 +545,9 @@ func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
+	if duration < 0 || duration > maxLockTimeout {
+		duration = maxLockTimeout
+	}
 	li, status, err := readLockInfo(r.Body)
 	if err != nil {
 		return status, err
@@ -579,6 +601,7 @@ func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus
 			Duration:  duration,
 			OwnerXML:  li.Owner.InnerXML,
 			ZeroDepth: depth == 0,
+			UserId:    user.ID,
 		}
 		token, err = h.LockSystem.Create(now, ld)
 		if err != nil {
@@ -684,7 +707,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, reqPath, info)
 			if err != nil {
 				return err
 			}
@@ -694,9 +717,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		}
 		if err != nil {
 			return err
@@ -724,18 +747,17 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
 	reqPath, err = user.JoinPath(reqPath)
 	if err != nil {
 		return 403, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 	if _, err := fs.Get(ctx, reqPath, &fs.GetArgs{}); err != nil {
 		if errs.IsObjectNotFound(err) {
 			return http.StatusNotFound, err